@@ -43,6 +43,28 @@ type SeedGenerator struct {
 type SeedGeneratorSpec struct {
 	SeedImage   string `json:"seedImage,omitempty"`
 	RecertImage string `json:"recertImage,omitempty"`
+	// AdditionalSeedImages lists extra destinations (e.g. additional tags or mirror registries) to which the seed
+	// image is pushed, in addition to SeedImage. The seed image is only built once.
+	AdditionalSeedImages []SeedImageDestination `json:"additionalSeedImages,omitempty"`
+	// OvnShutdownTimeout bounds the wait for the ovnkube-node pods to stop while shutting down the cluster
+	// (e.g. 10m). If unset, the SEEDGEN_OVN_SHUTDOWN_TIMEOUT environment variable of the operator is used, if set,
+	// or a 5 minutes default otherwise.
+	OvnShutdownTimeout *metav1.Duration `json:"ovnShutdownTimeout,omitempty"`
+	// ContainersStopTimeout bounds the wait for all running containers to stop while shutting down the cluster
+	// (e.g. 10m). If unset, the SEEDGEN_CONTAINERS_STOP_TIMEOUT environment variable of the operator is used, if
+	// set, or a 5 minutes default otherwise.
+	ContainersStopTimeout *metav1.Duration `json:"containersStopTimeout,omitempty"`
+}
+
+// SeedImageDestination defines an additional destination for the generated seed image
+type SeedImageDestination struct {
+	// +kubebuilder:validation:Required
+	// +required
+	Image string `json:"image"`
+
+	// AuthSecretKey is the key in the seedgen secret holding the auth file used to push to this destination.
+	// If empty, the seedAuth credentials are used.
+	AuthSecretKey string `json:"authSecretKey,omitempty"`
 }
 
 // SeedGeneratorStatus defines the observed state of SeedGenerator
@@ -53,6 +75,18 @@ type SeedGeneratorStatus struct {
 	CompletedAt        metav1.Time `json:"completedAt,omitempty"`
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions"
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Push Status"
+	PushStatus []SeedImagePushStatus `json:"pushStatus,omitempty"`
+	// BlockingComponent is the component that blocked the seed generation by not stopping in time, if any
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Blocking Component"
+	BlockingComponent string `json:"blockingComponent,omitempty"`
+}
+
+// SeedImagePushStatus reports the result of pushing the seed image to one of its destinations
+type SeedImagePushStatus struct {
+	Image   string `json:"image"`
+	Pushed  bool   `json:"pushed"`
+	Message string `json:"message,omitempty"`
 }
 
 //+kubebuilder:object:root=true
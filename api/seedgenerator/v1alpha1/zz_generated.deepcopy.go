@@ -30,7 +30,7 @@ func (in *SeedGenerator) DeepCopyInto(out *SeedGenerator) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -87,6 +87,21 @@ func (in *SeedGeneratorList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SeedGeneratorSpec) DeepCopyInto(out *SeedGeneratorSpec) {
 	*out = *in
+	if in.AdditionalSeedImages != nil {
+		in, out := &in.AdditionalSeedImages, &out.AdditionalSeedImages
+		*out = make([]SeedImageDestination, len(*in))
+		copy(*out, *in)
+	}
+	if in.OvnShutdownTimeout != nil {
+		in, out := &in.OvnShutdownTimeout, &out.OvnShutdownTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ContainersStopTimeout != nil {
+		in, out := &in.ContainersStopTimeout, &out.ContainersStopTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedGeneratorSpec.
@@ -111,6 +126,11 @@ func (in *SeedGeneratorStatus) DeepCopyInto(out *SeedGeneratorStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PushStatus != nil {
+		in, out := &in.PushStatus, &out.PushStatus
+		*out = make([]SeedImagePushStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedGeneratorStatus.
@@ -122,3 +142,33 @@ func (in *SeedGeneratorStatus) DeepCopy() *SeedGeneratorStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedImageDestination) DeepCopyInto(out *SeedImageDestination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedImageDestination.
+func (in *SeedImageDestination) DeepCopy() *SeedImageDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedImageDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedImagePushStatus) DeepCopyInto(out *SeedImagePushStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedImagePushStatus.
+func (in *SeedImagePushStatus) DeepCopy() *SeedImagePushStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedImagePushStatus)
+	in.DeepCopyInto(out)
+	return out
+}
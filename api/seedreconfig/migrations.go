@@ -0,0 +1,79 @@
+package seedreconfig
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// migration converts a decoded SeedReconfiguration of a given version into the
+// format of the next version
+type migration func(raw map[string]any) error
+
+// migrations holds, for each older SeedReconfiguration version, the migration
+// to the next version. A breaking change incrementing
+// SeedReconfigurationVersion must add the migration from the previous
+// version here.
+var migrations = map[int]migration{
+	// Version 1 has no cluster_networks and service_networks, which keep the
+	// seed cluster networks when empty
+	1: func(map[string]any) error { return nil },
+}
+
+// Decode decodes a SeedReconfiguration in YAML or JSON format, of any
+// supported version, and converts it to the current version.
+func Decode(data []byte) (*SeedReconfiguration, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode seed reconfiguration: %w", err)
+	}
+
+	raw := map[string]any{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode seed reconfiguration: %w", err)
+	}
+
+	version, err := apiVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+	if version > SeedReconfigurationVersion {
+		return nil, fmt.Errorf("unsupported seed reconfiguration version %d, the latest supported version is %d",
+			version, SeedReconfigurationVersion)
+	}
+
+	for ; version < SeedReconfigurationVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("unsupported seed reconfiguration version %d", version)
+		}
+		if err := migrate(raw); err != nil {
+			return nil, fmt.Errorf("failed to migrate seed reconfiguration from version %d to %d: %w",
+				version, version+1, err)
+		}
+	}
+	raw["api_version"] = SeedReconfigurationVersion
+
+	if data, err = json.Marshal(raw); err != nil {
+		return nil, fmt.Errorf("failed to encode migrated seed reconfiguration: %w", err)
+	}
+	seedReconfiguration := &SeedReconfiguration{}
+	if err := json.Unmarshal(data, seedReconfiguration); err != nil {
+		return nil, fmt.Errorf("failed to decode seed reconfiguration: %w", err)
+	}
+	return seedReconfiguration, nil
+}
+
+// apiVersion returns the api_version of a decoded SeedReconfiguration
+func apiVersion(raw map[string]any) (int, error) {
+	value, ok := raw["api_version"]
+	if !ok {
+		return 0, fmt.Errorf("seed reconfiguration api_version is missing")
+	}
+	version, ok := value.(float64)
+	if !ok || version != float64(int(version)) || version < 1 {
+		return 0, fmt.Errorf("invalid seed reconfiguration api_version %v", value)
+	}
+	return int(version), nil
+}
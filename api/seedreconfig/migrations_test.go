@@ -0,0 +1,83 @@
+package seedreconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	testcases := []struct {
+		name          string
+		data          string
+		expected      *SeedReconfiguration
+		expectedError string
+	}{
+		{
+			name: "Current version",
+			data: `{"api_version": 2, "cluster_name": "sno", "base_domain": "redhat.com",
+				"service_networks": ["172.30.0.0/16"]}`,
+			expected: &SeedReconfiguration{
+				APIVersion:      SeedReconfigurationVersion,
+				ClusterName:     "sno",
+				BaseDomain:      "redhat.com",
+				ServiceNetworks: []string{"172.30.0.0/16"},
+			},
+		},
+		{
+			name: "Version 1 is migrated",
+			data: `{"api_version": 1, "cluster_name": "sno", "node_ip": "192.168.127.10"}`,
+			expected: &SeedReconfiguration{
+				APIVersion:  SeedReconfigurationVersion,
+				ClusterName: "sno",
+				NodeIP:      "192.168.127.10",
+			},
+		},
+		{
+			name: "YAML",
+			data: "api_version: 1\nhostname: sno\n",
+			expected: &SeedReconfiguration{
+				APIVersion: SeedReconfigurationVersion,
+				Hostname:   "sno",
+			},
+		},
+		{
+			name:          "Newer version",
+			data:          `{"api_version": 3}`,
+			expectedError: "unsupported seed reconfiguration version 3",
+		},
+		{
+			name:          "Missing version",
+			data:          `{"cluster_name": "sno"}`,
+			expectedError: "api_version is missing",
+		},
+		{
+			name:          "Invalid version",
+			data:          `{"api_version": "2"}`,
+			expectedError: "invalid seed reconfiguration api_version 2",
+		},
+		{
+			name:          "Invalid data",
+			data:          `{"api_version": 2`,
+			expectedError: "failed to decode seed reconfiguration",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			seedReconfiguration, err := Decode([]byte(tc.data))
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, seedReconfiguration)
+		})
+	}
+}
+
+func TestMigrationsCoverAllVersions(t *testing.T) {
+	for version := 1; version < SeedReconfigurationVersion; version++ {
+		assert.Contains(t, migrations, version, "missing migration from version %d", version)
+	}
+}
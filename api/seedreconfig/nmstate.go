@@ -0,0 +1,118 @@
+package seedreconfig
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"sigs.k8s.io/yaml"
+)
+
+// nmstateConfig is the part of an NMState document that is validated offline
+type nmstateConfig struct {
+	Interfaces []nmstateInterface `json:"interfaces,omitempty"`
+}
+
+type nmstateInterface struct {
+	Name  string          `json:"name"`
+	Type  string          `json:"type,omitempty"`
+	State string          `json:"state,omitempty"`
+	IPv4  *nmstateIPStack `json:"ipv4,omitempty"`
+	IPv6  *nmstateIPStack `json:"ipv6,omitempty"`
+}
+
+type nmstateIPStack struct {
+	Enabled  bool             `json:"enabled,omitempty"`
+	DHCP     bool             `json:"dhcp,omitempty"`
+	Autoconf bool             `json:"autoconf,omitempty"`
+	Address  []nmstateAddress `json:"address,omitempty"`
+}
+
+type nmstateAddress struct {
+	IP           string `json:"ip"`
+	PrefixLength int    `json:"prefix-length"`
+}
+
+// ValidateNMStateConfig parses and checks an NMState document offline: the interfaces must be named once, with a
+// known state, and their static addresses must be valid IPs of the family of their stack. Each of the node IPs must be
+// a static address of an interface that is up, unless an interface that is up gets its addresses of the node IP
+// family dynamically, as they can't be known offline.
+func ValidateNMStateConfig(rawNMStateConfig string, nodeIPs ...string) error {
+	config := &nmstateConfig{}
+	if err := yaml.Unmarshal([]byte(rawNMStateConfig), config); err != nil {
+		return fmt.Errorf("failed to parse nmstate config: %w", err)
+	}
+
+	var errs []error
+	names := map[string]bool{}
+	staticIPs := map[string]bool{}
+	dynamic := map[bool]bool{}
+	for i, iface := range config.Interfaces {
+		if iface.Name == "" {
+			errs = append(errs, fmt.Errorf("interface %d has no name", i))
+			continue
+		}
+		if names[iface.Name] {
+			errs = append(errs, fmt.Errorf("interface %s is defined more than once", iface.Name))
+		}
+		names[iface.Name] = true
+
+		switch iface.State {
+		case "", "up", "down", "absent", "ignore":
+		default:
+			errs = append(errs, fmt.Errorf("interface %s has an unknown state %q", iface.Name, iface.State))
+		}
+		up := iface.State == "" || iface.State == "up"
+
+		for _, stack := range []struct {
+			isIPv4 bool
+			config *nmstateIPStack
+		}{{true, iface.IPv4}, {false, iface.IPv6}} {
+			if stack.config == nil || !stack.config.Enabled {
+				continue
+			}
+			if up && (stack.config.DHCP || stack.config.Autoconf) {
+				dynamic[stack.isIPv4] = true
+			}
+			for _, address := range stack.config.Address {
+				ip := net.ParseIP(address.IP)
+				if ip == nil || (ip.To4() != nil) != stack.isIPv4 {
+					errs = append(errs, fmt.Errorf("interface %s has an invalid %s address %q",
+						iface.Name, ipFamilyName(stack.isIPv4), address.IP))
+					continue
+				}
+				bits := 128
+				if stack.isIPv4 {
+					bits = 32
+				}
+				if address.PrefixLength < 0 || address.PrefixLength > bits {
+					errs = append(errs, fmt.Errorf("interface %s has an invalid prefix-length %d for %s",
+						iface.Name, address.PrefixLength, address.IP))
+				}
+				if up {
+					staticIPs[ip.String()] = true
+				}
+			}
+		}
+	}
+
+	for _, nodeIP := range nodeIPs {
+		ip := net.ParseIP(nodeIP)
+		if ip == nil {
+			// reported by Validate
+			continue
+		}
+		if !staticIPs[ip.String()] && !dynamic[ip.To4() != nil] {
+			errs = append(errs, fmt.Errorf("node IP %s is not an address of any interface that is up", nodeIP))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func ipFamilyName(isIPv4 bool) string {
+	if isIPv4 {
+		return "ipv4"
+	}
+	return "ipv6"
+}
@@ -0,0 +1,111 @@
+package seedreconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testNMStateConfig = `interfaces:
+- name: enp1s0
+  type: ethernet
+  state: up
+  ipv4:
+    enabled: true
+    address:
+    - ip: 192.168.127.10
+      prefix-length: 24
+  ipv6:
+    enabled: false
+- name: enp2s0
+  type: ethernet
+  state: down
+  ipv4:
+    enabled: true
+    address:
+    - ip: 10.0.0.10
+      prefix-length: 24
+routes:
+  config:
+  - destination: 0.0.0.0/0
+    next-hop-address: 192.168.127.1
+    next-hop-interface: enp1s0
+`
+
+func TestValidateNMStateConfig(t *testing.T) {
+	testcases := []struct {
+		name           string
+		config         string
+		nodeIPs        []string
+		expectedErrors []string
+	}{
+		{
+			name:    "Node IP is a static address",
+			config:  testNMStateConfig,
+			nodeIPs: []string{"192.168.127.10"},
+		},
+		{
+			name:    "Node IP is not an address of an interface that is up",
+			config:  testNMStateConfig,
+			nodeIPs: []string{"10.0.0.10", "fd00::10"},
+			expectedErrors: []string{
+				"node IP 10.0.0.10 is not an address of any interface that is up",
+				"node IP fd00::10 is not an address of any interface that is up",
+			},
+		},
+		{
+			name: "Node IP of a family with DHCP",
+			config: `interfaces:
+- name: enp1s0
+  ipv4:
+    enabled: true
+    dhcp: true
+  ipv6:
+    enabled: true
+    autoconf: true
+`,
+			nodeIPs: []string{"192.168.127.10", "fd00::10"},
+		},
+		{
+			name: "Invalid interfaces",
+			config: `interfaces:
+- type: ethernet
+- name: enp1s0
+  state: started
+- name: enp1s0
+  ipv4:
+    enabled: true
+    address:
+    - ip: fd00::10
+      prefix-length: 64
+    - ip: 192.168.127.10
+      prefix-length: 33
+`,
+			expectedErrors: []string{
+				"interface 0 has no name",
+				`interface enp1s0 has an unknown state "started"`,
+				"interface enp1s0 is defined more than once",
+				`interface enp1s0 has an invalid ipv4 address "fd00::10"`,
+				"interface enp1s0 has an invalid prefix-length 33 for 192.168.127.10",
+			},
+		},
+		{
+			name:           "Invalid YAML",
+			config:         "interfaces: [",
+			expectedErrors: []string{"failed to parse nmstate config"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateNMStateConfig(tc.config, tc.nodeIPs...)
+			if len(tc.expectedErrors) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, expectedError := range tc.expectedErrors {
+				assert.ErrorContains(t, err, expectedError)
+			}
+		})
+	}
+}
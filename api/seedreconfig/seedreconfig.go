@@ -3,7 +3,9 @@ package seedreconfig
 type PEM string
 
 const (
-	SeedReconfigurationVersion = 1
+	// Version 2 added the ClusterNetworks and ServiceNetworks fields. Version 1
+	// configurations are still accepted, they keep the seed cluster networks.
+	SeedReconfigurationVersion = 2
 )
 
 // SeedReconfiguration contains all the information that is required to
@@ -18,8 +20,10 @@ const (
 // lightly, as it is also used by the image-based-install-operator. Any changes
 // made here will also need to be handled in the image-based-install-operator
 // or be backwards compatible. If you've made a breaking change, you will need
-// to increment the SeedReconfigVersion constant to avoid silent breakage and
-// allow for backwards compatibility code.
+// to increment the SeedReconfigurationVersion constant to avoid silent
+// breakage, and add the migration from the previous version to migrations so
+// that Decode keeps accepting it. Producers should check their output with
+// Validate.
 type SeedReconfiguration struct {
 	// The version of the SeedReconfiguration struct format. This is used to detect
 	// breaking changes to the struct.
@@ -49,6 +53,32 @@ type SeedReconfiguration struct {
 	// The desired IP address of the SNO node.
 	NodeIP string `json:"node_ip,omitempty"`
 
+	// The desired IP addresses of the SNO node, one per IP family on a
+	// dual-stack cluster, the primary one first. When set, NodeIP must be the
+	// first of them. When empty, the IPs of the other IP families are taken
+	// from the host addresses within MachineNetworks, if any. The IP families
+	// must match the ones of the seed cluster.
+	NodeIPs []string `json:"node_ips,omitempty"`
+
+	// The machine network CIDRs of the cluster, one per IP family on a
+	// dual-stack cluster. When a node IP isn't provided for every IP family,
+	// the missing ones are taken from the host addresses within these
+	// networks. When they differ from the seed cluster machine networks, they
+	// replace them in the cluster configuration.
+	MachineNetworks []string `json:"machine_networks,omitempty"`
+
+	// The desired cluster network of the cluster, the IP blocks pod IPs are
+	// allocated from, one per IP family on a dual-stack cluster. Equivalent
+	// to install-config.yaml's networking.clusterNetwork. When empty, the
+	// seed cluster network is kept.
+	ClusterNetworks []ClusterNetworkEntry `json:"cluster_networks,omitempty"`
+
+	// The desired service network CIDRs of the cluster, one per IP family on
+	// a dual-stack cluster. Equivalent to install-config.yaml's
+	// networking.serviceNetwork. When empty, the seed service network is
+	// kept.
+	ServiceNetworks []string `json:"service_networks,omitempty"`
+
 	// The container registry used to host the release image of the seed cluster.
 	ReleaseRegistry string `json:"release_registry,omitempty"`
 
@@ -69,6 +99,13 @@ type SeedReconfiguration struct {
 	// the SSH keys of the seed cluster.
 	SSHKey string `json:"ssh_key,omitempty"`
 
+	// SSHKeys are additional public SSH keys, one authorized_keys line each,
+	// to provide access to instances, along with the ones of SSHKey. During
+	// IBU, these are all the keys of the core user of the cluster that is
+	// being upgraded, from its authorized_keys.d files and from its SSH
+	// MachineConfigs, so that they all survive the upgrade.
+	SSHKeys []string `json:"ssh_keys,omitempty"`
+
 	// KubeadminPasswordHash is the hash of the password for the kubeadmin
 	// user, as can be found in the kubeadmin key of the kube-system/kubeadmin
 	// secret. This will replace the kubeadmin password of the seed cluster.
@@ -76,10 +113,13 @@ type SeedReconfiguration struct {
 	// because the secret is rejected by OCP if its creation timestamp differs
 	// from the kube-system namespace creation timestamp by more than 1 hour.
 	// During IBU, this is taken from the cluster that is being upgraded.
-	// During IBI, this is generated in advance so it can be displayed to the
-	// user. If empty, the kubeadmin password secret of the seed cluster will
-	// be deleted (thus disabling kubeadmin password login), to ensure we're
-	// not accepting a possibly compromised seed password.
+	// When the IBU rotates the kubeadmin password, this is the hash held by
+	// the secret the IBU references instead, and when the IBU disables
+	// kubeadmin, this is left empty. During IBI, this is generated in
+	// advance so it can be displayed to the user. If empty, the kubeadmin
+	// password secret of the seed cluster will be deleted (thus disabling
+	// kubeadmin password login), to ensure we're not accepting a possibly
+	// compromised seed password.
 	KubeadminPasswordHash string `json:"kubeadmin_password_hash,omitempty"`
 
 	// RawNMStateConfig contains nmstate configuration YAML file provided as string.
@@ -90,6 +130,116 @@ type SeedReconfiguration struct {
 
 	// PullSecret is the secret to use when pulling images. Equivalent to install-config.yaml's pullSecret.
 	PullSecret string `json:"pull_secret,omitempty"`
+
+	// Proxy is the cluster-wide proxy settings. Equivalent to
+	// install-config.yaml's proxy. When nil, the seed cluster proxy settings
+	// are kept. During an IBU, the proxy is taken from the original SNO as a
+	// manifest instead.
+	Proxy *Proxy `json:"proxy,omitempty"`
+
+	// AdditionalTrustBundle is a PEM-encoded X.509 certificate bundle that is
+	// added to the node trust store and to the cluster-wide trusted CA
+	// bundle. Equivalent to install-config.yaml's additionalTrustBundle.
+	AdditionalTrustBundle string `json:"additional_trust_bundle,omitempty"`
+
+	// ImageDigestSources lists sources/repositories for the release-image
+	// content and their mirrors, from which images are pulled by digest.
+	// Equivalent to install-config.yaml's imageDigestSources. When empty,
+	// the seed cluster mirror configuration is kept. During an IBU, the
+	// mirrors are taken from the original SNO as manifests instead.
+	ImageDigestSources []ImageDigestSource `json:"image_digest_sources,omitempty"`
+
+	// RegistriesConf is additional containers-registries.conf(5) content,
+	// e.g. registries marked as insecure or blocked, written as a drop-in
+	// configuration file of the node.
+	RegistriesConf string `json:"registries_conf,omitempty"`
+
+	// APINamedCertificates are serving certificates, and their private keys,
+	// that the kube-apiserver serves for the given names, as configured by
+	// the APIServer servingCerts.namedCertificates day-2 setting. A
+	// certificate whose common name is the API name of the cluster (api.
+	// followed by the cluster name and base domain) is also installed by
+	// recert in place of the seed certificate, so that it is served from the
+	// first start of the cluster.
+	APINamedCertificates []NamedCertificate `json:"api_named_certificates,omitempty"`
+
+	// IngressCertificate is the default serving certificate, and its private
+	// key, of the ingress controller, as configured by the default
+	// IngressController defaultCertificate day-2 setting. When its common
+	// name is the wildcard apps name of the cluster (*.apps. followed by the
+	// cluster name and base domain), it is also installed by recert in place
+	// of the seed certificate. When nil, the ingress operator certificate is
+	// used.
+	IngressCertificate *ServingCertificate `json:"ingress_certificate,omitempty"`
+
+	// NTPSources are the hostnames or IP addresses of the NTP servers the
+	// node synchronizes its clock with, in place of the seed cluster chrony
+	// configuration. When empty, the seed cluster chrony configuration is
+	// kept.
+	NTPSources []string `json:"ntp_sources,omitempty"`
+
+	// Timezone is the timezone of the node, e.g. Europe/Paris, as a name of
+	// the tz database. When empty, the seed cluster timezone, UTC by default,
+	// is kept.
+	Timezone string `json:"timezone,omitempty"`
+
+	// KernelArguments are added to the kernel arguments of the seed, e.g.
+	// isolcpus or hugepages settings. They can only be set on the first boot
+	// when the seed reconfiguration is known when the stateroot is set up,
+	// e.g. with lca-cli ibi --seed-reconfiguration. They are kept in a master
+	// MachineConfig applied once the cluster is up.
+	KernelArguments []string `json:"kernel_arguments,omitempty"`
+
+	// MachineConfigs are MachineConfig manifests, in YAML or JSON format,
+	// applied once the cluster is up. The kernel arguments of the master ones
+	// are added to the kernel arguments of the seed along with
+	// KernelArguments.
+	MachineConfigs []string `json:"machine_configs,omitempty"`
+}
+
+// ServingCertificate is a PEM-encoded serving certificate chain and its
+// private key
+type ServingCertificate struct {
+	// Certificate is the serving certificate, followed by its intermediate
+	// certificates, if any.
+	Certificate PEM `json:"certificate"`
+
+	// Key is the private key of the serving certificate.
+	Key PEM `json:"key"`
+}
+
+// NamedCertificate is a serving certificate of the kube-apiserver and the
+// names it's served for
+type NamedCertificate struct {
+	// Names are the DNS names, leading wildcards allowed, the certificate is
+	// served for. When empty, the names are taken from the certificate.
+	Names []string `json:"names,omitempty"`
+
+	ServingCertificate `json:",inline"`
+}
+
+// ImageDigestSource is a source of images and the mirrors images are pulled
+// from by digest instead
+type ImageDigestSource struct {
+	// Source is the repository, or registry, whose images are mirrored.
+	Source string `json:"source"`
+
+	// Mirrors are the repositories, or registries, the images are pulled
+	// from, in order of preference.
+	Mirrors []string `json:"mirrors,omitempty"`
+}
+
+// Proxy is the cluster-wide proxy settings
+type Proxy struct {
+	// HTTPProxy is the URL of the proxy for HTTP requests.
+	HTTPProxy string `json:"http_proxy,omitempty"`
+
+	// HTTPSProxy is the URL of the proxy for HTTPS requests.
+	HTTPSProxy string `json:"https_proxy,omitempty"`
+
+	// NoProxy is a comma-separated list of hostnames and/or CIDRs for which
+	// the proxy should not be used.
+	NoProxy string `json:"no_proxy,omitempty"`
 }
 
 type KubeConfigCryptoRetention struct {
@@ -115,3 +265,13 @@ type ClientAuthCrypto struct {
 type IngresssCrypto struct {
 	IngressCA PEM `json:"ingress_ca,omitempty"`
 }
+
+// ClusterNetworkEntry is an IP block pod IPs are allocated from
+type ClusterNetworkEntry struct {
+	// The IP block, e.g. 10.128.0.0/14.
+	CIDR string `json:"cidr"`
+
+	// The prefix size of the subnet allocated to each node from the IP block,
+	// e.g. 23.
+	HostPrefix int `json:"host_prefix,omitempty"`
+}
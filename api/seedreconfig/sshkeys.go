@@ -0,0 +1,33 @@
+package seedreconfig
+
+import "strings"
+
+// ParseAuthorizedKeys returns the keys of the content of an authorized_keys
+// file, skipping the empty lines and the comments
+func ParseAuthorizedKeys(content string) []string {
+	var keys []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	return keys
+}
+
+// AuthorizedKeys returns the keys of SSHKey followed by SSHKeys, without
+// duplicates
+func (s *SeedReconfiguration) AuthorizedKeys() []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, key := range append(ParseAuthorizedKeys(s.SSHKey), s.SSHKeys...) {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,17 @@
+package seedreconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizedKeys(t *testing.T) {
+	s := &SeedReconfiguration{
+		SSHKey:  "# team a\nssh-ed25519 AAAA team-a\n\nssh-rsa BBBB team-b\n",
+		SSHKeys: []string{"ssh-rsa BBBB team-b", " ssh-ed25519 CCCC team-c "},
+	}
+	assert.Equal(t, []string{"ssh-ed25519 AAAA team-a", "ssh-rsa BBBB team-b", "ssh-ed25519 CCCC team-c"},
+		s.AuthorizedKeys())
+	assert.Nil(t, (&SeedReconfiguration{}).AuthorizedKeys())
+}
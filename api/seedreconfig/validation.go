@@ -0,0 +1,293 @@
+package seedreconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// timezoneRegexp matches the names of the tz database, e.g. UTC or America/Argentina/Buenos_Aires
+var timezoneRegexp = regexp.MustCompile(`^[A-Za-z0-9_+-]+(/[A-Za-z0-9_+-]+)*$`)
+
+// bcryptHashRegexp matches a bcrypt hash, the format of the kubeadmin password hash
+var bcryptHashRegexp = regexp.MustCompile(`^\$2[abxy]?\$[0-9]{2}\$[./A-Za-z0-9]{53}$`)
+
+// ValidateKubeadminPasswordHash checks that the kubeadmin password hash is a bcrypt hash, as found in the kubeadmin
+// key of the kube-system/kubeadmin secret
+func ValidateKubeadminPasswordHash(hash string) error {
+	if !bcryptHashRegexp.MatchString(hash) {
+		return fmt.Errorf("kubeadmin password hash must be a bcrypt hash")
+	}
+	return nil
+}
+
+// Validate checks that the SeedReconfiguration is of the current version, that
+// its required fields are set and that its addresses, names and crypto
+// material are well-formed. All the errors found are returned together.
+func (s *SeedReconfiguration) Validate() error {
+	var errs []error
+	addErr := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if s.APIVersion != SeedReconfigurationVersion {
+		addErr("api_version %d is not the current version %d", s.APIVersion, SeedReconfigurationVersion)
+	}
+
+	if s.BaseDomain == "" {
+		addErr("base_domain is required")
+	} else if msgs := validation.IsDNS1123Subdomain(s.BaseDomain); len(msgs) > 0 {
+		addErr("invalid base_domain %q: %s", s.BaseDomain, strings.Join(msgs, ", "))
+	}
+	if s.ClusterName == "" {
+		addErr("cluster_name is required")
+	} else if msgs := validation.IsDNS1123Label(s.ClusterName); len(msgs) > 0 {
+		addErr("invalid cluster_name %q: %s", s.ClusterName, strings.Join(msgs, ", "))
+	}
+	if s.Hostname == "" {
+		addErr("hostname is required")
+	} else if msgs := validation.IsDNS1123Subdomain(s.Hostname); len(msgs) > 0 {
+		addErr("invalid hostname %q: %s", s.Hostname, strings.Join(msgs, ", "))
+	}
+	if s.ClusterID != "" {
+		if _, err := uuid.Parse(s.ClusterID); err != nil {
+			addErr("invalid cluster_id %q: %w", s.ClusterID, err)
+		}
+	}
+
+	errs = append(errs, validateNodeIPs(s.NodeIP, s.NodeIPs)...)
+	if s.RawNMStateConfig != "" {
+		nodeIPs := s.NodeIPs
+		if len(nodeIPs) == 0 && s.NodeIP != "" {
+			nodeIPs = []string{s.NodeIP}
+		}
+		if err := ValidateNMStateConfig(s.RawNMStateConfig, nodeIPs...); err != nil {
+			addErr("invalid raw_nm_state_config: %w", err)
+		}
+	}
+	for _, cidr := range s.MachineNetworks {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			addErr("invalid machine_networks entry: %w", err)
+		}
+	}
+	for _, clusterNetwork := range s.ClusterNetworks {
+		_, ipNet, err := net.ParseCIDR(clusterNetwork.CIDR)
+		if err != nil {
+			addErr("invalid cluster_networks entry: %w", err)
+			continue
+		}
+		ones, bits := ipNet.Mask.Size()
+		if clusterNetwork.HostPrefix != 0 && (clusterNetwork.HostPrefix < ones || clusterNetwork.HostPrefix > bits) {
+			addErr("invalid cluster_networks host_prefix %d for %s", clusterNetwork.HostPrefix, clusterNetwork.CIDR)
+		}
+	}
+	for _, cidr := range s.ServiceNetworks {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			addErr("invalid service_networks entry: %w", err)
+		}
+	}
+
+	errs = append(errs, s.KubeconfigCryptoRetention.validate()...)
+
+	if s.PullSecret != "" && !json.Valid([]byte(s.PullSecret)) {
+		addErr("pull_secret is not valid JSON")
+	}
+	if s.AdditionalTrustBundle != "" {
+		if err := validateCertificates(PEM(s.AdditionalTrustBundle)); err != nil {
+			addErr("invalid additional_trust_bundle: %w", err)
+		}
+	}
+	if s.Proxy != nil {
+		for _, proxy := range []struct{ name, url string }{
+			{"http_proxy", s.Proxy.HTTPProxy},
+			{"https_proxy", s.Proxy.HTTPSProxy},
+		} {
+			if proxy.url == "" {
+				continue
+			}
+			if _, err := url.ParseRequestURI(proxy.url); err != nil {
+				addErr("invalid proxy %s: %w", proxy.name, err)
+			}
+		}
+	}
+	for i, imageDigestSource := range s.ImageDigestSources {
+		if imageDigestSource.Source == "" {
+			addErr("image_digest_sources entry %d has no source", i)
+		}
+	}
+
+	for i, sshKey := range s.SSHKeys {
+		if strings.TrimSpace(sshKey) == "" || strings.ContainsAny(sshKey, "\r\n") {
+			addErr("ssh_keys entry %d must be a single authorized_keys line", i)
+		}
+	}
+
+	if s.KubeadminPasswordHash != "" {
+		if err := ValidateKubeadminPasswordHash(s.KubeadminPasswordHash); err != nil {
+			addErr("invalid kubeadmin_password_hash: %w", err)
+		}
+	}
+
+	for _, ntpSource := range s.NTPSources {
+		if net.ParseIP(ntpSource) == nil && len(validation.IsDNS1123Subdomain(ntpSource)) > 0 {
+			addErr("invalid ntp_sources entry %q: must be a hostname or an IP address", ntpSource)
+		}
+	}
+	if s.Timezone != "" && !timezoneRegexp.MatchString(s.Timezone) {
+		addErr("invalid timezone %q", s.Timezone)
+	}
+
+	for _, kernelArgument := range s.KernelArguments {
+		if strings.TrimSpace(kernelArgument) == "" {
+			addErr("kernel_arguments has an empty entry")
+		}
+	}
+	for i, machineConfig := range s.MachineConfigs {
+		if err := validateMachineConfig(machineConfig); err != nil {
+			addErr("invalid machine_configs entry %d: %w", i, err)
+		}
+	}
+
+	for i, namedCertificate := range s.APINamedCertificates {
+		if _, err := tls.X509KeyPair([]byte(namedCertificate.Certificate), []byte(namedCertificate.Key)); err != nil {
+			addErr("invalid api_named_certificates entry %d: %w", i, err)
+		}
+	}
+	if s.IngressCertificate != nil {
+		if _, err := tls.X509KeyPair([]byte(s.IngressCertificate.Certificate), []byte(s.IngressCertificate.Key)); err != nil {
+			addErr("invalid ingress_certificate: %w", err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateMachineConfig checks that the manifest is a named MachineConfig
+func validateMachineConfig(manifest string) error {
+	machineConfig := struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}{}
+	if err := yaml.Unmarshal([]byte(manifest), &machineConfig); err != nil {
+		return err
+	}
+	if machineConfig.Kind != "MachineConfig" {
+		return fmt.Errorf("unexpected kind %q", machineConfig.Kind)
+	}
+	if machineConfig.Metadata.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	return nil
+}
+
+// validateNodeIPs checks that the node IPs are valid, that the primary one is the first of them and that there is at
+// most one per IP family
+func validateNodeIPs(nodeIP string, nodeIPs []string) []error {
+	var errs []error
+	if nodeIP != "" && net.ParseIP(nodeIP) == nil {
+		errs = append(errs, fmt.Errorf("invalid node_ip %q", nodeIP))
+	}
+	if len(nodeIPs) == 0 {
+		return errs
+	}
+	if nodeIP != "" && nodeIPs[0] != nodeIP {
+		errs = append(errs, fmt.Errorf("node_ip %s is not the first of node_ips", nodeIP))
+	}
+	families := map[bool]bool{}
+	for _, ip := range nodeIPs {
+		parsedIP := net.ParseIP(ip)
+		if parsedIP == nil {
+			errs = append(errs, fmt.Errorf("invalid node_ips entry %q", ip))
+			continue
+		}
+		isIPv4 := parsedIP.To4() != nil
+		if families[isIPv4] {
+			errs = append(errs, fmt.Errorf("node_ips has more than one IP of the family of %s", ip))
+		}
+		families[isIPv4] = true
+	}
+	return errs
+}
+
+// validate checks that all the kubeconfig crypto material is set and well-formed, as recert requires all of it
+func (k *KubeConfigCryptoRetention) validate() []error {
+	var errs []error
+	for _, key := range []struct {
+		name string
+		pem  PEM
+	}{
+		{"localhost_signer_private_key", k.KubeAPICrypto.ServingCrypto.LocalhostSignerPrivateKey},
+		{"service_network_signer_private_key", k.KubeAPICrypto.ServingCrypto.ServiceNetworkSignerPrivateKey},
+		{"loadbalancer_external_signer_private_key", k.KubeAPICrypto.ServingCrypto.LoadbalancerSignerPrivateKey},
+		{"ingress_ca", k.IngresssCrypto.IngressCA},
+	} {
+		if err := validatePrivateKey(key.pem); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", key.name, err))
+		}
+	}
+	if err := validateCertificates(k.KubeAPICrypto.ClientAuthCrypto.AdminCACertificate); err != nil {
+		errs = append(errs, fmt.Errorf("invalid admin_ca_certificate: %w", err))
+	}
+	return errs
+}
+
+// validatePrivateKey checks that data is a single PEM-encoded private key
+func validatePrivateKey(data PEM) error {
+	block, rest := pem.Decode([]byte(data))
+	if block == nil {
+		return fmt.Errorf("no PEM data found")
+	}
+	if len(strings.TrimSpace(string(rest))) > 0 {
+		return fmt.Errorf("unexpected data after the private key")
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return nil
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return nil
+	}
+	if _, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return nil
+	}
+	return fmt.Errorf("failed to parse %s", block.Type)
+}
+
+// validateCertificates checks that data is a non-empty bundle of PEM-encoded certificates
+func validateCertificates(data PEM) error {
+	rest := []byte(data)
+	count := 0
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected PEM block %s", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("failed to parse certificate %d: %w", count, err)
+		}
+		count++
+	}
+	if count == 0 {
+		return fmt.Errorf("no certificate found")
+	}
+	if len(strings.TrimSpace(string(rest))) > 0 {
+		return fmt.Errorf("unexpected data after the certificates")
+	}
+	return nil
+}
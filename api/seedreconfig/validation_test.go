@@ -0,0 +1,249 @@
+package seedreconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKeyAndCertificate(t *testing.T, commonName string) (PEM, PEM) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	return PEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})),
+		PEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}))
+}
+
+func testValidSeedReconfiguration(t *testing.T) *SeedReconfiguration {
+	key, certificate := testKeyAndCertificate(t, "admin-kubeconfig-signer")
+	return &SeedReconfiguration{
+		APIVersion:      SeedReconfigurationVersion,
+		BaseDomain:      "redhat.com",
+		ClusterName:     "sno",
+		ClusterID:       "a0b2c1d3-4e5f-6789-abcd-ef0123456789",
+		Hostname:        "sno.redhat.com",
+		NodeIP:          "192.168.127.10",
+		NodeIPs:         []string{"192.168.127.10", "fd00::10"},
+		MachineNetworks: []string{"192.168.127.0/24", "fd00::/64"},
+		ClusterNetworks: []ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 23}},
+		ServiceNetworks: []string{"172.30.0.0/16"},
+		KubeconfigCryptoRetention: KubeConfigCryptoRetention{
+			KubeAPICrypto: KubeAPICrypto{
+				ServingCrypto: ServingCrypto{
+					LocalhostSignerPrivateKey:      key,
+					ServiceNetworkSignerPrivateKey: key,
+					LoadbalancerSignerPrivateKey:   key,
+				},
+				ClientAuthCrypto: ClientAuthCrypto{AdminCACertificate: certificate + certificate},
+			},
+			IngresssCrypto: IngresssCrypto{IngressCA: key},
+		},
+		PullSecret:            `{"auths":{}}`,
+		AdditionalTrustBundle: string(certificate),
+		Proxy:                 &Proxy{HTTPProxy: "http://proxy.redhat.com:3128"},
+		IngressCertificate:    &ServingCertificate{Certificate: certificate, Key: key},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testcases := []struct {
+		name           string
+		mutate         func(s *SeedReconfiguration)
+		expectedErrors []string
+	}{
+		{
+			name:   "Valid",
+			mutate: func(s *SeedReconfiguration) {},
+		},
+		{
+			name: "Valid without optional fields",
+			mutate: func(s *SeedReconfiguration) {
+				s.ClusterID = ""
+				s.NodeIP = ""
+				s.NodeIPs = nil
+				s.PullSecret = ""
+				s.AdditionalTrustBundle = ""
+				s.Proxy = nil
+				s.IngressCertificate = nil
+			},
+		},
+		{
+			name: "Missing required fields",
+			mutate: func(s *SeedReconfiguration) {
+				s.APIVersion = 1
+				s.BaseDomain = ""
+				s.ClusterName = ""
+				s.Hostname = ""
+			},
+			expectedErrors: []string{
+				"api_version 1 is not the current version",
+				"base_domain is required",
+				"cluster_name is required",
+				"hostname is required",
+			},
+		},
+		{
+			name: "Invalid names",
+			mutate: func(s *SeedReconfiguration) {
+				s.BaseDomain = "redhat_com"
+				s.ClusterName = "sno.cluster"
+				s.Hostname = "SNO"
+				s.ClusterID = "not-a-uuid"
+			},
+			expectedErrors: []string{
+				`invalid base_domain "redhat_com"`,
+				`invalid cluster_name "sno.cluster"`,
+				`invalid hostname "SNO"`,
+				`invalid cluster_id "not-a-uuid"`,
+			},
+		},
+		{
+			name: "Invalid addresses",
+			mutate: func(s *SeedReconfiguration) {
+				s.NodeIP = "192.168.127.300"
+				s.NodeIPs = []string{"192.168.127.10", "192.168.127.11"}
+				s.MachineNetworks = []string{"192.168.127.0"}
+				s.ClusterNetworks = []ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 8}}
+				s.ServiceNetworks = []string{"172.30.0.0/33"}
+				s.Proxy.HTTPSProxy = "proxy"
+			},
+			expectedErrors: []string{
+				`invalid node_ip "192.168.127.300"`,
+				"node_ip 192.168.127.300 is not the first of node_ips",
+				"node_ips has more than one IP of the family of 192.168.127.11",
+				"invalid machine_networks entry",
+				"invalid cluster_networks host_prefix 8 for 10.128.0.0/14",
+				"invalid service_networks entry",
+				"invalid proxy https_proxy",
+			},
+		},
+		{
+			name: "Valid time configuration",
+			mutate: func(s *SeedReconfiguration) {
+				s.NTPSources = []string{"ntp1.redhat.com", "192.168.127.1", "fd00::1"}
+				s.Timezone = "America/Argentina/Buenos_Aires"
+			},
+		},
+		{
+			name: "Invalid time configuration",
+			mutate: func(s *SeedReconfiguration) {
+				s.NTPSources = []string{"ntp1.redhat.com", "ntp server"}
+				s.Timezone = "../../etc/passwd"
+			},
+			expectedErrors: []string{
+				`invalid ntp_sources entry "ntp server"`,
+				`invalid timezone "../../etc/passwd"`,
+			},
+		},
+		{
+			name: "Invalid SSH keys",
+			mutate: func(s *SeedReconfiguration) {
+				s.SSHKeys = []string{"ssh-ed25519 AAAA team-a", "", "ssh-ed25519 AAAA\nssh-rsa AAAA"}
+			},
+			expectedErrors: []string{
+				"ssh_keys entry 1 must be a single authorized_keys line",
+				"ssh_keys entry 2 must be a single authorized_keys line",
+			},
+		},
+		{
+			name: "Valid kubeadmin password hash",
+			mutate: func(s *SeedReconfiguration) {
+				s.KubeadminPasswordHash = "$2a$10$20Q4iRLy7cWZkjn/D07bF.RZQZonKwstyRGH0qiYbYRkx5Pe4Ztyi"
+			},
+		},
+		{
+			name: "Invalid kubeadmin password hash",
+			mutate: func(s *SeedReconfiguration) {
+				s.KubeadminPasswordHash = "password"
+			},
+			expectedErrors: []string{"invalid kubeadmin_password_hash: kubeadmin password hash must be a bcrypt hash"},
+		},
+		{
+			name: "Valid kernel arguments and machine configs",
+			mutate: func(s *SeedReconfiguration) {
+				s.KernelArguments = []string{"isolcpus=2-3"}
+				s.MachineConfigs = []string{"kind: MachineConfig\nmetadata:\n  name: 99-master-kargs\n"}
+			},
+		},
+		{
+			name: "Invalid kernel arguments and machine configs",
+			mutate: func(s *SeedReconfiguration) {
+				s.KernelArguments = []string{" "}
+				s.MachineConfigs = []string{"kind: ConfigMap\nmetadata:\n  name: cm\n", "kind: MachineConfig\n", "kind: ["}
+			},
+			expectedErrors: []string{
+				"kernel_arguments has an empty entry",
+				`invalid machine_configs entry 0: unexpected kind "ConfigMap"`,
+				"invalid machine_configs entry 1: name is required",
+				"invalid machine_configs entry 2",
+			},
+		},
+		{
+			name: "Valid NMState config",
+			mutate: func(s *SeedReconfiguration) {
+				s.NodeIPs = nil
+				s.RawNMStateConfig = testNMStateConfig
+			},
+		},
+		{
+			name: "Node IP not in NMState config",
+			mutate: func(s *SeedReconfiguration) {
+				s.RawNMStateConfig = testNMStateConfig
+			},
+			expectedErrors: []string{
+				"invalid raw_nm_state_config: node IP fd00::10 is not an address of any interface that is up",
+			},
+		},
+		{
+			name: "Invalid crypto",
+			mutate: func(s *SeedReconfiguration) {
+				s.KubeconfigCryptoRetention.KubeAPICrypto.ServingCrypto.LocalhostSignerPrivateKey = ""
+				s.KubeconfigCryptoRetention.IngresssCrypto.IngressCA = s.KubeconfigCryptoRetention.KubeAPICrypto.ClientAuthCrypto.AdminCACertificate
+				s.KubeconfigCryptoRetention.KubeAPICrypto.ClientAuthCrypto.AdminCACertificate = "certificate"
+				s.AdditionalTrustBundle = string(s.KubeconfigCryptoRetention.KubeAPICrypto.ServingCrypto.LoadbalancerSignerPrivateKey)
+				s.IngressCertificate.Key = s.KubeconfigCryptoRetention.KubeAPICrypto.ServingCrypto.LoadbalancerSignerPrivateKey[:20]
+				s.PullSecret = "{"
+			},
+			expectedErrors: []string{
+				"invalid localhost_signer_private_key: no PEM data found",
+				"invalid ingress_ca: unexpected data after the private key",
+				"invalid admin_ca_certificate: no certificate found",
+				"invalid additional_trust_bundle: unexpected PEM block PRIVATE KEY",
+				"invalid ingress_certificate",
+				"pull_secret is not valid JSON",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			seedReconfiguration := testValidSeedReconfiguration(t)
+			tc.mutate(seedReconfiguration)
+			err := seedReconfiguration.Validate()
+			if len(tc.expectedErrors) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, expectedError := range tc.expectedErrors {
+				assert.ErrorContains(t, err, expectedError)
+			}
+		})
+	}
+}
@@ -31,6 +31,9 @@ import (
 // +kubebuilder:validation:XValidation:message="can not change spec.seedImageRef while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.seedImageRef) && has(self.spec.seedImageRef) && oldSelf.spec.seedImageRef==self.spec.seedImageRef || !has(self.spec.seedImageRef) && !has(oldSelf.spec.seedImageRef)"
 // +kubebuilder:validation:XValidation:message="can not change spec.oadpContent while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.oadpContent) && has(self.spec.oadpContent) && oldSelf.spec.oadpContent==self.spec.oadpContent || !has(self.spec.oadpContent) && !has(oldSelf.spec.oadpContent)"
 // +kubebuilder:validation:XValidation:message="can not change spec.extraManifests while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.extraManifests) && has(self.spec.extraManifests) && oldSelf.spec.extraManifests==self.spec.extraManifests || !has(self.spec.extraManifests) && !has(oldSelf.spec.extraManifests)"
+// +kubebuilder:validation:XValidation:message="can not change spec.kernelArguments while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.kernelArguments) && has(self.spec.kernelArguments) && oldSelf.spec.kernelArguments==self.spec.kernelArguments || !has(self.spec.kernelArguments) && !has(oldSelf.spec.kernelArguments)"
+// +kubebuilder:validation:XValidation:message="can not change spec.nodeFiles while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.nodeFiles) && has(self.spec.nodeFiles) && oldSelf.spec.nodeFiles==self.spec.nodeFiles || !has(self.spec.nodeFiles) && !has(oldSelf.spec.nodeFiles)"
+// +kubebuilder:validation:XValidation:message="can not change spec.kubeadmin while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.kubeadmin) && has(self.spec.kubeadmin) && oldSelf.spec.kubeadmin==self.spec.kubeadmin || !has(self.spec.kubeadmin) && !has(oldSelf.spec.kubeadmin)"
 // +kubebuilder:validation:XValidation:message="can not change spec.autoRollbackOnFailure while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.autoRollbackOnFailure) && has(self.spec.autoRollbackOnFailure) && oldSelf.spec.autoRollbackOnFailure==self.spec.autoRollbackOnFailure || !has(self.spec.autoRollbackOnFailure) && !has(oldSelf.spec.autoRollbackOnFailure)"
 // +operator-sdk:csv:customresourcedefinitions:displayName="Image-based Cluster Upgrade",resources={{Namespace, v1},{Deployment,apps/v1}}
 // ImageBasedUpgrade is the Schema for the ImageBasedUpgrades API
@@ -69,6 +72,29 @@ type ImageBasedUpgradeSpec struct {
 	OADPContent           []ConfigMapRef        `json:"oadpContent,omitempty"`
 	ExtraManifests        []ConfigMapRef        `json:"extraManifests,omitempty"`
 	AutoRollbackOnFailure AutoRollbackOnFailure `json:"autoRollbackOnFailure,omitempty"`
+	// KernelArguments are added to the kernel arguments of the seed in the new stateroot, along with the ones of the
+	// master MachineConfigs of the extra manifests, so that the first boot already has them. They are kept in a master
+	// MachineConfig once the cluster is up.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Kernel Arguments"
+	KernelArguments []string `json:"kernelArguments,omitempty"`
+	// NodeFiles are configmaps listing node-local paths below /etc and /var that are copied from the current stateroot
+	// into the new one before the pivot, each value being a YAML list of {path, mode} with a mode of copy or merge
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Node Files"
+	NodeFiles []ConfigMapRef `json:"nodeFiles,omitempty"`
+	// Kubeadmin rotates the kubeadmin password or disables the kubeadmin user as part of the upgrade. When not set, the
+	// kubeadmin password of the cluster is kept.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Kubeadmin"
+	Kubeadmin *Kubeadmin `json:"kubeadmin,omitempty"`
+}
+
+// Kubeadmin defines what happens to the kubeadmin user of the cluster during the upgrade
+// +kubebuilder:validation:XValidation:message="passwordHashSecretRef and disabled are mutually exclusive", rule="!has(self.passwordHashSecretRef) || !has(self.disabled) || !self.disabled"
+type Kubeadmin struct {
+	// PasswordHashSecretRef references a secret whose kubeadmin key holds the bcrypt hash of the new kubeadmin password,
+	// in the format of the kube-system/kubeadmin secret
+	PasswordHashSecretRef *SecretRef `json:"passwordHashSecretRef,omitempty"`
+	// Disabled deletes the kubeadmin password secret of the upgraded cluster, disabling the kubeadmin login
+	Disabled bool `json:"disabled,omitempty"`
 }
 
 // SeedImageRef defines the seed image and OCP version for the upgrade
@@ -83,6 +109,8 @@ type AutoRollbackOnFailure struct {
 	DisabledForUpgradeCompletion bool `json:"disabledForUpgradeCompletion,omitempty"` // If true, disable auto-rollback for Upgrade completion handler
 	DisabledInitMonitor          bool `json:"disabledInitMonitor,omitempty"`          // If true, disable LCA Init Monitor watchdog, which triggers auto-rollback if timeout occurs before upgrade completion
 	InitMonitorTimeoutSeconds    int  `json:"initMonitorTimeoutSeconds,omitempty"`    // LCA Init Monitor watchdog timeout, in seconds. Value <= 0 is treated as "use default" when writing config file in Prep stage
+	// +kubebuilder:validation:XValidation:message="post pivot step timeouts must be positive", rule="self.all(step, self[step] > 0)"
+	PostPivotStepTimeoutsSeconds map[string]int `json:"postPivotStepTimeoutsSeconds,omitempty"` // Timeouts of the post-pivot steps, in seconds, by step name as reported in postPivotSteps. A step that times out fails the post-pivot configuration, which triggers auto-rollback unless disabledForPostRebootConfig is set
 }
 
 // ConfigMapRef defines a reference to a config map
@@ -96,6 +124,17 @@ type ConfigMapRef struct {
 	Namespace string `json:"namespace"`
 }
 
+// SecretRef defines a reference to a secret
+type SecretRef struct {
+	// +kubebuilder:validation:Required
+	// +required
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Required
+	// +required
+	Namespace string `json:"namespace"`
+}
+
 // PullSecretRef defines a reference to a secret with credentials for pulling container images
 type PullSecretRef struct {
 	// +kubebuilder:validation:Required
@@ -111,6 +150,35 @@ type ImageBasedUpgradeStatus struct {
 	CompletedAt        metav1.Time `json:"completedAt,omitempty"`
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions"
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// PostPivotSteps reports the steps of the post-pivot configuration, which runs on the new stateroot before the
+	// cluster API is available
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Post Pivot Steps"
+	PostPivotSteps []PostPivotStep `json:"postPivotSteps,omitempty"`
+	// RecertSummary is a digest of the changes recert made to the cluster during the post-pivot configuration
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Recert Summary"
+	RecertSummary *RecertSummary `json:"recertSummary,omitempty"`
+}
+
+// PostPivotStep defines the progress of a single step of the post-pivot configuration
+type PostPivotStep struct {
+	Name        string      `json:"name"`
+	StartedAt   metav1.Time `json:"startedAt,omitempty"`
+	CompletedAt metav1.Time `json:"completedAt,omitempty"`
+	// Error is the error of the last attempt of the step, if it failed
+	Error string `json:"error,omitempty"`
+}
+
+// RecertSummary is a digest of the summary written by recert during the post-pivot configuration. The full summary is
+// kept in /var/lib/lca of the new stateroot.
+type RecertSummary struct {
+	// Certificates is the number of certificates regenerated by recert
+	Certificates int `json:"certificates,omitempty"`
+	// Keys is the number of private keys regenerated by recert
+	Keys int `json:"keys,omitempty"`
+	// Renames are the renames done by recert, e.g. "hostname: seed -> sno"
+	Renames []string `json:"renames,omitempty"`
+	// Timings are the durations of the recert phases, as reported by recert
+	Timings map[string]string `json:"timings,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -28,6 +28,13 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AutoRollbackOnFailure) DeepCopyInto(out *AutoRollbackOnFailure) {
 	*out = *in
+	if in.PostPivotStepTimeoutsSeconds != nil {
+		in, out := &in.PostPivotStepTimeoutsSeconds, &out.PostPivotStepTimeoutsSeconds
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoRollbackOnFailure.
@@ -129,7 +136,22 @@ func (in *ImageBasedUpgradeSpec) DeepCopyInto(out *ImageBasedUpgradeSpec) {
 		*out = make([]ConfigMapRef, len(*in))
 		copy(*out, *in)
 	}
-	out.AutoRollbackOnFailure = in.AutoRollbackOnFailure
+	in.AutoRollbackOnFailure.DeepCopyInto(&out.AutoRollbackOnFailure)
+	if in.KernelArguments != nil {
+		in, out := &in.KernelArguments, &out.KernelArguments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeFiles != nil {
+		in, out := &in.NodeFiles, &out.NodeFiles
+		*out = make([]ConfigMapRef, len(*in))
+		copy(*out, *in)
+	}
+	if in.Kubeadmin != nil {
+		in, out := &in.Kubeadmin, &out.Kubeadmin
+		*out = new(Kubeadmin)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageBasedUpgradeSpec.
@@ -154,6 +176,18 @@ func (in *ImageBasedUpgradeStatus) DeepCopyInto(out *ImageBasedUpgradeStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PostPivotSteps != nil {
+		in, out := &in.PostPivotSteps, &out.PostPivotSteps
+		*out = make([]PostPivotStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RecertSummary != nil {
+		in, out := &in.RecertSummary, &out.RecertSummary
+		*out = new(RecertSummary)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageBasedUpgradeStatus.
@@ -166,6 +200,43 @@ func (in *ImageBasedUpgradeStatus) DeepCopy() *ImageBasedUpgradeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Kubeadmin) DeepCopyInto(out *Kubeadmin) {
+	*out = *in
+	if in.PasswordHashSecretRef != nil {
+		in, out := &in.PasswordHashSecretRef, &out.PasswordHashSecretRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Kubeadmin.
+func (in *Kubeadmin) DeepCopy() *Kubeadmin {
+	if in == nil {
+		return nil
+	}
+	out := new(Kubeadmin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostPivotStep) DeepCopyInto(out *PostPivotStep) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	in.CompletedAt.DeepCopyInto(&out.CompletedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostPivotStep.
+func (in *PostPivotStep) DeepCopy() *PostPivotStep {
+	if in == nil {
+		return nil
+	}
+	out := new(PostPivotStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PullSecretRef) DeepCopyInto(out *PullSecretRef) {
 	*out = *in
@@ -181,6 +252,48 @@ func (in *PullSecretRef) DeepCopy() *PullSecretRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecertSummary) DeepCopyInto(out *RecertSummary) {
+	*out = *in
+	if in.Renames != nil {
+		in, out := &in.Renames, &out.Renames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timings != nil {
+		in, out := &in.Timings, &out.Timings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecertSummary.
+func (in *RecertSummary) DeepCopy() *RecertSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(RecertSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretRef) DeepCopyInto(out *SecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretRef.
+func (in *SecretRef) DeepCopy() *SecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SeedImageRef) DeepCopyInto(out *SeedImageRef) {
 	*out = *in
@@ -19,6 +19,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,12 +28,14 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/internal/clusterconfig"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
 	"github.com/openshift-kni/lifecycle-agent/internal/precache"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -404,6 +407,27 @@ func validateStageTransition(ibu *lcav1alpha1.ImageBasedUpgrade, isAfterPivot bo
 func (r *ImageBasedUpgradeReconciler) validateIBUSpec(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (bool, error) {
 	r.Log.Info("Validating IBU spec")
 
+	// Only the node of an SNO cluster is upgraded: compact clusters and clusters with additional worker nodes, which
+	// would be neither re-provisioned nor pivoted to the new stateroot, are not supported
+	nodes := &corev1.NodeList{}
+	if err := r.Client.List(ctx, nodes); err != nil {
+		return false, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	if len(nodes.Items) > 1 {
+		names := make([]string, 0, len(nodes.Items))
+		for _, node := range nodes.Items {
+			names = append(names, node.Name)
+		}
+		utils.SetPrepStatusFailed(ibu, fmt.Sprintf(
+			"image based upgrade requires a single node cluster, found %d nodes: %s", len(nodes.Items), strings.Join(names, ", ")))
+		return false, nil
+	}
+
+	if err := common.ValidatePostPivotStepTimeouts(ibu.Spec.AutoRollbackOnFailure.PostPivotStepTimeoutsSeconds); err != nil {
+		utils.SetPrepStatusFailed(ibu, fmt.Sprintf("invalid auto-rollback configuration: %s", err.Error()))
+		return false, nil
+	}
+
 	// If OADP configmap is provided, validate the configmap and check if OADP operator is available
 	if len(ibu.Spec.OADPContent) != 0 {
 		err := r.BackupRestore.ValidateOadpConfigmap(ctx, ibu.Spec.OADPContent)
@@ -424,6 +448,32 @@ func (r *ImageBasedUpgradeReconciler) validateIBUSpec(ctx context.Context, ibu *
 			return false, err
 		}
 	}
+
+	if len(ibu.Spec.NodeFiles) != 0 {
+		configMaps, err := common.GetConfigMaps(ctx, r.Client, ibu.Spec.NodeFiles)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				utils.SetPrepStatusFailed(ibu, fmt.Sprintf("node files configmap not found: %s", err.Error()))
+				return false, nil
+			}
+			return false, err
+		}
+		if _, err := common.NodeFilesFromConfigMaps(configMaps); err != nil {
+			utils.SetPrepStatusFailed(ibu, fmt.Sprintf("invalid node files: %s", err.Error()))
+			return false, nil
+		}
+	}
+
+	if ibu.Spec.Kubeadmin != nil && ibu.Spec.Kubeadmin.PasswordHashSecretRef != nil {
+		if _, err := clusterconfig.GetKubeadminPasswordHashFromSecret(ctx, r.Client, ibu.Spec.Kubeadmin.PasswordHashSecretRef); err != nil {
+			// Errors of the API server other than not found are retried, the others are in the secret itself
+			if !errors.IsNotFound(err) && errors.ReasonForError(err) != metav1.StatusReasonUnknown {
+				return false, err
+			}
+			utils.SetPrepStatusFailed(ibu, fmt.Sprintf("invalid kubeadmin password hash secret: %s", err.Error()))
+			return false, nil
+		}
+	}
 	return true, nil
 }
 
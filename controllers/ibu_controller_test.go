@@ -26,6 +26,7 @@ import (
 	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -730,3 +731,78 @@ func TestImageBasedUpgradeReconciler_Reconcile(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateIBUSpecPostPivotStepTimeouts(t *testing.T) {
+	c, _ := getFakeClientFromObjects()
+	r := &ImageBasedUpgradeReconciler{Client: c, Log: logr.Discard()}
+
+	ibu := &lcav1alpha1.ImageBasedUpgrade{}
+	ibu.Spec.AutoRollbackOnFailure.PostPivotStepTimeoutsSeconds = map[string]int{"start_cluster": 1200}
+	valid, err := r.validateIBUSpec(context.TODO(), ibu)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	ibu.Spec.AutoRollbackOnFailure.PostPivotStepTimeoutsSeconds = map[string]int{"start-cluster": 1200}
+	valid, err = r.validateIBUSpec(context.TODO(), ibu)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	condition := meta.FindStatusCondition(ibu.Status.Conditions, string(utils.ConditionTypes.PrepInProgress))
+	if assert.NotNil(t, condition) {
+		assert.Contains(t, condition.Message, `invalid auto-rollback configuration: unknown post pivot step "start-cluster"`)
+	}
+}
+
+func TestValidateIBUSpecNodes(t *testing.T) {
+	node := func(name string, roles ...string) client.Object {
+		labels := map[string]string{}
+		for _, role := range roles {
+			labels[role] = ""
+		}
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	sno := node("master-0", "node-role.kubernetes.io/master", "node-role.kubernetes.io/worker")
+
+	testcases := []struct {
+		name            string
+		nodes           []client.Object
+		expectedValid   bool
+		expectedMessage string
+	}{
+		{
+			name:          "single node",
+			nodes:         []client.Object{sno},
+			expectedValid: true,
+		},
+		{
+			name:            "additional worker node",
+			nodes:           []client.Object{sno, node("worker-0", "node-role.kubernetes.io/worker")},
+			expectedMessage: "image based upgrade requires a single node cluster, found 2 nodes: master-0, worker-0",
+		},
+		{
+			name: "compact cluster",
+			nodes: []client.Object{sno,
+				node("master-1", "node-role.kubernetes.io/master", "node-role.kubernetes.io/worker"),
+				node("master-2", "node-role.kubernetes.io/master", "node-role.kubernetes.io/worker")},
+			expectedMessage: "image based upgrade requires a single node cluster, found 3 nodes: master-0, master-1, master-2",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := getFakeClientFromObjects(tc.nodes...)
+			assert.NoError(t, err)
+			r := &ImageBasedUpgradeReconciler{Client: c, Log: logr.Discard()}
+
+			ibu := &lcav1alpha1.ImageBasedUpgrade{}
+			valid, err := r.validateIBUSpec(context.TODO(), ibu)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedValid, valid)
+			if !tc.expectedValid {
+				condition := meta.FindStatusCondition(ibu.Status.Conditions, string(utils.ConditionTypes.PrepInProgress))
+				if assert.NotNil(t, condition) {
+					assert.Equal(t, tc.expectedMessage, condition.Message)
+				}
+			}
+		})
+	}
+}
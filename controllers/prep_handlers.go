@@ -23,8 +23,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
+	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 
@@ -35,6 +37,7 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
 	commonUtils "github.com/openshift-kni/lifecycle-agent/utils"
 
+	"github.com/openshift-kni/lifecycle-agent/internal/clusterconfig"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/precache"
 	"github.com/openshift-kni/lifecycle-agent/internal/prep"
@@ -224,9 +227,40 @@ func (r *ImageBasedUpgradeReconciler) queryPrecachingStatus(ctx context.Context)
 	return
 }
 
+// kernelArguments returns the kernel arguments of the spec and of the master MachineConfigs of the extra manifests, to
+// be added to the seed ones in the new stateroot
+func (r *ImageBasedUpgradeReconciler) kernelArguments(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) ([]string, error) {
+	configMaps, err := common.GetConfigMaps(ctx, r.Client, ibu.Spec.ExtraManifests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get extra manifests configmaps: %w", err)
+	}
+	var manifests []string
+	for _, cm := range configMaps {
+		keys := lo.Keys(cm.Data)
+		sort.Strings(keys)
+		for _, key := range keys {
+			manifests = append(manifests, cm.Data[key])
+		}
+	}
+
+	kargs, err := commonUtils.KernelArguments(ibu.Spec.KernelArguments, manifests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kernel arguments from extra manifests: %w", err)
+	}
+	if len(kargs) > 0 {
+		r.Log.Info("Adding kernel arguments to the new stateroot", "kargs", kargs)
+	}
+	return kargs, nil
+}
+
 func (r *ImageBasedUpgradeReconciler) SetupStateroot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade, imageListFile string) error {
+	kargs, err := r.kernelArguments(ctx, ibu)
+	if err != nil {
+		return err
+	}
+
 	if err := prep.SetupStateroot(r.Log, r.Ops, r.OstreeClient, r.RPMOstreeClient, ibu.Spec.SeedImageRef.Image,
-		ibu.Spec.SeedImageRef.Version, imageListFile, false); err != nil {
+		ibu.Spec.SeedImageRef.Version, imageListFile, false, kargs); err != nil {
 		return err
 	}
 
@@ -245,6 +279,37 @@ func (r *ImageBasedUpgradeReconciler) SetupStateroot(ctx context.Context, ibu *l
 	return nil
 }
 
+// recertDryRun validates the seed reconfiguration of the current cluster against the new stateroot, so that a recert
+// failure is caught before the pivot. The validated seed reconfiguration is written into the new stateroot, to be used
+// as is at upgrade, and a description of the planned changes is returned.
+func (r *ImageBasedUpgradeReconciler) recertDryRun(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (string, error) {
+	clusterConfig := &clusterconfig.UpgradeClusterConfigGather{Client: r.Client, Log: r.Log, Scheme: r.Scheme}
+	seedReconfiguration, err := clusterConfig.SeedReconfiguration(ctx, ibu.Spec.Kubeadmin, ibu.Spec.KernelArguments)
+	if err != nil {
+		return "", fmt.Errorf("failed to get seed reconfiguration: %w", err)
+	}
+	if err := seedReconfiguration.Validate(); err != nil {
+		return "", fmt.Errorf("invalid seed reconfiguration of the cluster: %w", err)
+	}
+
+	stateroot := common.GetDesiredStaterootName(ibu)
+	summary, err := prep.RecertDryRun(r.Log, r.Ops, r.OstreeClient, seedReconfiguration, stateroot,
+		common.ImageRegistryAuthFile)
+	if err != nil {
+		return "", err
+	}
+	if err := clusterConfig.WriteSeedReconfiguration(getStaterootVarPath(stateroot), seedReconfiguration); err != nil {
+		return "", fmt.Errorf("failed to write seed reconfiguration into the new stateroot: %w", err)
+	}
+
+	if summary == nil {
+		return "", nil
+	}
+	msg := commonUtils.RecertDryRunSummaryMessage(summary)
+	r.Log.Info(msg, "timings", summary.Timings)
+	return msg, nil
+}
+
 func (r *ImageBasedUpgradeReconciler) verifyPrecachingCompleteFunc(retries int, interval time.Duration) wait.ConditionWithContextFunc {
 	return func(ctx context.Context) (bool, error) {
 		r.Log.Info("Querying pre-caching job for completion...")
@@ -284,7 +349,10 @@ func (r *ImageBasedUpgradeReconciler) prepStageWorker(ctx context.Context, ibu *
 	defer r.PrepTask.Cancel() // Ensure that the cancel function is called when the prepStageWorker function exits
 
 	errGroup.Go(func() error {
-		var ok bool
+		var (
+			ok            bool
+			dryRunSummary string
+		)
 		imageListFile := filepath.Join(utils.IBUWorkspacePath, "image-list-file")
 
 		// Pull seed image
@@ -317,6 +385,21 @@ func (r *ImageBasedUpgradeReconciler) prepStageWorker(ctx context.Context, ibu *
 			r.PrepTask.Progress = "Successfully setup stateroot"
 		}
 
+		// Validate the seed reconfiguration against the new stateroot
+		select {
+		case <-derivedCtx.Done():
+			r.Log.Info("Context canceled before running recert dry-run")
+			return derivedCtx.Err()
+		default:
+			r.PrepTask.Progress = "Running recert dry-run"
+			if dryRunSummary, err = r.recertDryRun(derivedCtx, ibu); err != nil {
+				r.Log.Error(err, "failed to run recert dry-run")
+				return err
+			}
+			r.Log.Info("Successfully ran recert dry-run")
+			r.PrepTask.Progress = "Successfully ran recert dry-run"
+		}
+
 		// Launch precaching job
 		select {
 		case <-derivedCtx.Done():
@@ -350,6 +433,9 @@ func (r *ImageBasedUpgradeReconciler) prepStageWorker(ctx context.Context, ibu *
 		if err == nil && status != nil && status.Message != "" {
 			r.Log.Info(msg, "summary", status.Message)
 		}
+		if dryRunSummary != "" {
+			msg = fmt.Sprintf("%s. %s", msg, dryRunSummary)
+		}
 		r.PrepTask.Progress = msg
 
 		// Prep-stage completed successfully
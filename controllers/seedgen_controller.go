@@ -31,6 +31,7 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/healthcheck"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcreator"
 	commonUtils "github.com/openshift-kni/lifecycle-agent/utils"
 	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
 
@@ -72,12 +73,19 @@ type SeedGeneratorReconciler struct {
 var (
 	lcaImage               string
 	seedgenAuthFile        = filepath.Join(utils.SeedgenWorkspacePath, "auth.json")
+	seedgenPushStatusFile  = filepath.Join(utils.SeedgenWorkspacePath, "push-status.json")
+	seedgenBlockingFile    = filepath.Join(utils.SeedgenWorkspacePath, "blocking-component.json")
 	storedManagedClusterCR = filepath.Join(utils.SeedgenWorkspacePath, "managedcluster.json")
 	lcaCliContainerName    = "lca_image_builder"
 )
 
 const (
 	EnvSkipRecert = "SEEDGEN_SKIP_RECERT"
+
+	// Optional overrides, as durations (e.g. 10m), of the waits done by the lca-cli while shutting down the cluster.
+	// They are the defaults of the corresponding SeedGenerator spec fields.
+	EnvOvnShutdownTimeout    = "SEEDGEN_OVN_SHUTDOWN_TIMEOUT"
+	EnvContainersStopTimeout = "SEEDGEN_CONTAINERS_STOP_TIMEOUT"
 )
 
 //+kubebuilder:rbac:groups=lca.openshift.io,resources=seedgenerators,verbs=get;list;watch;create;update;patch;delete
@@ -454,6 +462,68 @@ func (r *SeedGeneratorReconciler) pullRecertImagePullSpec(seedgen *seedgenv1alph
 	return nil
 }
 
+// additionalSeedImageAuthFile returns the path of the auth file used to push to the additional seed image destination
+// at the given index
+func additionalSeedImageAuthFile(index int) string {
+	return filepath.Join(utils.SeedgenWorkspacePath, fmt.Sprintf("auth-%d.json", index))
+}
+
+// writeAdditionalSeedImageAuthFiles writes the auth file of each additional seed image destination from the seedgen
+// secret, defaulting to the seedAuth credentials when no key is specified
+func writeAdditionalSeedImageAuthFiles(seedgen *seedgenv1alpha1.SeedGenerator, seedGenSecret *corev1.Secret) error {
+	for i, destination := range seedgen.Spec.AdditionalSeedImages {
+		key := destination.AuthSecretKey
+		if key == "" {
+			key = "seedAuth"
+		}
+		auth, exists := seedGenSecret.Data[key]
+		if !exists {
+			return fmt.Errorf("could not find %s in %s secret for seed image destination %s", key, utils.SeedGenSecretName, destination.Image)
+		}
+		authFile := additionalSeedImageAuthFile(i)
+		if err := os.WriteFile(common.PathOutsideChroot(authFile), auth, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", authFile, err)
+		}
+	}
+	return nil
+}
+
+// readSeedImagePushStatus reads the per-destination push results written by the lca-cli, if any
+func readSeedImagePushStatus() ([]seedgenv1alpha1.SeedImagePushStatus, error) {
+	var pushStatus []seedgenv1alpha1.SeedImagePushStatus
+	filePath := common.PathOutsideChroot(seedgenPushStatusFile)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err := commonUtils.ReadYamlOrJSONFile(filePath, &pushStatus); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", seedgenPushStatusFile, err)
+	}
+	return pushStatus, nil
+}
+
+// readSeedGenBlockingComponent reads the component that blocked the seed generation, as reported by the lca-cli.
+// It returns nil if the seed generation wasn't blocked.
+func readSeedGenBlockingComponent() (*seedcreator.BlockedError, error) {
+	filePath := common.PathOutsideChroot(seedgenBlockingFile)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return nil, nil
+	}
+	blocking := &seedcreator.BlockedError{}
+	if err := commonUtils.ReadYamlOrJSONFile(filePath, blocking); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", seedgenBlockingFile, err)
+	}
+	return blocking, nil
+}
+
+// seedGenTimeout returns the timeout set in the SeedGenerator spec, falling back to the given environment variable.
+// It returns an empty string if neither is set, leaving the lca-cli default in place.
+func seedGenTimeout(specTimeout *metav1.Duration, envVar string) string {
+	if specTimeout != nil {
+		return specTimeout.Duration.String()
+	}
+	return os.Getenv(envVar)
+}
+
 // Launch a container to run the lca-cli
 func (r *SeedGeneratorReconciler) launchLCACli(seedgen *seedgenv1alpha1.SeedGenerator) error {
 	r.Log.Info("Launching lca-cli")
@@ -478,6 +548,19 @@ func (r *SeedGeneratorReconciler) launchLCACli(seedgen *seedgenv1alpha1.SeedGene
 		"--authfile", seedgenAuthFile,
 		"--image", seedgen.Spec.SeedImage,
 		"--recert-image", recertImage,
+		"--push-status-file", seedgenPushStatusFile,
+		"--blocking-component-file", seedgenBlockingFile,
+	}
+
+	if timeout := seedGenTimeout(seedgen.Spec.OvnShutdownTimeout, EnvOvnShutdownTimeout); timeout != "" {
+		lcaCliCmdArgs = append(lcaCliCmdArgs, "--ovn-shutdown-timeout", timeout)
+	}
+	if timeout := seedGenTimeout(seedgen.Spec.ContainersStopTimeout, EnvContainersStopTimeout); timeout != "" {
+		lcaCliCmdArgs = append(lcaCliCmdArgs, "--containers-stop-timeout", timeout)
+	}
+
+	for i, destination := range seedgen.Spec.AdditionalSeedImages {
+		lcaCliCmdArgs = append(lcaCliCmdArgs, "--additional-image", fmt.Sprintf("%s,%s", destination.Image, additionalSeedImageAuthFile(i)))
 	}
 
 	if skipRecert {
@@ -646,6 +729,10 @@ func (r *SeedGeneratorReconciler) generateSeedImage(ctx context.Context, seedgen
 		return fmt.Errorf("could not find seedAuth in %s secret", utils.SeedGenSecretName)
 	}
 
+	if err := writeAdditionalSeedImageAuthFiles(seedgen, seedGenSecret); err != nil {
+		return err
+	}
+
 	// Save the seedgen CR in order to restore it after the lca-cli is complete
 	if err := commonUtils.MarshalToFile(seedgen, common.PathOutsideChroot(utils.SeedGenStoredCR)); err != nil {
 		return fmt.Errorf("failed to write CR to %s: %w", utils.SeedGenStoredCR, err)
@@ -776,11 +863,28 @@ func (r *SeedGeneratorReconciler) restoreManagedCluster(ctx context.Context, clu
 }
 
 // finishSeedgen runs after the lca-cli container completes and restores kubelet, once the LCA operator restarts
-func (r *SeedGeneratorReconciler) finishSeedgen(ctx context.Context, clusterName string) error {
+func (r *SeedGeneratorReconciler) finishSeedgen(ctx context.Context, seedgen *seedgenv1alpha1.SeedGenerator, clusterName string) error {
 	if err := r.restoreManagedCluster(ctx, clusterName); err != nil {
 		return err
 	}
 
+	// Report the per-destination push results, regardless of the lca-cli exit status
+	pushStatus, err := readSeedImagePushStatus()
+	if err != nil {
+		return err
+	}
+	seedgen.Status.PushStatus = pushStatus
+
+	// Report the component that blocked the seed generation, if any
+	blocking, err := readSeedGenBlockingComponent()
+	if err != nil {
+		return err
+	}
+	if blocking != nil {
+		seedgen.Status.BlockingComponent = blocking.Component
+		return fmt.Errorf("seed generation blocked: %w", blocking)
+	}
+
 	// Check exit status of lca_cli container
 	if err := r.checkLCACliStatus(); err != nil {
 		return fmt.Errorf("lca-cli container status check failed: %w", err)
@@ -885,7 +989,7 @@ func (r *SeedGeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	} else if isSeedGenInProgress(seedgen) {
 		r.Log.Info("Completing Seed Generation")
-		if err = r.finishSeedgen(ctx, clusterName); err != nil {
+		if err = r.finishSeedgen(ctx, seedgen, clusterName); err != nil {
 			r.Log.Error(err, "Seed generation failed")
 			setSeedGenStatusFailed(seedgen, fmt.Sprintf("Seed generation failed: %s", err))
 			if err = r.updateStatus(ctx, seedgen); err != nil {
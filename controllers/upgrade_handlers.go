@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -31,6 +32,7 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/extramanifest"
 	"github.com/openshift-kni/lifecycle-agent/internal/healthcheck"
+	"github.com/openshift-kni/lifecycle-agent/internal/lvm"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
 	"github.com/openshift-kni/lifecycle-agent/internal/reboot"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
@@ -165,7 +167,11 @@ func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUp
 	}
 
 	u.Log.Info("Writing cluster-configuration into new stateroot")
-	if err := u.ClusterConfig.FetchClusterConfig(ctx, staterootVarPath); err != nil {
+	if err := u.ClusterConfig.FetchClusterConfig(ctx, staterootVarPath, ibu.Spec.Kubeadmin, ibu.Spec.KernelArguments); err != nil {
+		if errors.Is(err, clusterconfig.ErrSeedReconfigurationChanged) {
+			utils.SetUpgradeStatusFailed(ibu, err.Error())
+			return doNotRequeue(), nil
+		}
 		return requeueWithError(fmt.Errorf("error while fetching cluster configuration: %w", err))
 	}
 
@@ -174,6 +180,11 @@ func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUp
 		return requeueWithError(fmt.Errorf("error while fetching LVM configuration: %w", err))
 	}
 
+	u.Log.Info("Copying node files into new stateroot")
+	if err := u.carryOverNodeFiles(ctx, ibu, stateroot); err != nil {
+		return requeueWithError(fmt.Errorf("error while copying node files: %w", err))
+	}
+
 	// Clear any error status that may have been previously set
 	u.resetProgressMessage(ctx, ibu)
 
@@ -237,6 +248,66 @@ var getStaterootVarPath = func(stateroot string) string {
 	return common.PathOutsideChroot(filepath.Join(common.GetStaterootPath(stateroot), "/var"))
 }
 
+// statHostPath returns the file info of a path of the host
+var statHostPath = func(path string) (os.FileInfo, error) {
+	return os.Lstat(common.PathOutsideChroot(path))
+}
+
+// carryOverNodeFiles copies the node files of the spec from the current stateroot into the new one, /etc paths into
+// the new deployment and /var paths into the new stateroot var. The copy is done in the host namespace to keep the
+// ownership and SELinux labels, paths missing from the host are skipped.
+func (u *UpgHandler) carryOverNodeFiles(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade, stateroot string) error {
+	if len(ibu.Spec.NodeFiles) == 0 {
+		u.Log.Info("No node files configmap is provided")
+		return nil
+	}
+
+	configMaps, err := common.GetConfigMaps(ctx, u.Client, ibu.Spec.NodeFiles)
+	if err != nil {
+		return fmt.Errorf("failed to get node files configmaps: %w", err)
+	}
+	nodeFiles, err := common.NodeFilesFromConfigMaps(configMaps)
+	if err != nil {
+		return fmt.Errorf("invalid node files: %w", err)
+	}
+
+	deploymentDir, err := u.OstreeClient.GetDeploymentDir(stateroot)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment dir of %s: %w", stateroot, err)
+	}
+	staterootPath := common.GetStaterootPath(stateroot)
+
+	for _, nodeFile := range nodeFiles {
+		if _, err := statHostPath(nodeFile.Path); err != nil {
+			if os.IsNotExist(err) {
+				u.Log.Info("Skipping node file missing from the host", "path", nodeFile.Path)
+				continue
+			}
+			return fmt.Errorf("failed to stat node file %s: %w", nodeFile.Path, err)
+		}
+
+		dest := filepath.Join(deploymentDir, nodeFile.Path)
+		if strings.HasPrefix(nodeFile.Path, common.VarFolder+"/") {
+			dest = filepath.Join(staterootPath, nodeFile.Path)
+		}
+
+		u.Log.Info("Copying node file", "path", nodeFile.Path, "to", dest, "mode", nodeFile.Mode)
+		if _, err := u.Ops.RunInHostNamespace("mkdir", "-p", filepath.Dir(dest)); err != nil {
+			return fmt.Errorf("failed to create parent directory of %s: %w", dest, err)
+		}
+		if nodeFile.Mode == common.NodeFileCopy {
+			if _, err := u.Ops.RunInHostNamespace("rm", "-rf", dest); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", dest, err)
+			}
+		}
+		// -T copies the content of a directory into an existing one, merging them
+		if _, err := u.Ops.RunInHostNamespace("cp", "-a", "-T", nodeFile.Path, dest); err != nil {
+			return fmt.Errorf("failed to copy node file %s to %s: %w", nodeFile.Path, dest, err)
+		}
+	}
+	return nil
+}
+
 // CheckHealth helper func to call HealthChecks
 var CheckHealth = healthcheck.HealthChecks
 
@@ -263,6 +334,18 @@ func (u *UpgHandler) autoRollbackIfEnabled(ibu *lcav1alpha1.ImageBasedUpgrade, m
 // Note: All decisions, including reconciles and failures, should be made within this function.
 // The caller will simply return what this function returns.
 func (u *UpgHandler) PostPivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (ctrl.Result, error) {
+	// The post-pivot configuration may still be running when the controller starts, keep the status up to date
+	if err := lcautils.MergePostPivotProgress(ibu, common.PathOutsideChroot(common.PostPivotProgressFile)); err != nil {
+		u.Log.Error(err, "failed to merge post pivot progress into the IBU status")
+	}
+	hadRecertSummary := ibu.Status.RecertSummary != nil
+	if err := lcautils.MergeRecertSummary(ibu, common.PathOutsideChroot(common.RecertSummaryDigestFile)); err != nil {
+		u.Log.Error(err, "failed to merge recert summary into the IBU status")
+	}
+	if !hadRecertSummary && ibu.Status.RecertSummary != nil {
+		u.Recorder.Event(ibu, v1.EventTypeNormal, "Recert", lcautils.RecertSummaryMessage(ibu.Status.RecertSummary))
+	}
+
 	u.Log.Info("Starting health check for different components")
 	err := CheckHealth(u.Client, u.Log)
 	if err != nil {
@@ -319,6 +402,18 @@ func (u *UpgHandler) PostPivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedU
 		return result, nil
 	}
 
+	// The LVMS objects recorded before the pivot are expected to be restored and ready
+	lvmRecord := &lvm.Record{}
+	if err := lcautils.ReadYamlOrJSONFile(common.PathOutsideChroot(common.LvmRecordFile), lvmRecord); err != nil {
+		if !os.IsNotExist(err) {
+			return requeueWithError(fmt.Errorf("error while reading lvm record: %w", err))
+		}
+	} else if err := lvm.VerifyObjects(ctx, u.Client, lvmRecord); err != nil {
+		u.Log.Info("LVMS objects are not restored and ready yet", "error", err.Error())
+		utils.SetUpgradeStatusInProgress(ibu, fmt.Sprintf("Waiting for LVMS objects: %s", err))
+		return requeueWithMediumInterval(), nil
+	}
+
 	if err := u.RebootClient.DisableInitMonitor(); err != nil {
 		// Don't fail the upgrade on failure here, just log it
 		u.Log.Error(err, "unable to disable LCA init monitor")
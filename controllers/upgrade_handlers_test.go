@@ -13,6 +13,7 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
 	"github.com/openshift-kni/lifecycle-agent/internal/backuprestore"
 	mock_backuprestore "github.com/openshift-kni/lifecycle-agent/internal/backuprestore/mocks"
+	"github.com/openshift-kni/lifecycle-agent/internal/clusterconfig"
 	mock_clusterconfig "github.com/openshift-kni/lifecycle-agent/internal/clusterconfig/mocks"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/extramanifest"
@@ -24,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	"go.uber.org/mock/gomock"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -617,6 +619,49 @@ func TestImageBasedUpgradeReconciler_prePivot(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "FetchClusterConfig with a cluster configuration changed since Prep",
+			args: args{
+				ibu: lcav1alpha1.ImageBasedUpgrade{},
+			},
+			getSortedBackupsFromConfigmapReturn: func() ([][]*velerov1.Backup, error) {
+				return nil, nil
+			},
+			remountSysrootReturn: func() error {
+				return nil
+			},
+			exportOadpConfigurationToDirReturn: func() error {
+				return nil
+			},
+			exportRestoresToDirReturn: func() error {
+				return nil
+			},
+			extractAndExportManifestFromPoliciesToDirReturn: func() error {
+				return nil
+			},
+			exportExtraManifestToDirReturn: func() error {
+				return nil
+			},
+			fetchClusterConfigReturn: func() error {
+				return fmt.Errorf("%w: changed fields: pull_secret", clusterconfig.ErrSeedReconfigurationChanged)
+			},
+			want:    doNotRequeue(),
+			wantErr: assert.NoError,
+			wantConditions: []metav1.Condition{
+				{
+					Type:    string(utils.ConditionTypes.UpgradeCompleted),
+					Reason:  string(utils.ConditionReasons.Failed),
+					Status:  metav1.ConditionFalse,
+					Message: "Upgrade failed",
+				},
+				{
+					Type:    string(utils.ConditionTypes.UpgradeInProgress),
+					Reason:  string(utils.ConditionReasons.Failed),
+					Status:  metav1.ConditionFalse,
+					Message: "the cluster configuration changed since the recert dry-run at Prep, abort and retry the upgrade: changed fields: pull_secret",
+				},
+			},
+		},
 		{
 			name: "Export IBU Crs successfully and reboot fail",
 			args: args{
@@ -694,7 +739,7 @@ func TestImageBasedUpgradeReconciler_prePivot(t *testing.T) {
 				mockExtramanifest.EXPECT().ExportExtraManifestToDir(gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.exportExtraManifestToDirReturn()).Times(1)
 			}
 			if tt.fetchClusterConfigReturn != nil {
-				mockClusterconfig.EXPECT().FetchClusterConfig(gomock.Any(), gomock.Any()).Return(tt.fetchClusterConfigReturn()).Times(1)
+				mockClusterconfig.EXPECT().FetchClusterConfig(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.fetchClusterConfigReturn()).Times(1)
 			}
 			if tt.fetchLvmConfigReturn != nil {
 				mockClusterconfig.EXPECT().FetchLvmConfig(gomock.Any(), gomock.Any()).Return(tt.fetchLvmConfigReturn()).Times(1)
@@ -1052,3 +1097,62 @@ func TestImageBasedUpgradeReconciler_postPivot(t *testing.T) {
 		})
 	}
 }
+
+func TestCarryOverNodeFiles(t *testing.T) {
+	defer func(stat func(string) (os.FileInfo, error)) { statHostPath = stat }(statHostPath)
+	statHostPath = func(path string) (os.FileInfo, error) {
+		if path == "/var/lib/missing" {
+			return nil, os.ErrNotExist
+		}
+		return nil, nil
+	}
+
+	nodeFilesCM := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-files", Namespace: common.LcaNamespace},
+		Data: map[string]string{
+			"files": `- path: /etc/multipath
+- path: /etc/udev/rules.d
+  mode: merge
+- path: /var/lib/myapp
+- path: /var/lib/missing
+`,
+		},
+	}
+	ibu := &lcav1alpha1.ImageBasedUpgrade{
+		Spec: lcav1alpha1.ImageBasedUpgradeSpec{
+			NodeFiles: []lcav1alpha1.ConfigMapRef{{Name: "node-files", Namespace: common.LcaNamespace}},
+		},
+	}
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+	mockOps := ops.NewMockOps(mockController)
+	ostreeclientMock := ostreeclient.NewMockIClient(mockController)
+
+	deploymentDir := "/ostree/deploy/rhcos_4.14.0/deploy/abc.0"
+	staterootPath := common.GetStaterootPath("rhcos_4.14.0")
+	ostreeclientMock.EXPECT().GetDeploymentDir("rhcos_4.14.0").Return(deploymentDir, nil)
+	gomock.InOrder(
+		mockOps.EXPECT().RunInHostNamespace("mkdir", "-p", deploymentDir+"/etc").Return("", nil),
+		mockOps.EXPECT().RunInHostNamespace("rm", "-rf", deploymentDir+"/etc/multipath").Return("", nil),
+		mockOps.EXPECT().RunInHostNamespace("cp", "-a", "-T", "/etc/multipath", deploymentDir+"/etc/multipath").Return("", nil),
+		mockOps.EXPECT().RunInHostNamespace("mkdir", "-p", deploymentDir+"/etc/udev").Return("", nil),
+		mockOps.EXPECT().RunInHostNamespace("cp", "-a", "-T", "/etc/udev/rules.d", deploymentDir+"/etc/udev/rules.d").Return("", nil),
+		mockOps.EXPECT().RunInHostNamespace("mkdir", "-p", staterootPath+"/var/lib").Return("", nil),
+		mockOps.EXPECT().RunInHostNamespace("rm", "-rf", staterootPath+"/var/lib/myapp").Return("", nil),
+		mockOps.EXPECT().RunInHostNamespace("cp", "-a", "-T", "/var/lib/myapp", staterootPath+"/var/lib/myapp").Return("", nil),
+	)
+
+	fakeClient, err := getFakeClientFromObjects(nodeFilesCM)
+	assert.NoError(t, err)
+	uh := &UpgHandler{
+		Client:       fakeClient,
+		Log:          logr.Discard(),
+		Ops:          mockOps,
+		OstreeClient: ostreeclientMock,
+	}
+	assert.NoError(t, uh.carryOverNodeFiles(context.Background(), ibu, "rhcos_4.14.0"))
+
+	// Nothing to do without node files
+	assert.NoError(t, uh.carryOverNodeFiles(context.Background(), &lcav1alpha1.ImageBasedUpgrade{}, "rhcos_4.14.0"))
+}
@@ -332,7 +332,7 @@ func SetPrepStatusCompleted(ibu *lcav1alpha1.ImageBasedUpgrade, msg string) {
 		GetCompletedConditionType(lcav1alpha1.Stages.Prep),
 		ConditionReasons.Completed,
 		metav1.ConditionTrue,
-		msg,
+		"Upgrade completed",
 		ibu.Generation)
 }
 
@@ -18,9 +18,16 @@ const (
 	SeedgenWorkspacePath string = common.LCAConfigDir + "/ibu-seedgen-orch" // The LCAConfigDir folder is excluded from the var.tgz backup in seed image creation
 )
 
+// The names of the files the seedgen orchestration stores in the SeedgenWorkspacePath
+const (
+	SeedGenStoredCRFileName       string = "seedgen-cr.json"
+	SeedGenStoredSecretCRFileName string = "seedgen-secret.json"
+	StoredPullSecretFileName      string = "pull-secret.json"
+)
+
 var (
-	SeedGenStoredCR       = filepath.Join(SeedgenWorkspacePath, "seedgen-cr.json")
-	SeedGenStoredSecretCR = filepath.Join(SeedgenWorkspacePath, "seedgen-secret.json")
+	SeedGenStoredCR       = filepath.Join(SeedgenWorkspacePath, SeedGenStoredCRFileName)
+	SeedGenStoredSecretCR = filepath.Join(SeedgenWorkspacePath, SeedGenStoredSecretCRFileName)
 
-	StoredPullSecret = filepath.Join(SeedgenWorkspacePath, "pull-secret.json")
+	StoredPullSecret = filepath.Join(SeedgenWorkspacePath, StoredPullSecretFileName)
 )
@@ -1,14 +1,20 @@
 package clusterconfig
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/go-logr/logr"
 	v1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
 	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -17,7 +23,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	"github.com/openshift-kni/lifecycle-agent/utils"
 )
 
@@ -38,14 +46,18 @@ const (
 
 	pullSecretName = "pull-secret"
 
-	idmsFileName  = "image-digest-mirror-set.json"
 	icspsFileName = "image-content-source-policy-list.json"
 
 	caBundleCMName   = "user-ca-bundle"
 	caBundleFileName = caBundleCMName + ".json"
 
+	// ssh authorized keys files of the core user, created by mco from ssh machine configs or added by users
+	sshKeysDir = "/home/core/.ssh/authorized_keys.d"
 	// ssh authorized keys file created by mco from ssh machine configs
-	sshKeyFile = "/home/core/.ssh/authorized_keys.d/ignition"
+	sshKeyFile = sshKeysDir + "/ignition"
+	// ssh machine configs of the roles, as created by the installer
+	sshMachineConfig = "99-%s-ssh"
+	userCore         = "core"
 )
 
 var (
@@ -55,8 +67,11 @@ var (
 	}
 )
 
+// ErrSeedReconfigurationChanged is returned when the cluster configuration changed since it was validated at Prep
+var ErrSeedReconfigurationChanged = fmt.Errorf("the cluster configuration changed since the recert dry-run at Prep, abort and retry the upgrade")
+
 type UpgradeClusterConfigGatherer interface {
-	FetchClusterConfig(ctx context.Context, ostreeVarDir string) error
+	FetchClusterConfig(ctx context.Context, ostreeVarDir string, kubeadmin *lcav1alpha1.Kubeadmin, kernelArguments []string) error
 	FetchLvmConfig(ctx context.Context, ostreeVarDir string) error
 }
 
@@ -65,11 +80,15 @@ type UpgradeClusterConfigGather struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// Ops runs the LVM tools in the host namespace
+	Ops ops.Ops
 }
 
 // FetchClusterConfig collects the current cluster's configuration and write it as JSON files into
-// given filesystem directory.
-func (r *UpgradeClusterConfigGather) FetchClusterConfig(ctx context.Context, ostreeVarDir string) error {
+// given filesystem directory. The kubeadmin user and the kernel arguments of the upgraded cluster are configured as set
+// in the IBU.
+func (r *UpgradeClusterConfigGather) FetchClusterConfig(ctx context.Context, ostreeVarDir string, kubeadmin *lcav1alpha1.Kubeadmin,
+	kernelArguments []string) error {
 	r.Log.Info("Fetching cluster configuration")
 
 	clusterConfigPath, err := r.configDir(ostreeVarDir)
@@ -85,7 +104,7 @@ func (r *UpgradeClusterConfigGather) FetchClusterConfig(ctx context.Context, ost
 		return err
 	}
 
-	if err := r.fetchClusterInfo(ctx, clusterConfigPath); err != nil {
+	if err := r.fetchClusterInfo(ctx, clusterConfigPath, kubeadmin, kernelArguments); err != nil {
 		return err
 	}
 	if err := r.fetchCABundle(ctx, manifestsDir, clusterConfigPath); err != nil {
@@ -141,6 +160,56 @@ func (r *UpgradeClusterConfigGather) fetchSSHPublicKey() (string, error) {
 	return string(sshKey), err
 }
 
+// fetchSSHPublicKeys returns all the ssh public keys of the core user, from every authorized keys file and from the
+// master and worker ssh machine configs
+func (r *UpgradeClusterConfigGather) fetchSSHPublicKeys(ctx context.Context) ([]string, error) {
+	var keys []string
+
+	dir := filepath.Join(hostPath, sshKeysDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read ssh keys dir %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ssh keys file %s: %w", entry.Name(), err)
+		}
+		keys = append(keys, seedreconfig.ParseAuthorizedKeys(string(content))...)
+	}
+
+	for _, role := range []string{"master", "worker"} {
+		mc := &mcfgv1.MachineConfig{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: fmt.Sprintf(sshMachineConfig, role)}, mc); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get %s ssh machine config: %w", role, err)
+		}
+		ignConfig := struct {
+			Passwd struct {
+				Users []struct {
+					Name              string   `json:"name"`
+					SSHAuthorizedKeys []string `json:"sshAuthorizedKeys"`
+				} `json:"users"`
+			} `json:"passwd"`
+		}{}
+		if err := json.Unmarshal(mc.Spec.Config.Raw, &ignConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse the config of %s: %w", mc.Name, err)
+		}
+		for _, user := range ignConfig.Passwd.Users {
+			if user.Name == userCore {
+				keys = append(keys, seedreconfig.ParseAuthorizedKeys(strings.Join(user.SSHAuthorizedKeys, "\n"))...)
+			}
+		}
+	}
+
+	return lo.Uniq(keys), nil
+}
+
 func (r *UpgradeClusterConfigGather) fetchInfraID(ctx context.Context) (string, error) {
 	infra, err := utils.GetInfrastructure(ctx, r.Client)
 	if err != nil {
@@ -170,6 +239,34 @@ func (r *UpgradeClusterConfigGather) GetKubeadminPasswordHash(ctx context.Contex
 	return kubeadminPasswordHash, nil
 }
 
+// kubeadminPasswordHash returns the kubeadmin password hash of the upgraded cluster: the one of the secret referenced
+// in the IBU when the password is rotated, none when kubeadmin is disabled, or else the current one
+func (r *UpgradeClusterConfigGather) kubeadminPasswordHash(ctx context.Context, kubeadmin *lcav1alpha1.Kubeadmin) (string, error) {
+	switch {
+	case kubeadmin != nil && kubeadmin.Disabled:
+		r.Log.Info("Kubeadmin is disabled, its password secret will be deleted")
+		return "", nil
+	case kubeadmin != nil && kubeadmin.PasswordHashSecretRef != nil:
+		r.Log.Info("Rotating the kubeadmin password")
+		return GetKubeadminPasswordHashFromSecret(ctx, r.Client, kubeadmin.PasswordHashSecretRef)
+	default:
+		return r.GetKubeadminPasswordHash(ctx)
+	}
+}
+
+// GetKubeadminPasswordHashFromSecret returns the kubeadmin password hash held by the kubeadmin key of the referenced
+// secret, after checking that it is a bcrypt hash
+func GetKubeadminPasswordHashFromSecret(ctx context.Context, c client.Client, ref *lcav1alpha1.SecretRef) (string, error) {
+	kubeadminPasswordHash, err := utils.GetSecretData(ctx, ref.Name, ref.Namespace, "kubeadmin", c)
+	if err != nil {
+		return "", fmt.Errorf("failed to get kubeadmin password hash from secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	if err := seedreconfig.ValidateKubeadminPasswordHash(kubeadminPasswordHash); err != nil {
+		return "", fmt.Errorf("invalid kubeadmin key in secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	return kubeadminPasswordHash, nil
+}
+
 func SeedReconfigurationFromClusterInfo(clusterInfo *utils.ClusterInfo,
 	kubeconfigCryptoRetention *seedreconfig.KubeConfigCryptoRetention, sshKey, infraID, pullSecret, kubeadminPasswordHash string) *seedreconfig.SeedReconfiguration {
 	return &seedreconfig.SeedReconfiguration{
@@ -179,6 +276,10 @@ func SeedReconfigurationFromClusterInfo(clusterInfo *utils.ClusterInfo,
 		ClusterID:                 clusterInfo.ClusterID,
 		InfraID:                   infraID,
 		NodeIP:                    clusterInfo.NodeIP,
+		NodeIPs:                   clusterInfo.NodeIPs,
+		MachineNetworks:           clusterInfo.MachineNetworks,
+		ClusterNetworks:           clusterInfo.ClusterNetworks,
+		ServiceNetworks:           clusterInfo.ServiceNetworks,
 		ReleaseRegistry:           clusterInfo.ReleaseRegistry,
 		Hostname:                  clusterInfo.Hostname,
 		KubeconfigCryptoRetention: *kubeconfigCryptoRetention,
@@ -188,37 +289,107 @@ func SeedReconfigurationFromClusterInfo(clusterInfo *utils.ClusterInfo,
 	}
 }
 
-func (r *UpgradeClusterConfigGather) fetchClusterInfo(ctx context.Context, clusterConfigPath string) error {
+// fetchClusterInfo writes the seed reconfiguration of the current cluster. When the seed reconfiguration validated by
+// the recert dry-run at Prep was written, the cluster configuration must not have changed since, as the dry-run would
+// no longer cover the configuration the stateroot is reconfigured with.
+func (r *UpgradeClusterConfigGather) fetchClusterInfo(ctx context.Context, clusterConfigPath string, kubeadmin *lcav1alpha1.Kubeadmin,
+	kernelArguments []string) error {
 	r.Log.Info("Fetching ClusterInfo")
+	seedReconfiguration, err := r.SeedReconfiguration(ctx, kubeadmin, kernelArguments)
+	if err != nil {
+		return err
+	}
 
-	clusterInfo, err := utils.GetClusterInfo(ctx, r.Client)
+	filePath := filepath.Join(clusterConfigPath, common.SeedReconfigurationFileName)
+	prepSeedReconfiguration := &seedreconfig.SeedReconfiguration{}
+	if err := utils.ReadYamlOrJSONFile(filePath, prepSeedReconfiguration); err == nil {
+		if changed := changedSeedReconfigurationFields(prepSeedReconfiguration, seedReconfiguration); len(changed) > 0 {
+			return fmt.Errorf("%w: changed fields: %s", ErrSeedReconfigurationChanged, strings.Join(changed, ", "))
+		}
+		r.Log.Info("The ClusterInfo matches the one validated at Prep", "path", filePath)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read the ClusterInfo written at Prep %s: %w", filePath, err)
+	}
+
+	r.Log.Info("Writing ClusterInfo to file", "path", filePath)
+	return utils.MarshalToFile(seedReconfiguration, filePath)
+}
+
+// changedSeedReconfigurationFields returns the JSON names of the fields that differ between the two seed
+// reconfigurations, without their values as some are secrets
+func changedSeedReconfigurationFields(a, b *seedreconfig.SeedReconfiguration) []string {
+	toFields := func(s *seedreconfig.SeedReconfiguration) map[string]json.RawMessage {
+		fields := map[string]json.RawMessage{}
+		if data, err := json.Marshal(s); err == nil {
+			_ = json.Unmarshal(data, &fields)
+		}
+		return fields
+	}
+	aFields, bFields := toFields(a), toFields(b)
+	for name := range bFields {
+		if _, ok := aFields[name]; !ok {
+			aFields[name] = nil
+		}
+	}
+
+	var changed []string
+	for name, value := range aFields {
+		if !bytes.Equal(value, bFields[name]) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// WriteSeedReconfiguration writes the given seed reconfiguration into the cluster configuration of the stateroot, so
+// that the seed reconfiguration gathered at upgrade can be checked against the one validated at Prep
+func (r *UpgradeClusterConfigGather) WriteSeedReconfiguration(ostreeVarDir string, seedReconfiguration *seedreconfig.SeedReconfiguration) error {
+	clusterConfigPath, err := r.configDir(ostreeVarDir)
 	if err != nil {
 		return err
 	}
+	filePath := filepath.Join(clusterConfigPath, common.SeedReconfigurationFileName)
+	r.Log.Info("Writing ClusterInfo to file", "path", filePath)
+	return utils.MarshalToFile(seedReconfiguration, filePath)
+}
+
+// SeedReconfiguration returns the seed reconfiguration that transforms the seed into the current cluster, with its
+// kubeadmin user and kernel arguments configured as set in the IBU
+func (r *UpgradeClusterConfigGather) SeedReconfiguration(ctx context.Context, kubeadmin *lcav1alpha1.Kubeadmin,
+	kernelArguments []string) (*seedreconfig.SeedReconfiguration, error) {
+	clusterInfo, err := utils.GetClusterInfo(ctx, r.Client)
+	if err != nil {
+		return nil, err
+	}
 
 	seedReconfigurationKubeconfigRetention, err := utils.SeedReconfigurationKubeconfigRetentionFromCluster(ctx, r.Client)
 	if err != nil {
-		return fmt.Errorf("failed to get kubeconfig retention from crypto dir: %w", err)
+		return nil, fmt.Errorf("failed to get kubeconfig retention from crypto dir: %w", err)
 	}
 
 	sshKey, err := r.fetchSSHPublicKey()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	sshKeys, err := r.fetchSSHPublicKeys(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	infraID, err := r.fetchInfraID(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	pullSecret, err := r.fetchPullSecret(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	kubeadminPasswordHash, err := r.GetKubeadminPasswordHash(ctx)
+	kubeadminPasswordHash, err := r.kubeadminPasswordHash(ctx, kubeadmin)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	seedReconfiguration := SeedReconfigurationFromClusterInfo(clusterInfo, seedReconfigurationKubeconfigRetention,
@@ -227,10 +398,9 @@ func (r *UpgradeClusterConfigGather) fetchClusterInfo(ctx context.Context, clust
 		pullSecret,
 		kubeadminPasswordHash,
 	)
-
-	filePath := filepath.Join(clusterConfigPath, common.SeedReconfigurationFileName)
-	r.Log.Info("Writing ClusterInfo to file", "path", filePath)
-	return utils.MarshalToFile(seedReconfiguration, filePath)
+	seedReconfiguration.SSHKeys = sshKeys
+	seedReconfiguration.KernelArguments = kernelArguments
+	return seedReconfiguration, nil
 }
 
 func (r *UpgradeClusterConfigGather) fetchIDMS(ctx context.Context, manifestsDir string) error {
@@ -245,7 +415,7 @@ func (r *UpgradeClusterConfigGather) fetchIDMS(ctx context.Context, manifestsDir
 		return nil
 	}
 
-	filePath := filepath.Join(manifestsDir, idmsFileName)
+	filePath := filepath.Join(manifestsDir, common.IDMSFileName)
 	r.Log.Info("Writing IDMS to file", "path", filePath)
 	return utils.MarshalToFile(idms, filePath)
 }
@@ -18,6 +18,7 @@ package clusterconfig
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -34,11 +35,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/utils"
 )
@@ -156,6 +159,23 @@ var (
 		},
 		Data: map[string][]byte{"kubeadmin": []byte(`$2a$10$20Q4iRLy7cWZkjn/D07bF.RZQZonKwstyRGH0qiYbYRkx5Pe4Ztyi`)},
 	}
+
+	rotatedKubeadminSecrets = []client.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kubeadmin-rotated",
+				Namespace: common.LcaNamespace,
+			},
+			Data: map[string][]byte{"kubeadmin": []byte(`$2a$10$Cg3Fy7i1Ikf8fSxJDmY5Euf4hWp6pGqFYt6pQ4Xl0dKxjB6iQnVJa`)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kubeadmin-invalid",
+				Namespace: common.LcaNamespace,
+			},
+			Data: map[string][]byte{"kubeadmin": []byte("password")},
+		},
+	}
 )
 
 func init() {
@@ -231,8 +251,15 @@ func TestClusterConfig(t *testing.T) {
 		node            client.Object
 		proxy           client.Object
 		deleteKubeadmin bool
-		expectedErr     bool
-		validateFunc    func(t *testing.T, tempDir string, err error, ucc UpgradeClusterConfigGather)
+		kubeadmin       *lcav1alpha1.Kubeadmin
+		kernelArguments []string
+		// prepSeedReconfiguration is the seed reconfiguration written at Prep, if any
+		prepSeedReconfiguration *seedreconfig.SeedReconfiguration
+		// prepSeedReconfigurationSet writes the seed reconfiguration of the cluster, with prepKernelArguments, at Prep
+		prepSeedReconfigurationSet bool
+		prepKernelArguments        []string
+		expectedErr                bool
+		validateFunc               func(t *testing.T, tempDir string, err error, ucc UpgradeClusterConfigGather)
 	}{
 		{
 			testCaseName:   "Validate success flow",
@@ -261,7 +288,7 @@ func TestClusterConfig(t *testing.T) {
 
 				// validate pull idms
 				idms := &ocpV1.ImageDigestMirrorSetList{}
-				if err := utils.ReadYamlOrJSONFile(filepath.Join(manifestsDir, idmsFileName), idms); err != nil {
+				if err := utils.ReadYamlOrJSONFile(filepath.Join(manifestsDir, common.IDMSFileName), idms); err != nil {
 					t.Errorf("unexpected error: %v", err)
 				}
 				if assert.Equal(t, 1, len(idms.Items)) {
@@ -278,9 +305,14 @@ func TestClusterConfig(t *testing.T) {
 				assert.Equal(t, "mysno-xsb4m", seedReconfig.InfraID)
 				assert.Equal(t, "pull-secret", seedReconfig.PullSecret)
 				assert.Equal(t, "ssh-key", seedReconfig.SSHKey)
+				assert.Equal(t, []string{"ssh-key"}, seedReconfig.SSHKeys)
 				assert.Equal(t, "test-infra-cluster", seedReconfig.ClusterName)
 				assert.Equal(t, "redhat.com", seedReconfig.BaseDomain)
 				assert.Equal(t, "192.168.121.10", seedReconfig.NodeIP)
+				assert.Equal(t, []string{"192.168.121.10"}, seedReconfig.NodeIPs)
+				assert.Equal(t, []string{"192.168.127.0/24"}, seedReconfig.MachineNetworks)
+				assert.Equal(t, []seedreconfig.ClusterNetworkEntry{{CIDR: "172.30.0.0/16", HostPrefix: 23}}, seedReconfig.ClusterNetworks)
+				assert.Equal(t, []string{"10.128.0.0/14"}, seedReconfig.ServiceNetworks)
 				assert.Equal(t, "mirror.redhat.com:5005", seedReconfig.ReleaseRegistry)
 			},
 		},
@@ -318,6 +350,106 @@ func TestClusterConfig(t *testing.T) {
 				assert.Equal(t, "", seedReconfig.KubeadminPasswordHash)
 			},
 		},
+		{
+			testCaseName:   "kubeadmin password rotated",
+			pullSecret:     defaultPullSecret,
+			clusterVersion: defaultClusterVersion,
+			node:           validMasterNode,
+			proxy:          defaultProxy,
+			kubeadmin: &lcav1alpha1.Kubeadmin{
+				PasswordHashSecretRef: &lcav1alpha1.SecretRef{Name: "kubeadmin-rotated", Namespace: common.LcaNamespace},
+			},
+			expectedErr: false,
+			validateFunc: func(t *testing.T, tempDir string, err error, ucc UpgradeClusterConfigGather) {
+				seedReconfig, err := getSeedReconfigFromUcc(ucc, tempDir)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				assert.Equal(t, "$2a$10$Cg3Fy7i1Ikf8fSxJDmY5Euf4hWp6pGqFYt6pQ4Xl0dKxjB6iQnVJa", seedReconfig.KubeadminPasswordHash)
+			},
+		},
+		{
+			testCaseName:   "kubeadmin password rotated to an invalid hash",
+			pullSecret:     defaultPullSecret,
+			clusterVersion: defaultClusterVersion,
+			node:           validMasterNode,
+			proxy:          defaultProxy,
+			kubeadmin: &lcav1alpha1.Kubeadmin{
+				PasswordHashSecretRef: &lcav1alpha1.SecretRef{Name: "kubeadmin-invalid", Namespace: common.LcaNamespace},
+			},
+			expectedErr: true,
+			validateFunc: func(t *testing.T, tempDir string, err error, ucc UpgradeClusterConfigGather) {
+				assert.ErrorContains(t, err, "invalid kubeadmin key in secret openshift-lifecycle-agent/kubeadmin-invalid")
+			},
+		},
+		{
+			testCaseName:   "kubeadmin disabled",
+			pullSecret:     defaultPullSecret,
+			clusterVersion: defaultClusterVersion,
+			node:           validMasterNode,
+			proxy:          defaultProxy,
+			kubeadmin:      &lcav1alpha1.Kubeadmin{Disabled: true},
+			expectedErr:    false,
+			validateFunc: func(t *testing.T, tempDir string, err error, ucc UpgradeClusterConfigGather) {
+				seedReconfig, err := getSeedReconfigFromUcc(ucc, tempDir)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				assert.Equal(t, "", seedReconfig.KubeadminPasswordHash)
+			},
+		},
+		{
+			testCaseName:   "seed reconfiguration written at Prep that no longer matches the cluster fails",
+			pullSecret:     defaultPullSecret,
+			clusterVersion: defaultClusterVersion,
+			node:           validMasterNode,
+			proxy:          defaultProxy,
+			prepSeedReconfiguration: &seedreconfig.SeedReconfiguration{
+				ClusterName: "prep",
+				PullSecret:  "prep-pull-secret",
+			},
+			expectedErr: true,
+			validateFunc: func(t *testing.T, tempDir string, err error, ucc UpgradeClusterConfigGather) {
+				assert.ErrorContains(t, err, "the cluster configuration changed since the recert dry-run at Prep")
+				assert.ErrorContains(t, err, "cluster_name")
+				assert.ErrorContains(t, err, "pull_secret")
+				assert.NotContains(t, err.Error(), "prep-pull-secret")
+			},
+		},
+		{
+			testCaseName:               "seed reconfiguration written at Prep that matches the cluster is kept",
+			pullSecret:                 defaultPullSecret,
+			clusterVersion:             defaultClusterVersion,
+			node:                       validMasterNode,
+			proxy:                      defaultProxy,
+			kernelArguments:            []string{"nosmt"},
+			prepSeedReconfigurationSet: true,
+			prepKernelArguments:        []string{"nosmt"},
+			expectedErr:                false,
+			validateFunc: func(t *testing.T, tempDir string, err error, ucc UpgradeClusterConfigGather) {
+				seedReconfig, err := getSeedReconfigFromUcc(ucc, tempDir)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				assert.Equal(t, "pull-secret", seedReconfig.PullSecret)
+				assert.Equal(t, []string{"nosmt"}, seedReconfig.KernelArguments)
+			},
+		},
+		{
+			testCaseName:               "kernel arguments changed since Prep fails",
+			pullSecret:                 defaultPullSecret,
+			clusterVersion:             defaultClusterVersion,
+			node:                       validMasterNode,
+			proxy:                      defaultProxy,
+			kernelArguments:            []string{"nosmt", "isolcpus=2-3"},
+			prepSeedReconfigurationSet: true,
+			prepKernelArguments:        []string{"nosmt"},
+			expectedErr:                true,
+			validateFunc: func(t *testing.T, tempDir string, err error, ucc UpgradeClusterConfigGather) {
+				assert.ErrorIs(t, err, ErrSeedReconfigurationChanged)
+				assert.ErrorContains(t, err, "changed fields: kernel_arguments")
+			},
+		},
 		{
 			testCaseName:   " clusterversion error",
 			pullSecret:     defaultPullSecret,
@@ -398,7 +530,7 @@ func TestClusterConfig(t *testing.T) {
 
 				// validate pull idms
 				idms := &ocpV1.ImageDigestMirrorSetList{}
-				if err := utils.ReadYamlOrJSONFile(filepath.Join(manifestsDir, idmsFileName), idms); err != nil {
+				if err := utils.ReadYamlOrJSONFile(filepath.Join(manifestsDir, common.IDMSFileName), idms); err != nil {
 					t.Errorf("unexpected error: %v", err)
 				}
 				if assert.Equal(t, 1, len(idms.Items)) {
@@ -455,6 +587,7 @@ func TestClusterConfig(t *testing.T) {
 			for _, kcro := range kubeconfigRetentionObjects {
 				objs = append(objs, kcro)
 			}
+			objs = append(objs, rotatedKubeadminSecrets...)
 
 			if tc.icsps != nil {
 				for _, icsp := range tc.icsps {
@@ -506,7 +639,19 @@ func TestClusterConfig(t *testing.T) {
 				t.Errorf("failed to create seed manifest, error: %v", err)
 			}
 
-			err = ucc.FetchClusterConfig(context.TODO(), tmpDir)
+			if tc.prepSeedReconfigurationSet {
+				tc.prepSeedReconfiguration, err = ucc.SeedReconfiguration(context.TODO(), tc.kubeadmin, tc.prepKernelArguments)
+				if err != nil {
+					t.Errorf("failed to get seed reconfiguration, error: %v", err)
+				}
+			}
+			if tc.prepSeedReconfiguration != nil {
+				if err := ucc.WriteSeedReconfiguration(tmpDir, tc.prepSeedReconfiguration); err != nil {
+					t.Errorf("failed to write seed reconfiguration, error: %v", err)
+				}
+			}
+
+			err = ucc.FetchClusterConfig(context.TODO(), tmpDir, tc.kubeadmin, tc.kernelArguments)
 			if !tc.expectedErr && err != nil {
 				t.Errorf("unexpected error: %v", err)
 			}
@@ -596,3 +741,69 @@ func TestNetworkConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchSSHPublicKeys(t *testing.T) {
+	defer func(path string) { hostPath = path }(hostPath)
+	sshMachineConfig := func(role string, keys ...string) *mcv1.MachineConfig {
+		config, err := json.Marshal(map[string]any{
+			"ignition": map[string]string{"version": "3.2.0"},
+			"passwd": map[string]any{
+				"users": []any{map[string]any{"name": userCore, "sshAuthorizedKeys": keys}},
+			},
+		})
+		assert.NoError(t, err)
+		return &mcv1.MachineConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("99-%s-ssh", role)},
+			Spec:       mcv1.MachineConfigSpec{Config: runtime.RawExtension{Raw: config}},
+		}
+	}
+
+	testcases := []struct {
+		name           string
+		files          map[string]string
+		machineConfigs []client.Object
+		expected       []string
+	}{
+		{
+			name:     "Nothing found",
+			expected: []string{},
+		},
+		{
+			name: "Keys of all files and of the master and worker machine configs",
+			files: map[string]string{
+				"ignition":        "ssh-ed25519 AAAA team-a\n",
+				"ib-early-access": "ssh-ed25519 AAAA team-a\nssh-rsa BBBB team-b\n",
+				"team-c":          "# team c\nssh-ed25519 CCCC team-c\n",
+			},
+			machineConfigs: []client.Object{
+				sshMachineConfig("master", "ssh-ed25519 AAAA team-a"),
+				sshMachineConfig("worker", "ssh-ed25519 DDDD team-d\nssh-ed25519 EEEE team-e"),
+			},
+			expected: []string{
+				"ssh-ed25519 AAAA team-a",
+				"ssh-rsa BBBB team-b",
+				"ssh-ed25519 CCCC team-c",
+				"ssh-ed25519 DDDD team-d",
+				"ssh-ed25519 EEEE team-e",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			hostPath = tmpDir
+			for name, content := range tc.files {
+				assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, sshKeysDir), 0o700))
+				assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, sshKeysDir, name), []byte(content), 0o600))
+			}
+			fakeClient, err := getFakeClientFromObjects(tc.machineConfigs...)
+			assert.NoError(t, err)
+
+			ucc := UpgradeClusterConfigGather{Client: fakeClient, Log: logr.Discard()}
+			keys, err := ucc.fetchSSHPublicKeys(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, keys)
+		})
+	}
+}
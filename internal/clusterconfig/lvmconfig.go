@@ -18,10 +18,13 @@ package clusterconfig
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/lvm"
+	"github.com/openshift-kni/lifecycle-agent/utils"
 	cp "github.com/otiai10/copy"
 )
 
@@ -37,11 +40,19 @@ func (r *UpgradeClusterConfigGather) FetchLvmConfig(ctx context.Context, ostreeD
 		filepath.Join(hostPath, common.LvmDevicesPath),
 		filepath.Join(lvmConfigPath, filepath.Base(common.LvmDevicesPath)))
 	if err != nil {
-		if os.IsNotExist(err) {
-			r.Log.Info("lvm devices file does not exist")
-			return nil
+		if !os.IsNotExist(err) {
+			return err
 		}
-		return err
+		r.Log.Info("lvm devices file does not exist")
+	}
+
+	r.Log.Info("Recording lvm layout and LVMS objects")
+	record, err := lvm.NewRecord(ctx, r.Client, r.Ops)
+	if err != nil {
+		return fmt.Errorf("failed to record lvm configuration: %w", err)
+	}
+	if err := utils.MarshalToFile(record, filepath.Join(lvmConfigPath, lvm.RecordFileName)); err != nil {
+		return fmt.Errorf("failed to write lvm record: %w", err)
 	}
 	r.Log.Info("Done fetching node lvm files")
 	return nil
@@ -24,7 +24,11 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/lvm"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/utils"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
 )
 
 func TestFetchLvmConfig(t *testing.T) {
@@ -66,11 +70,19 @@ func TestFetchLvmConfig(t *testing.T) {
 				file.Close()
 			}
 
+			mockOps := ops.NewMockOps(gomock.NewController(t))
+			mockOps.EXPECT().RunInHostNamespace("vgs", gomock.Any()).Return(`{"report":[{"vg":[]}]}`, nil)
+			mockOps.EXPECT().RunInHostNamespace("lvs", gomock.Any()).Return(`{"report":[{"lv":[]}]}`, nil)
+			fakeClient, err := getFakeClientFromObjects()
+			assert.NoError(t, err)
+
 			ucc := &UpgradeClusterConfigGather{
-				Log: logr.Discard(),
+				Client: fakeClient,
+				Log:    logr.Discard(),
+				Ops:    mockOps,
 			}
 
-			err := ucc.FetchLvmConfig(context.Background(), tmpDir)
+			err = ucc.FetchLvmConfig(context.Background(), tmpDir)
 			if err != nil {
 				if tc.expectedErr {
 					assert.Error(t, err)
@@ -89,6 +101,11 @@ func TestFetchLvmConfig(t *testing.T) {
 				}
 			}
 			assert.Equal(t, tc.expectedNumOfFiles, len(tc.lvmFilesToCopy))
+
+			// Verify that the lvm configuration was recorded
+			record := &lvm.Record{}
+			assert.NoError(t, utils.ReadYamlOrJSONFile(
+				filepath.Join(tmpDir, common.OptOpenshift, common.LvmConfigDir, lvm.RecordFileName), record))
 		})
 	}
 }
@@ -45,16 +45,22 @@ const (
 	KubeconfigCryptoDir               = "kubeconfig-crypto"
 	ClusterConfigDir                  = "cluster-configuration"
 	SeedClusterInfoFileName           = "manifest.json"
+	SeedSBOMFileName                  = "seed-sbom.json"
 	SeedReconfigurationFileName       = "manifest.json"
 	ManifestsDir                      = "manifests"
 	ExtraManifestsDir                 = "extra-manifests"
+	RecertContainerName               = "recert"
 	EtcdContainerName                 = "recert_etcd"
+	EtcdDryRunContainerName           = "recert_dry_run_etcd"
 	LvmConfigDir                      = "lvm-configuration"
 	LvmDevicesPath                    = "/etc/lvm/devices/system.devices"
 	CABundleFilePath                  = "/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem"
 
 	LCAConfigDir                                    = "/var/lib/lca"
 	IBUAutoRollbackConfigFile                       = LCAConfigDir + "/autorollback_config.json"
+	PostPivotProgressFile                           = LCAConfigDir + "/postpivot_progress.json"
+	RecertSummaryDigestFile                         = LCAConfigDir + "/recert_summary_digest.json"
+	LvmRecordFile                                   = LCAConfigDir + "/lvm_record.json"
 	IBUAutoRollbackInitMonitorTimeoutDefaultSeconds = 1800
 	IBUInitMonitorService                           = "lca-init-monitor.service"
 	IBUInitMonitorServiceFile                       = "/etc/systemd/system/" + IBUInitMonitorService
@@ -62,6 +68,18 @@ const (
 	LcaNamespace = "openshift-lifecycle-agent"
 	Host         = "/host"
 
+	// IdentityRecordConfigMapName is the configmap of the LCA namespace holding the identity record written by post pivot
+	IdentityRecordConfigMapName = "lca-cluster-identity"
+	// IdentityRecordKeySecretName is the secret of the LCA namespace holding the key the identity record is signed with
+	IdentityRecordKeySecretName = "lca-cluster-identity-key"
+
+	// IDMSFileName is the manifest of the ImageDigestMirrorSets applied post pivot, either gathered from the original
+	// SNO during an IBU or rendered from the seed reconfiguration image digest sources
+	IDMSFileName = "image-digest-mirror-set.json"
+	// InstallerIDMSName is the name of the ImageDigestMirrorSet the installer creates from the install-config
+	// imageDigestSources, which the one rendered from the seed reconfiguration replaces
+	InstallerIDMSName = "image-digest-mirror"
+
 	CsvDeploymentName      = "cluster-version-operator"
 	CsvDeploymentNamespace = "openshift-cluster-version"
 	// InstallConfigCM cm name
@@ -0,0 +1,112 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// NodeFileMode defines how a node file is carried over to the new stateroot
+type NodeFileMode string
+
+const (
+	// NodeFileCopy replaces the path of the new stateroot with the one of the current stateroot
+	NodeFileCopy NodeFileMode = "copy"
+	// NodeFileMerge copies the path of the current stateroot over the one of the new stateroot, keeping the files
+	// that only the new stateroot has
+	NodeFileMerge NodeFileMode = "merge"
+)
+
+// NodeFile is a node-local path carried over to the new stateroot
+type NodeFile struct {
+	Path string       `json:"path"`
+	Mode NodeFileMode `json:"mode,omitempty"`
+}
+
+// nodeFileRoots are the directories of the stateroot that node files can be carried over from
+var nodeFileRoots = []string{"/etc", VarFolder}
+
+// reservedNodeFilePaths are managed by the upgrade itself, they can't be carried over, nor any of their parents
+var reservedNodeFilePaths = []string{
+	"/etc/kubernetes",
+	"/etc/machine-config-daemon",
+	NMConnectionFolder,
+	"/var/lib/containers",
+	"/var/lib/etcd",
+	"/var/lib/kubelet",
+	LCAConfigDir,
+	filepath.Join(VarFolder, OptOpenshift),
+	SeedDataDir,
+}
+
+// Validate checks that the node file is a path below /etc or /var that is not managed by the upgrade, with a known mode
+func (f *NodeFile) Validate() error {
+	if !filepath.IsAbs(f.Path) || filepath.Clean(f.Path) != f.Path {
+		return fmt.Errorf("node file path %q must be an absolute and clean path", f.Path)
+	}
+	inRoot := false
+	for _, root := range nodeFileRoots {
+		if strings.HasPrefix(f.Path, root+"/") {
+			inRoot = true
+		}
+	}
+	if !inRoot {
+		return fmt.Errorf("node file path %s must be below one of %s", f.Path, strings.Join(nodeFileRoots, ", "))
+	}
+	for _, reserved := range reservedNodeFilePaths {
+		if f.Path == reserved || strings.HasPrefix(f.Path, reserved+"/") || strings.HasPrefix(reserved, f.Path+"/") {
+			return fmt.Errorf("node file path %s overlaps %s, which is managed by the upgrade", f.Path, reserved)
+		}
+	}
+	switch f.Mode {
+	case "", NodeFileCopy, NodeFileMerge:
+	default:
+		return fmt.Errorf("node file %s has an unknown mode %q, must be %s or %s", f.Path, f.Mode, NodeFileCopy, NodeFileMerge)
+	}
+	return nil
+}
+
+// NodeFilesFromConfigMaps returns the node files listed in the configmaps, each value of which is a YAML list of node
+// files. The mode defaults to copy.
+func NodeFilesFromConfigMaps(configMaps []corev1.ConfigMap) ([]NodeFile, error) {
+	var (
+		nodeFiles []NodeFile
+		errs      []error
+	)
+	paths := map[string]bool{}
+	for _, cm := range configMaps {
+		keys := make([]string, 0, len(cm.Data))
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			var entries []NodeFile
+			if err := yaml.UnmarshalStrict([]byte(cm.Data[key]), &entries); err != nil {
+				errs = append(errs, fmt.Errorf("failed to parse node files of %s/%s key %s: %w", cm.Namespace, cm.Name, key, err))
+				continue
+			}
+			for _, entry := range entries {
+				if err := entry.Validate(); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				if paths[entry.Path] {
+					errs = append(errs, fmt.Errorf("node file path %s is listed more than once", entry.Path))
+					continue
+				}
+				paths[entry.Path] = true
+				if entry.Mode == "" {
+					entry.Mode = NodeFileCopy
+				}
+				nodeFiles = append(nodeFiles, entry)
+			}
+		}
+	}
+	return nodeFiles, errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeFilesFromConfigMaps(t *testing.T) {
+	testcases := []struct {
+		name           string
+		data           map[string]string
+		expected       []NodeFile
+		expectedErrors []string
+	}{
+		{
+			name: "Node files of several keys, in order",
+			data: map[string]string{
+				"b": "- path: /var/lib/myapp\n  mode: merge\n",
+				"a": "- path: /etc/multipath\n- path: /etc/iscsi/initiatorname.iscsi\n  mode: copy\n",
+			},
+			expected: []NodeFile{
+				{Path: "/etc/multipath", Mode: NodeFileCopy},
+				{Path: "/etc/iscsi/initiatorname.iscsi", Mode: NodeFileCopy},
+				{Path: "/var/lib/myapp", Mode: NodeFileMerge},
+			},
+		},
+		{
+			name: "Invalid node files",
+			data: map[string]string{
+				"files": `- path: etc/multipath
+- path: /etc/../root
+- path: /usr/local/bin
+- path: /var
+- path: /var/lib
+- path: /etc/kubernetes/manifests
+- path: /etc/chrony.conf
+  mode: move
+- path: /etc/multipath
+- path: /etc/multipath
+`,
+			},
+			expected: []NodeFile{{Path: "/etc/multipath", Mode: NodeFileCopy}},
+			expectedErrors: []string{
+				`node file path "etc/multipath" must be an absolute and clean path`,
+				`node file path "/etc/../root" must be an absolute and clean path`,
+				"node file path /usr/local/bin must be below one of /etc, /var",
+				"node file path /var must be below one of /etc, /var",
+				"node file path /var/lib overlaps /var/lib/containers, which is managed by the upgrade",
+				"node file path /etc/kubernetes/manifests overlaps /etc/kubernetes, which is managed by the upgrade",
+				`node file /etc/chrony.conf has an unknown mode "move", must be copy or merge`,
+				"node file path /etc/multipath is listed more than once",
+			},
+		},
+		{
+			name:           "Unknown field",
+			data:           map[string]string{"files": "- path: /etc/multipath\n  merge: true\n"},
+			expectedErrors: []string{"failed to parse node files of default/node-files key files"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodeFiles, err := NodeFilesFromConfigMaps([]corev1.ConfigMap{{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-files", Namespace: "default"},
+				Data:       tc.data,
+			}})
+			assert.Equal(t, tc.expected, nodeFiles)
+			if len(tc.expectedErrors) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, expectedError := range tc.expectedErrors {
+				assert.ErrorContains(t, err, expectedError)
+			}
+		})
+	}
+}
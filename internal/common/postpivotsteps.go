@@ -0,0 +1,69 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The post pivot steps, by the name they are reported under in the IBU status and given a timeout under in the
+// auto-rollback configuration
+const (
+	PostPivotStepWaitForConfiguration = "wait_for_configuration"
+	PostPivotStepNetworkConfiguration = "network_configuration"
+	PostPivotStepTimeConfiguration    = "time_configuration"
+	PostPivotStepSSHKey               = "ssh_key"
+	PostPivotStepPullSecret           = "pull_secret"
+	PostPivotStepProxyAndTrustBundle  = "proxy_and_trust_bundle"
+	PostPivotStepMirrorRegistries     = "mirror_registries"
+	PostPivotStepServingCertificates  = "serving_certificates"
+	PostPivotStepMachineConfigs       = "machine_configs"
+	PostPivotStepPullRecertImage      = "pull_recert_image"
+	PostPivotStepRecert               = "recert"
+	PostPivotStepStartCluster         = "start_cluster"
+	PostPivotStepApplyManifests       = "apply_manifests"
+	PostPivotStepSetClusterID         = "set_cluster_id"
+	PostPivotStepRecordIdentity       = "record_identity"
+	PostPivotStepRecoverLvmDevices    = "recover_lvm_devices"
+)
+
+// PostPivotSteps are the post pivot steps, in the order they run
+var PostPivotSteps = []string{
+	PostPivotStepWaitForConfiguration,
+	PostPivotStepNetworkConfiguration,
+	PostPivotStepTimeConfiguration,
+	PostPivotStepSSHKey,
+	PostPivotStepPullSecret,
+	PostPivotStepProxyAndTrustBundle,
+	PostPivotStepMirrorRegistries,
+	PostPivotStepServingCertificates,
+	PostPivotStepMachineConfigs,
+	PostPivotStepPullRecertImage,
+	PostPivotStepRecert,
+	PostPivotStepStartCluster,
+	PostPivotStepApplyManifests,
+	PostPivotStepSetClusterID,
+	PostPivotStepRecordIdentity,
+	PostPivotStepRecoverLvmDevices,
+}
+
+// ValidatePostPivotStepTimeouts checks that the post pivot step timeouts, in seconds by step name, are positive and
+// given for known steps
+func ValidatePostPivotStepTimeouts(timeoutsSeconds map[string]int) error {
+	known := map[string]bool{}
+	for _, step := range PostPivotSteps {
+		known[step] = true
+	}
+
+	var errs []error
+	for step, seconds := range timeoutsSeconds {
+		switch {
+		case !known[step]:
+			errs = append(errs, fmt.Errorf("unknown post pivot step %q, known steps are %s", step,
+				strings.Join(PostPivotSteps, ", ")))
+		case seconds <= 0:
+			errs = append(errs, fmt.Errorf("timeout of post pivot step %s must be positive, got %d", step, seconds))
+		}
+	}
+	return errors.Join(errs...)
+}
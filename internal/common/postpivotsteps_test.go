@@ -0,0 +1,27 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePostPivotStepTimeouts(t *testing.T) {
+	assert.NoError(t, ValidatePostPivotStepTimeouts(nil))
+	assert.NoError(t, ValidatePostPivotStepTimeouts(map[string]int{
+		PostPivotStepStartCluster:      1200,
+		PostPivotStepPullRecertImage:   600,
+		PostPivotStepRecoverLvmDevices: 60,
+	}))
+
+	err := ValidatePostPivotStepTimeouts(map[string]int{
+		"start-cluster":         1200,
+		PostPivotStepRecert:     0,
+		PostPivotStepSSHKey:     -1,
+		PostPivotStepPullSecret: 30,
+	})
+	assert.ErrorContains(t, err, `unknown post pivot step "start-cluster", known steps are wait_for_configuration, `)
+	assert.ErrorContains(t, err, "timeout of post pivot step recert must be positive, got 0")
+	assert.ErrorContains(t, err, "timeout of post pivot step ssh_key must be positive, got -1")
+	assert.NotContains(t, err.Error(), "post pivot step "+PostPivotStepPullSecret)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/lvm"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/types"
@@ -43,7 +44,7 @@ func HealthChecks(c client.Reader, l logr.Logger) error {
 	defer common.FuncTimer(time.Now(), "healthCheck", l)
 
 	// using channel store go routine return val and WaitGroup to sync.
-	chanBufferSize := 5
+	chanBufferSize := 6
 	errChn := make(chan error, chanBufferSize)
 	var wg sync.WaitGroup
 
@@ -83,6 +84,13 @@ func HealthChecks(c client.Reader, l logr.Logger) error {
 		errChn <- nodesReady(c, l)
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer common.FuncTimer(time.Now(), "lvmClustersReady", l)
+		errChn <- lvmClustersReady(c, l)
+	}()
+
 	// wait
 	go func() {
 		l.Info("Wait until WaitGroup is done")
@@ -192,6 +200,12 @@ func isMachineConfigPoolReady(c client.Reader, l logr.Logger) wait.ConditionWith
 				l.Info(fmt.Sprintf("%s not ready yet", mcp.Name), "kind", mcp.Kind)
 				return false, nil
 			}
+			// The MachineConfigs applied post pivot, e.g. the time configuration, are rendered into a new config whose
+			// rollout may reboot the node, the upgrade is only complete once it is rolled out
+			if !isMachineConfigPoolUpdated(mcp) {
+				l.Info(fmt.Sprintf("%s is rolling out %s", mcp.Name, mcp.Spec.Configuration.Name), "kind", mcp.Kind)
+				return false, nil
+			}
 		}
 
 		l.Info("MachineConfigPool ready")
@@ -199,6 +213,20 @@ func isMachineConfigPoolReady(c client.Reader, l logr.Logger) wait.ConditionWith
 	}
 }
 
+// isMachineConfigPoolUpdated returns whether the pool rolled out the latest config rendered for it
+func isMachineConfigPoolUpdated(mcp mcv1.MachineConfigPool) bool {
+	if mcp.Status.ObservedGeneration != mcp.Generation ||
+		mcp.Status.Configuration.Name != mcp.Spec.Configuration.Name {
+		return false
+	}
+	for _, condition := range mcp.Status.Conditions {
+		if condition.Type == mcv1.MachineConfigPoolUpdating && condition.Status == corev1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
+
 func clusterOperatorsReady(c client.Reader, l logr.Logger) error {
 	l.Info("Waiting for all ClusterOperator (co) to be ready")
 	err := wait.PollUntilContextTimeout(context.Background(), pollInterval, pollTimeout, true, areClusterOperatorsReady(c, l))
@@ -308,6 +336,43 @@ func isNodeReady(c client.Reader, l logr.Logger) wait.ConditionWithContextFunc {
 	}
 }
 
+func lvmClustersReady(c client.Reader, l logr.Logger) error {
+	l.Info("Waiting for all LVMCluster to be ready")
+	var notReady error
+	err := wait.PollUntilContextTimeout(context.Background(), pollInterval, pollTimeout, true, areLVMClustersReady(c, l, &notReady))
+	if err != nil {
+		if notReady != nil {
+			// Report the state of each LVMCluster rather than the poll timeout alone
+			return fmt.Errorf("LVMClusters are not ready: %w", notReady)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func areLVMClustersReady(c client.Reader, l logr.Logger, notReady *error) wait.ConditionWithContextFunc {
+	return func(ctx context.Context) (bool, error) {
+		lvmClusters, err := lvm.ListLVMClusters(ctx, c)
+		if err != nil {
+			l.Error(err, "failed to get LVMCluster list")
+			*notReady = err
+			return false, nil
+		}
+
+		if *notReady = lvm.NotReady(lvmClusters); *notReady != nil {
+			l.Info(fmt.Sprintf("LVMClusters not ready yet: %s", *notReady))
+			return false, nil
+		}
+
+		for _, lvmCluster := range lvmClusters {
+			l.Info(fmt.Sprintf("%s is ready", lvmCluster.GetName()), "kind", lvmCluster.GetKind())
+		}
+		l.Info("All LVMClusters are ready")
+		return true, nil
+	}
+}
+
 func getNodeStatusCondition(conditions []corev1.NodeCondition, conditionType corev1.NodeConditionType) bool {
 	for _, condition := range conditions {
 		if condition.Type == conditionType {
@@ -7,6 +7,7 @@ import (
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -419,6 +420,92 @@ func Test_machineConfigPoolReady(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "new rendered config not rolled out yet",
+			objects: []runtime.Object{
+				&mcv1.MachineConfigPool{
+					ObjectMeta: metav1.ObjectMeta{Name: "master", Generation: 3},
+					Spec: mcv1.MachineConfigPoolSpec{
+						Configuration: mcv1.MachineConfigPoolStatusConfiguration{
+							ObjectReference: v1.ObjectReference{Name: "rendered-master-with-time"},
+						},
+					},
+					Status: mcv1.MachineConfigPoolStatus{
+						ObservedGeneration: 3,
+						MachineCount:       1,
+						ReadyMachineCount:  1,
+						Configuration: mcv1.MachineConfigPoolStatusConfiguration{
+							ObjectReference: v1.ObjectReference{Name: "rendered-master-seed"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "new pool generation not observed yet",
+			objects: []runtime.Object{
+				&mcv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: "master", Generation: 4}, Status: mcv1.MachineConfigPoolStatus{
+					ObservedGeneration: 3,
+					MachineCount:       1,
+					ReadyMachineCount:  1,
+				}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "pool updating",
+			objects: []runtime.Object{
+				&mcv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: "master"}, Status: mcv1.MachineConfigPoolStatus{
+					MachineCount:      1,
+					ReadyMachineCount: 1,
+					Conditions: []mcv1.MachineConfigPoolCondition{
+						{Type: mcv1.MachineConfigPoolUpdating, Status: v1.ConditionTrue},
+					},
+				}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "new rendered config rolled out",
+			objects: []runtime.Object{
+				&mcv1.MachineConfigPool{
+					ObjectMeta: metav1.ObjectMeta{Name: "master", Generation: 3},
+					Spec: mcv1.MachineConfigPoolSpec{
+						Configuration: mcv1.MachineConfigPoolStatusConfiguration{
+							ObjectReference: v1.ObjectReference{Name: "rendered-master-with-time"},
+						},
+					},
+					Status: mcv1.MachineConfigPoolStatus{
+						ObservedGeneration: 3,
+						MachineCount:       1,
+						ReadyMachineCount:  1,
+						Configuration: mcv1.MachineConfigPoolStatusConfiguration{
+							ObjectReference: v1.ObjectReference{Name: "rendered-master-with-time"},
+						},
+						Conditions: []mcv1.MachineConfigPoolCondition{
+							{Type: mcv1.MachineConfigPoolUpdating, Status: v1.ConditionFalse},
+							{Type: mcv1.MachineConfigPoolUpdated, Status: v1.ConditionTrue},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "worker pool not ready",
+			objects: []runtime.Object{
+				&mcv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: "master"}, Status: mcv1.MachineConfigPoolStatus{
+					MachineCount:      1,
+					ReadyMachineCount: 1,
+				}},
+				&mcv1.MachineConfigPool{ObjectMeta: metav1.ObjectMeta{Name: "worker"}, Status: mcv1.MachineConfigPoolStatus{
+					MachineCount:      1,
+					ReadyMachineCount: 0,
+				}},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -430,6 +517,63 @@ func Test_machineConfigPoolReady(t *testing.T) {
 	}
 }
 
+func Test_lvmClustersReady(t *testing.T) {
+	oldPoll := pollTimeout
+	defer func() {
+		pollTimeout = oldPoll
+	}()
+	pollTimeout = 1 * time.Microsecond
+
+	lvmCluster := func(state string) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "lvm.topolvm.io/v1alpha1",
+			"kind":       "LVMCluster",
+			"metadata":   map[string]any{"name": "lvmcluster", "namespace": "openshift-storage"},
+			"status":     map[string]any{"state": state},
+		}}
+	}
+
+	type args struct {
+		c client.Reader
+		l logr.Logger
+	}
+	tests := []struct {
+		name       string
+		args       args
+		objects    []runtime.Object
+		wantErr    bool
+		wantErrMsg string
+	}{
+		{
+			name:    "happy path",
+			objects: []runtime.Object{lvmCluster("Ready")},
+			wantErr: false,
+		},
+		{
+			name:    "no LVMCluster",
+			wantErr: false,
+		},
+		{
+			name:       "LVMCluster degraded",
+			objects:    []runtime.Object{lvmCluster("Degraded")},
+			wantErr:    true,
+			wantErrMsg: `LVMClusters are not ready: LVMCluster openshift-storage/lvmcluster is not ready, its state is "Degraded"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.objects...).Build()
+			err := lvmClustersReady(tt.args.c, tt.args.l)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("lvmClustersReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErrMsg != "" && (err == nil || err.Error() != tt.wantErrMsg) {
+				t.Errorf("lvmClustersReady() error = %v, wantErrMsg %v", err, tt.wantErrMsg)
+			}
+		})
+	}
+}
+
 func Test_clusterVersionReady(t *testing.T) {
 	oldPoll := pollTimeout
 	defer func() {
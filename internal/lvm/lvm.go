@@ -0,0 +1,259 @@
+package lvm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+// +kubebuilder:rbac:groups=lvm.topolvm.io,resources=lvmclusters;lvmvolumegroups,verbs=get;list;watch
+
+const (
+	// RecordFileName is the name of the record of the LVM configuration carried over to the new stateroot
+	RecordFileName = "lvm-record.json"
+
+	lvmClusterKind     = "LVMCluster"
+	lvmVolumeGroupKind = "LVMVolumeGroup"
+	// lvmClusterReadyState is the state of an LVMCluster whose volume groups are all ready
+	lvmClusterReadyState = "Ready"
+)
+
+var lvmsGroupVersion = schema.GroupVersion{Group: "lvm.topolvm.io", Version: "v1alpha1"}
+
+// Record is the LVM configuration of the node and of the cluster, recorded before the pivot and verified after it
+type Record struct {
+	Layout          Layout      `json:"layout"`
+	LVMClusters     []ObjectRef `json:"lvmClusters,omitempty"`
+	LVMVolumeGroups []ObjectRef `json:"lvmVolumeGroups,omitempty"`
+}
+
+// Layout is the LVM layout of the node
+type Layout struct {
+	VolumeGroups   []VolumeGroup   `json:"volumeGroups,omitempty"`
+	LogicalVolumes []LogicalVolume `json:"logicalVolumes,omitempty"`
+}
+
+// VolumeGroup is an LVM volume group, as reported by vgs
+type VolumeGroup struct {
+	Name string `json:"vg_name"`
+	UUID string `json:"vg_uuid"`
+	Size string `json:"vg_size"`
+}
+
+// LogicalVolume is an LVM logical volume, as reported by lvs
+type LogicalVolume struct {
+	VolumeGroup string `json:"vg_name"`
+	Name        string `json:"lv_name"`
+	UUID        string `json:"lv_uuid"`
+	Size        string `json:"lv_size"`
+	Active      string `json:"lv_active"`
+}
+
+// ObjectRef is a reference to an LVMS object
+type ObjectRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+func (r ObjectRef) String() string {
+	return r.Namespace + "/" + r.Name
+}
+
+// report is the JSON report of the LVM tools
+type report struct {
+	Report []struct {
+		VG []VolumeGroup   `json:"vg"`
+		LV []LogicalVolume `json:"lv"`
+	} `json:"report"`
+}
+
+// ReadLayout reads the LVM layout of the node from the LVM tools run in the host namespace
+func ReadLayout(hostOps ops.Ops) (*Layout, error) {
+	layout := &Layout{}
+	output, err := hostOps.RunInHostNamespace("vgs", "--reportformat", "json", "--units", "b", "--nosuffix",
+		"-o", "vg_name,vg_uuid,vg_size")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volume groups: %w", err)
+	}
+	vgs := report{}
+	if err := json.Unmarshal([]byte(output), &vgs); err != nil {
+		return nil, fmt.Errorf("failed to parse volume groups report: %w", err)
+	}
+	for _, r := range vgs.Report {
+		layout.VolumeGroups = append(layout.VolumeGroups, r.VG...)
+	}
+
+	output, err = hostOps.RunInHostNamespace("lvs", "--reportformat", "json", "--units", "b", "--nosuffix",
+		"-o", "vg_name,lv_name,lv_uuid,lv_size,lv_active")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list logical volumes: %w", err)
+	}
+	lvs := report{}
+	if err := json.Unmarshal([]byte(output), &lvs); err != nil {
+		return nil, fmt.Errorf("failed to parse logical volumes report: %w", err)
+	}
+	for _, r := range lvs.Report {
+		layout.LogicalVolumes = append(layout.LogicalVolumes, r.LV...)
+	}
+	return layout, nil
+}
+
+// NewRecord records the LVM layout of the node along with the LVMS objects of the cluster
+func NewRecord(ctx context.Context, c client.Reader, hostOps ops.Ops) (*Record, error) {
+	layout, err := ReadLayout(hostOps)
+	if err != nil {
+		return nil, err
+	}
+	lvmClusters, err := listObjects(ctx, c, lvmClusterKind)
+	if err != nil {
+		return nil, err
+	}
+	lvmVolumeGroups, err := listObjects(ctx, c, lvmVolumeGroupKind)
+	if err != nil {
+		return nil, err
+	}
+	return &Record{
+		Layout:          *layout,
+		LVMClusters:     refs(lvmClusters),
+		LVMVolumeGroups: refs(lvmVolumeGroups),
+	}, nil
+}
+
+// VerifyLayout checks that every volume group and logical volume of the recorded layout is in the current one, with
+// the same name and size, and that the logical volumes that were active are active again
+func VerifyLayout(recorded, current *Layout) error {
+	var errs []error
+	vgs := map[string]VolumeGroup{}
+	for _, vg := range current.VolumeGroups {
+		vgs[vg.UUID] = vg
+	}
+	for _, vg := range recorded.VolumeGroups {
+		found, ok := vgs[vg.UUID]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("volume group %s (%s) is missing", vg.Name, vg.UUID))
+		case found.Name != vg.Name:
+			errs = append(errs, fmt.Errorf("volume group %s (%s) is now named %s", vg.Name, vg.UUID, found.Name))
+		case found.Size != vg.Size:
+			errs = append(errs, fmt.Errorf("volume group %s has a size of %s bytes instead of %s", vg.Name, found.Size, vg.Size))
+		}
+	}
+
+	lvs := map[string]LogicalVolume{}
+	for _, lv := range current.LogicalVolumes {
+		lvs[lv.UUID] = lv
+	}
+	for _, lv := range recorded.LogicalVolumes {
+		name := lv.VolumeGroup + "/" + lv.Name
+		found, ok := lvs[lv.UUID]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("logical volume %s (%s) is missing", name, lv.UUID))
+		case found.VolumeGroup != lv.VolumeGroup || found.Name != lv.Name:
+			errs = append(errs, fmt.Errorf("logical volume %s (%s) is now named %s/%s", name, lv.UUID,
+				found.VolumeGroup, found.Name))
+		case found.Size != lv.Size:
+			errs = append(errs, fmt.Errorf("logical volume %s has a size of %s bytes instead of %s", name, found.Size, lv.Size))
+		case lv.Active == "active" && found.Active != "active":
+			errs = append(errs, fmt.Errorf("logical volume %s is not active", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// VerifyObjects checks that the recorded LVMS objects exist in the cluster and that the LVMClusters are ready
+func VerifyObjects(ctx context.Context, c client.Reader, record *Record) error {
+	if len(record.LVMClusters) == 0 && len(record.LVMVolumeGroups) == 0 {
+		return nil
+	}
+	lvmClusters, err := listObjects(ctx, c, lvmClusterKind)
+	if err != nil {
+		return err
+	}
+	lvmVolumeGroups, err := listObjects(ctx, c, lvmVolumeGroupKind)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	existing := map[ObjectRef]bool{}
+	for _, ref := range refs(lvmVolumeGroups) {
+		existing[ref] = true
+	}
+	for _, ref := range record.LVMVolumeGroups {
+		if !existing[ref] {
+			errs = append(errs, fmt.Errorf("%s %s is missing", lvmVolumeGroupKind, ref))
+		}
+	}
+
+	states := map[ObjectRef]string{}
+	for _, lvmCluster := range lvmClusters {
+		states[ObjectRef{Name: lvmCluster.GetName(), Namespace: lvmCluster.GetNamespace()}] = State(lvmCluster)
+	}
+	for _, ref := range record.LVMClusters {
+		state, ok := states[ref]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("%s %s is missing", lvmClusterKind, ref))
+		case state != lvmClusterReadyState:
+			errs = append(errs, fmt.Errorf("%s %s is not ready, its state is %q", lvmClusterKind, ref, state))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListLVMClusters returns the LVMClusters of the cluster, none if LVMS is not installed
+func ListLVMClusters(ctx context.Context, c client.Reader) ([]unstructured.Unstructured, error) {
+	return listObjects(ctx, c, lvmClusterKind)
+}
+
+// State returns the state of an LVMCluster
+func State(lvmCluster unstructured.Unstructured) string {
+	state, _, _ := unstructured.NestedString(lvmCluster.Object, "status", "state")
+	return state
+}
+
+// IsReady returns whether an LVMCluster is ready
+func IsReady(lvmCluster unstructured.Unstructured) bool {
+	return State(lvmCluster) == lvmClusterReadyState
+}
+
+// NotReady returns an error naming each LVMCluster that is not ready along with its state, nil if all are ready
+func NotReady(lvmClusters []unstructured.Unstructured) error {
+	var errs []error
+	for _, lvmCluster := range lvmClusters {
+		if !IsReady(lvmCluster) {
+			errs = append(errs, fmt.Errorf("%s %s is not ready, its state is %q", lvmClusterKind,
+				ObjectRef{Name: lvmCluster.GetName(), Namespace: lvmCluster.GetNamespace()}, State(lvmCluster)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func listObjects(ctx context.Context, c client.Reader, kind string) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(lvmsGroupVersion.WithKind(kind + "List"))
+	if err := c.List(ctx, list); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list %s: %w", kind, err)
+	}
+	return list.Items, nil
+}
+
+func refs(objects []unstructured.Unstructured) []ObjectRef {
+	var refs []ObjectRef
+	for _, object := range objects {
+		refs = append(refs, ObjectRef{Name: object.GetName(), Namespace: object.GetNamespace()})
+	}
+	return refs
+}
@@ -0,0 +1,142 @@
+package lvm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+const (
+	vgsReport = `{"report":[{"vg":[{"vg_name":"vg1","vg_uuid":"vg-uuid","vg_size":"107369988096"}]}]}`
+	lvsReport = `{"report":[{"lv":[
+{"vg_name":"vg1","lv_name":"thin-pool-1","lv_uuid":"pool-uuid","lv_size":"96632569856","lv_active":"active"},
+{"vg_name":"vg1","lv_name":"pvc-1","lv_uuid":"pvc-uuid","lv_size":"1073741824","lv_active":"active"}]}]}`
+)
+
+func lvmsObject(kind, name, state string) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{}
+	object.SetGroupVersionKind(lvmsGroupVersion.WithKind(kind))
+	object.SetName(name)
+	object.SetNamespace("openshift-storage")
+	if state != "" {
+		_ = unstructured.SetNestedField(object.Object, state, "status", "state")
+	}
+	return object
+}
+
+func fakeClient(objects ...client.Object) client.Client {
+	return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).Build()
+}
+
+func TestNewRecord(t *testing.T) {
+	mockOps := ops.NewMockOps(gomock.NewController(t))
+	mockOps.EXPECT().RunInHostNamespace("vgs", gomock.Any()).Return(vgsReport, nil)
+	mockOps.EXPECT().RunInHostNamespace("lvs", gomock.Any()).Return(lvsReport, nil)
+
+	record, err := NewRecord(context.Background(), fakeClient(
+		lvmsObject(lvmClusterKind, "lvmcluster", lvmClusterReadyState),
+		lvmsObject(lvmVolumeGroupKind, "vg1", "")), mockOps)
+	assert.NoError(t, err)
+	assert.Equal(t, &Record{
+		Layout: Layout{
+			VolumeGroups: []VolumeGroup{{Name: "vg1", UUID: "vg-uuid", Size: "107369988096"}},
+			LogicalVolumes: []LogicalVolume{
+				{VolumeGroup: "vg1", Name: "thin-pool-1", UUID: "pool-uuid", Size: "96632569856", Active: "active"},
+				{VolumeGroup: "vg1", Name: "pvc-1", UUID: "pvc-uuid", Size: "1073741824", Active: "active"},
+			},
+		},
+		LVMClusters:     []ObjectRef{{Name: "lvmcluster", Namespace: "openshift-storage"}},
+		LVMVolumeGroups: []ObjectRef{{Name: "vg1", Namespace: "openshift-storage"}},
+	}, record)
+}
+
+func TestVerifyLayout(t *testing.T) {
+	recorded := &Layout{
+		VolumeGroups: []VolumeGroup{{Name: "vg1", UUID: "vg-uuid", Size: "8192"}, {Name: "vg2", UUID: "vg2-uuid"}},
+		LogicalVolumes: []LogicalVolume{
+			{VolumeGroup: "vg1", Name: "lv1", UUID: "lv1-uuid", Size: "1024", Active: "active"},
+			{VolumeGroup: "vg1", Name: "lv2", UUID: "lv2-uuid", Size: "1024", Active: "active"},
+			{VolumeGroup: "vg1", Name: "lv3", UUID: "lv3-uuid", Size: "1024", Active: "active"},
+			{VolumeGroup: "vg1", Name: "lv4", UUID: "lv4-uuid", Size: "1024"},
+			{VolumeGroup: "vg1", Name: "lv5", UUID: "lv5-uuid", Size: "1024", Active: "active"},
+		},
+	}
+
+	assert.NoError(t, VerifyLayout(recorded, recorded))
+	assert.NoError(t, VerifyLayout(&Layout{}, &Layout{}))
+
+	err := VerifyLayout(recorded, &Layout{
+		VolumeGroups: []VolumeGroup{{Name: "vg1", UUID: "vg-uuid", Size: "4096"}, {Name: "vg3", UUID: "vg2-uuid"}},
+		LogicalVolumes: []LogicalVolume{
+			{VolumeGroup: "vg1", Name: "lv2", UUID: "lv2-uuid", Size: "2048", Active: "active"},
+			{VolumeGroup: "vg1", Name: "lv3", UUID: "lv3-uuid", Size: "1024"},
+			{VolumeGroup: "vg1", Name: "lv4", UUID: "lv4-uuid", Size: "1024"},
+			{VolumeGroup: "vg1", Name: "lv6", UUID: "lv5-uuid", Size: "1024", Active: "active"},
+		},
+	})
+	for _, expectedError := range []string{
+		"volume group vg1 has a size of 4096 bytes instead of 8192",
+		"volume group vg2 (vg2-uuid) is now named vg3",
+		"logical volume vg1/lv1 (lv1-uuid) is missing",
+		"logical volume vg1/lv2 has a size of 2048 bytes instead of 1024",
+		"logical volume vg1/lv3 is not active",
+		"logical volume vg1/lv5 (lv5-uuid) is now named vg1/lv6",
+	} {
+		assert.ErrorContains(t, err, expectedError)
+	}
+	assert.NotContains(t, err.Error(), "lv4")
+}
+
+func TestVerifyObjects(t *testing.T) {
+	record := &Record{
+		LVMClusters:     []ObjectRef{{Name: "lvmcluster", Namespace: "openshift-storage"}},
+		LVMVolumeGroups: []ObjectRef{{Name: "vg1", Namespace: "openshift-storage"}},
+	}
+
+	testcases := []struct {
+		name           string
+		objects        []client.Object
+		expectedErrors []string
+	}{
+		{
+			name: "Objects restored and ready",
+			objects: []client.Object{
+				lvmsObject(lvmClusterKind, "lvmcluster", lvmClusterReadyState),
+				lvmsObject(lvmVolumeGroupKind, "vg1", ""),
+			},
+		},
+		{
+			name:    "LVMCluster not ready",
+			objects: []client.Object{lvmsObject(lvmClusterKind, "lvmcluster", "Progressing")},
+			expectedErrors: []string{
+				`LVMCluster openshift-storage/lvmcluster is not ready, its state is "Progressing"`,
+				"LVMVolumeGroup openshift-storage/vg1 is missing",
+			},
+		},
+		{
+			name:           "Objects missing",
+			expectedErrors: []string{"LVMCluster openshift-storage/lvmcluster is missing"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyObjects(context.Background(), fakeClient(tc.objects...), record)
+			if len(tc.expectedErrors) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, expectedError := range tc.expectedErrors {
+				assert.ErrorContains(t, err, expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package prep
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// appendKernelArguments appends the kernel arguments to the ostree admin deploy arguments built by
+// buildKernelArgumentsFromMCOFile, skipping the ones already there
+func appendKernelArguments(deployArgs, kernelArguments []string) ([]string, error) {
+	existing := map[string]bool{}
+	for _, arg := range deployArgs {
+		existing[arg] = true
+	}
+	for _, karg := range kernelArguments {
+		// if we don't marshal the karg, `"` won't appear in the kernel arguments after reboot
+		val, err := json.Marshal(karg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal karg %s: %w", karg, err)
+		}
+		if existing[string(val)] {
+			continue
+		}
+		existing[string(val)] = true
+		deployArgs = append(deployArgs, "--karg-append", string(val))
+	}
+	return deployArgs, nil
+}
@@ -0,0 +1,19 @@
+package prep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendKernelArguments(t *testing.T) {
+	deployArgs, err := appendKernelArguments(
+		[]string{"--karg-append", `"ip=dhcp"`, "--karg-append", `"hugepages=16"`},
+		[]string{"hugepages=16", `foo="bar baz"`})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"--karg-append", `"ip=dhcp"`,
+		"--karg-append", `"hugepages=16"`,
+		"--karg-append", `"foo=\"bar baz\""`,
+	}, deployArgs)
+}
@@ -112,8 +112,11 @@ func getDeploymentFromDeploymentID(deploymentID string) (string, error) {
 	return splitted[len(splitted)-1], nil
 }
 
+// SetupStateroot deploys the seed image into a new stateroot, with the kernel arguments of the seed followed by the
+// additional ones
 func SetupStateroot(log logr.Logger, ops ops.Ops, ostreeClient ostreeclient.IClient,
-	rpmOstreeClient rpmostreeclient.IClient, seedImage, expectedVersion, imageListFile string, ibi bool) error {
+	rpmOstreeClient rpmostreeclient.IClient, seedImage, expectedVersion, imageListFile string, ibi bool,
+	additionalKernelArguments []string) error {
 	log.Info("Start setupstateroot")
 
 	defer ops.UnmountAndRemoveImage(seedImage)
@@ -196,6 +199,9 @@ func SetupStateroot(log logr.Logger, ops ops.Ops, ostreeClient ostreeclient.ICli
 	if err != nil {
 		return fmt.Errorf("failed to build kargs: %w", err)
 	}
+	if kargs, err = appendKernelArguments(kargs, additionalKernelArguments); err != nil {
+		return fmt.Errorf("failed to add kargs: %w", err)
+	}
 
 	if err = ostreeClient.Deploy(osname, seedBootedRef, kargs); err != nil {
 		return fmt.Errorf("failed ostree admin deploy: %w", err)
@@ -235,9 +241,30 @@ func SetupStateroot(log logr.Logger, ops ops.Ops, ostreeClient ostreeclient.ICli
 		return fmt.Errorf("failed to copy image list file: %w", err)
 	}
 
+	if err := copySeedSBOM(log, mountpoint, common.GetStaterootPath(osname)); err != nil {
+		return fmt.Errorf("failed to copy seed SBOM: %w", err)
+	}
+
 	return nil
 }
 
+// copySeedSBOM copies the seed SBOM, if the seed image provides one, into the LCA config dir of the new stateroot
+// so it remains available for later audit
+func copySeedSBOM(log logr.Logger, mountpoint, staterootPath string) error {
+	sbomFile := filepath.Join(mountpoint, common.SeedSBOMFileName)
+	if _, err := os.Stat(common.PathOutsideChroot(sbomFile)); os.IsNotExist(err) {
+		log.Info("Seed image does not provide an SBOM, skipping copy")
+		return nil
+	}
+
+	lcaConfigDir := filepath.Join(staterootPath, common.LCAConfigDir)
+	if err := os.MkdirAll(common.PathOutsideChroot(lcaConfigDir), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", lcaConfigDir, err)
+	}
+
+	return common.CopyOutsideChroot(sbomFile, filepath.Join(lcaConfigDir, common.SeedSBOMFileName))
+}
+
 func ReadPrecachingList(imageListFile, clusterRegistry, seedRegistry string, overrideSeedRegistry bool) (imageList []string, err error) {
 	var content []byte
 	content, err = os.ReadFile(common.PathOutsideChroot(imageListFile))
@@ -0,0 +1,111 @@
+package prep
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+
+	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
+	"github.com/openshift-kni/lifecycle-agent/internal/recert"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// recertDryRunDir is the dry-run workspace, relative to the stateroot, so that the copy of the etcd database is on the
+// same filesystem as the original one
+const recertDryRunDir = "/var/tmp/recert-dry-run"
+
+// RecertDryRun runs recert in dry-run mode with the final seed reconfiguration against a copy of the etcd database of
+// the new stateroot, so that a recert configuration that would fail post-pivot, e.g. because of bad CN/SAN rules or
+// missing crypto, is caught before rebooting into it. The summary of the planned changes is kept in the LCA config dir
+// of the stateroot and its digest is returned, nil if the summary can't be digested as that only affects the reporting.
+func RecertDryRun(log logr.Logger, ops ops.Ops, ostreeClient ostreeclient.IClient,
+	seedReconfiguration *seedreconfig.SeedReconfiguration, osname, authFile string) (*lcav1alpha1.RecertSummary, error) {
+	log.Info("Start recert dry-run")
+
+	staterootPath := common.GetStaterootPath(osname)
+	deploymentDir, err := ostreeClient.GetDeploymentDir(osname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment dir: %w", err)
+	}
+
+	seedClusterInfo, err := seedclusterinfo.ReadSeedClusterInfoFromFile(
+		common.PathOutsideChroot(filepath.Join(staterootPath, common.SeedDataDir, common.SeedClusterInfoFileName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed info: %w", err)
+	}
+
+	etcdImage, err := utils.ReadImageFromStaticPodDefinition(
+		common.PathOutsideChroot(filepath.Join(deploymentDir, common.EtcdStaticPodFile)), common.EtcdStaticPodContainer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get etcd image of the stateroot: %w", err)
+	}
+
+	workspace := filepath.Join(staterootPath, recertDryRunDir)
+	workspaceOutsideChroot := common.PathOutsideChroot(workspace)
+	if err := os.RemoveAll(workspaceOutsideChroot); err != nil {
+		return nil, fmt.Errorf("failed to cleanup %s: %w", workspace, err)
+	}
+	if err := os.MkdirAll(workspaceOutsideChroot, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", workspace, err)
+	}
+	defer func() {
+		if err := os.RemoveAll(workspaceOutsideChroot); err != nil {
+			log.Error(err, "failed to cleanup recert dry-run workspace")
+		}
+	}()
+
+	// The recert config refers to the crypto files by their path in this container, the workspace is mounted at the
+	// same path in the recert container
+	cryptoDir := filepath.Join(workspaceOutsideChroot, common.KubeconfigCryptoDir)
+	if err := utils.SeedReconfigurationKubeconfigRetentionToCryptoDir(cryptoDir, &seedReconfiguration.KubeconfigCryptoRetention); err != nil {
+		return nil, fmt.Errorf("failed to populate crypto dir from seed reconfiguration: %w", err)
+	}
+	servingCertificates, err := utils.SeedReconfigurationServingCertificatesToCryptoDir(cryptoDir, seedReconfiguration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to populate crypto dir with serving certificates: %w", err)
+	}
+	if err := recert.CreateRecertConfigFileForDryRun(seedReconfiguration, seedClusterInfo, servingCertificates,
+		cryptoDir, workspaceOutsideChroot); err != nil {
+		return nil, fmt.Errorf("failed to create recert config file: %w", err)
+	}
+
+	etcdDataDir := filepath.Join(workspace, "etcd")
+	if _, err := ops.RunInHostNamespace("cp", "-a", "--reflink=auto",
+		filepath.Join(staterootPath, "/var/lib/etcd"), etcdDataDir); err != nil {
+		return nil, fmt.Errorf("failed to copy etcd database of the stateroot: %w", err)
+	}
+
+	if err := ops.RecertDryRun(seedClusterInfo.RecertImagePullSpec, etcdImage, authFile,
+		filepath.Join(workspaceOutsideChroot, recert.RecertConfigFile), etcdDataDir, staterootPath, deploymentDir,
+		"-v", fmt.Sprintf("%s:%s", workspace, workspaceOutsideChroot)); err != nil {
+		return nil, fmt.Errorf("recert dry-run failed: %w", err)
+	}
+
+	summary, err := os.ReadFile(filepath.Join(workspaceOutsideChroot, recert.DryRunSummaryFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recert dry-run summary: %w", err)
+	}
+
+	lcaConfigDir := common.PathOutsideChroot(filepath.Join(staterootPath, common.LCAConfigDir))
+	if err := os.MkdirAll(lcaConfigDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", lcaConfigDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(lcaConfigDir, recert.DryRunSummaryFileName), summary, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to keep recert dry-run summary: %w", err)
+	}
+
+	log.Info("Recert dry-run completed successfully")
+	digest, err := recert.DigestSummary(summary, seedClusterInfo)
+	if err != nil {
+		log.Error(err, "failed to digest recert dry-run summary")
+		return nil, nil
+	}
+	return digest, nil
+}
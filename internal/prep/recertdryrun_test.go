@@ -0,0 +1,121 @@
+package prep
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
+	"github.com/openshift-kni/lifecycle-agent/internal/recert"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+const testDryRunSummary = `cluster_crypto:
+  cert_key_pairs:
+  - distributed_private_key:
+      locations:
+      - k8s:Secret/openshift-kube-apiserver-operator/localhost-serving-signer:/data/tls.key
+    distributed_cert:
+      certificate_subject: CN=localhost-serving-signer,OU=openshift
+    signees: []
+  distributed_jwts: []
+config:
+  cluster_customizations:
+    hostname: sno
+run_times:
+  scan_run_time: 1s 212ms
+`
+
+func TestRecertDryRun(t *testing.T) {
+	defer func(prefix string) { common.OstreeDeployPathPrefix = prefix }(common.OstreeDeployPathPrefix)
+
+	testcases := []struct {
+		name          string
+		recertError   error
+		expectedError bool
+	}{
+		{
+			name: "Dry-run succeeded",
+		},
+		{
+			name:          "Dry-run failed",
+			recertError:   fmt.Errorf("bad cn/san rule"),
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			mockOps := ops.NewMockOps(mockController)
+			mockOstreeClient := ostreeclient.NewMockIClient(mockController)
+			defer mockController.Finish()
+
+			common.OstreeDeployPathPrefix = t.TempDir()
+			osname := "rhcos_4.16.0"
+			staterootPath := common.GetStaterootPath(osname)
+			deploymentDir := filepath.Join(staterootPath, "deploy", "abcdef.0")
+
+			assert.NoError(t, os.MkdirAll(filepath.Join(staterootPath, common.SeedDataDir), 0o700))
+			assert.NoError(t, utils.MarshalToFile(&seedclusterinfo.SeedClusterInfo{
+				SNOHostname:         "seed",
+				ClusterName:         "seed",
+				BaseDomain:          "example.com",
+				NodeIP:              "192.168.127.10",
+				RecertImagePullSpec: "quay.io/edge-infrastructure/recert:latest",
+			}, filepath.Join(staterootPath, common.SeedDataDir, common.SeedClusterInfoFileName)))
+			assert.NoError(t, os.MkdirAll(filepath.Join(deploymentDir, filepath.Dir(common.EtcdStaticPodFile)), 0o700))
+			assert.NoError(t, os.WriteFile(filepath.Join(deploymentDir, common.EtcdStaticPodFile),
+				[]byte(`{"spec":{"containers":[{"name":"etcd","image":"quay.io/openshift/etcd:latest"}]}}`), 0o600))
+
+			workspace := filepath.Join(staterootPath, recertDryRunDir)
+			mockOstreeClient.EXPECT().GetDeploymentDir(osname).Return(deploymentDir, nil)
+			mockOps.EXPECT().RunInHostNamespace("cp", "-a", "--reflink=auto",
+				filepath.Join(staterootPath, "/var/lib/etcd"), filepath.Join(workspace, "etcd")).Return("", nil)
+			mockOps.EXPECT().RecertDryRun("quay.io/edge-infrastructure/recert:latest", "quay.io/openshift/etcd:latest",
+				"/auth.json", filepath.Join(workspace, recert.RecertConfigFile), filepath.Join(workspace, "etcd"),
+				staterootPath, deploymentDir, "-v", fmt.Sprintf("%s:%s", workspace, workspace)).
+				DoAndReturn(func(_, _, _, configFile, _, _, _ string, _ ...string) error {
+					config := &recert.RecertConfig{}
+					assert.NoError(t, utils.ReadYamlOrJSONFile(configFile, config))
+					assert.True(t, config.DryRun)
+					assert.Equal(t, recert.DryRunEtcdEndpoint, config.EtcdEndpoint)
+					assert.Equal(t, recert.IPs{"192.168.127.20"}, config.IP)
+					assert.NoError(t, os.WriteFile(config.SummaryFile, []byte(testDryRunSummary), 0o600))
+					return tc.recertError
+				})
+
+			summary, err := RecertDryRun(logr.Discard(), mockOps, mockOstreeClient, &seedreconfig.SeedReconfiguration{
+				ClusterName: "sno",
+				BaseDomain:  "example.com",
+				Hostname:    "sno",
+				NodeIP:      "192.168.127.20",
+			}, osname, "/auth.json")
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			_, statErr := os.Stat(workspace)
+			assert.True(t, os.IsNotExist(statErr), "workspace is cleaned up")
+			if tc.expectedError {
+				return
+			}
+			assert.Equal(t, &lcav1alpha1.RecertSummary{
+				Certificates: 1,
+				Keys:         1,
+				Renames:      []string{"hostname: seed -> sno"},
+				Timings:      map[string]string{"scan_run_time": "1s 212ms"},
+			}, summary)
+			kept, err := os.ReadFile(filepath.Join(staterootPath, common.LCAConfigDir, recert.DryRunSummaryFileName))
+			assert.NoError(t, err)
+			assert.Equal(t, testDryRunSummary, string(kept))
+		})
+	}
+}
@@ -31,6 +31,8 @@ type IBUAutoRollbackConfig struct {
 	InitMonitorEnabled bool            `json:"monitor_enabled,omitempty"`
 	InitMonitorTimeout int             `json:"monitor_timeout,omitempty"`
 	EnabledComponents  map[string]bool `json:"enabled_components,omitempty"`
+	// PostPivotStepTimeouts are the timeouts of the post-pivot steps, in seconds, by step name
+	PostPivotStepTimeouts map[string]int `json:"post_pivot_step_timeouts,omitempty"`
 }
 
 // RebootIntf is an interface for LCA reboot and rollback commands.
@@ -85,9 +87,10 @@ func (c *RebootClient) WriteIBUAutoRollbackConfigFile(ibu *lcav1alpha1.ImageBase
 	}
 
 	rollbackCfg := IBUAutoRollbackConfig{
-		InitMonitorEnabled: !ibu.Spec.AutoRollbackOnFailure.DisabledInitMonitor,
-		InitMonitorTimeout: monitorTimeout,
-		EnabledComponents:  make(map[string]bool),
+		InitMonitorEnabled:    !ibu.Spec.AutoRollbackOnFailure.DisabledInitMonitor,
+		InitMonitorTimeout:    monitorTimeout,
+		EnabledComponents:     make(map[string]bool),
+		PostPivotStepTimeouts: ibu.Spec.AutoRollbackOnFailure.PostPivotStepTimeoutsSeconds,
 	}
 
 	rollbackCfg.EnabledComponents[InstallationConfigurationComponent] = !ibu.Spec.AutoRollbackOnFailure.DisabledForPostRebootConfig
@@ -192,6 +195,14 @@ func (c *RebootClient) InitiateRollback(msg string) error {
 
 	utils.SetUpgradeStatusFailed(savedIbu, msg)
 
+	// Carry the post-pivot progress and recert summary of the current stateroot over, so they're visible after the rollback
+	if err := lcautils.MergePostPivotProgress(savedIbu, common.PathOutsideChroot(common.PostPivotProgressFile)); err != nil {
+		c.log.Error(err, "unable to merge post pivot progress into saved IBU CR")
+	}
+	if err := lcautils.MergeRecertSummary(savedIbu, common.PathOutsideChroot(common.RecertSummaryDigestFile)); err != nil {
+		c.log.Error(err, "unable to merge recert summary into saved IBU CR")
+	}
+
 	if err := lcautils.MarshalToFile(savedIbu, filePath); err != nil {
 		return fmt.Errorf("unable to save updated ibu CR to %s: %w", filePath, err)
 	}
@@ -1,11 +1,14 @@
 package recert
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/samber/lo"
+
 	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
@@ -15,6 +18,11 @@ import (
 const (
 	RecertConfigFile = "recert_config.json"
 	SummaryFile      = "/var/tmp/recert-summary.yaml"
+
+	// DryRunEtcdEndpoint is where the unauthenticated etcd server of a dry-run listens, so that it doesn't conflict
+	// with the etcd of the running cluster
+	DryRunEtcdEndpoint    = "localhost:2479"
+	DryRunSummaryFileName = "recert-dry-run-summary.yaml"
 )
 
 var staticDirs = []string{"/kubelet", "/kubernetes", "/machine-config-daemon"}
@@ -26,7 +34,8 @@ type RecertConfig struct {
 	EtcdEndpoint     string `json:"etcd_endpoint,omitempty"`
 	ClusterRename    string `json:"cluster_rename,omitempty"`
 	Hostname         string `json:"hostname,omitempty"`
-	IP               string `json:"ip,omitempty"`
+	// IP holds the desired node IPs, in the order of the seed ones
+	IP IPs `json:"ip,omitempty"`
 	// We intentionally don't omitEmpty this field because an empty string here
 	// means "delete the kubeadmin password secret" while a complete omission
 	// of the field means "don't touch the secret". We never want the latter,
@@ -41,10 +50,61 @@ type RecertConfig struct {
 	UseCertRules          []string `json:"use_cert_rules,omitempty"`
 }
 
+// IPs are the node IPs given to recert, one ip rule per address. A single IP is marshalled as a plain string, the
+// form every recert version parses, while the IPs of a dual-stack node are marshalled as a list, which needs a
+// recert version supporting dual-stack IP changes.
+type IPs []string
+
+func (ips IPs) MarshalJSON() ([]byte, error) {
+	if len(ips) == 1 {
+		return json.Marshal(ips[0])
+	}
+	return json.Marshal([]string(ips))
+}
+
+func (ips *IPs) UnmarshalJSON(data []byte) error {
+	var ip string
+	if err := json.Unmarshal(data, &ip); err == nil {
+		*ips = IPs{ip}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to parse recert ip %s: %w", string(data), err)
+	}
+	*ips = list
+	return nil
+}
+
 // CreateRecertConfigFile function to create recert config file
 // those params will be provided to an installation script after reboot
 // that will run recert command with them
-func CreateRecertConfigFile(seedReconfig *seedreconfig.SeedReconfiguration, seedClusterInfo *seedclusterinfo.SeedClusterInfo, cryptoDir, recertConfigFolder string) error {
+func CreateRecertConfigFile(seedReconfig *seedreconfig.SeedReconfiguration, seedClusterInfo *seedclusterinfo.SeedClusterInfo,
+	servingCertificates []utils.ServingCertificateFiles, cryptoDir, recertConfigFolder string) error {
+	config, err := createRecertConfig(seedReconfig, seedClusterInfo, servingCertificates, cryptoDir)
+	if err != nil {
+		return err
+	}
+	return utils.MarshalToFile(config, filepath.Join(recertConfigFolder, RecertConfigFile))
+}
+
+// CreateRecertConfigFileForDryRun creates the same recert config file as CreateRecertConfigFile, but in dry-run mode
+// against the etcd server listening on DryRunEtcdEndpoint. The summary of the planned changes is written to
+// DryRunSummaryFileName in the recert config folder.
+func CreateRecertConfigFileForDryRun(seedReconfig *seedreconfig.SeedReconfiguration, seedClusterInfo *seedclusterinfo.SeedClusterInfo,
+	servingCertificates []utils.ServingCertificateFiles, cryptoDir, recertConfigFolder string) error {
+	config, err := createRecertConfig(seedReconfig, seedClusterInfo, servingCertificates, cryptoDir)
+	if err != nil {
+		return err
+	}
+	config.DryRun = true
+	config.EtcdEndpoint = DryRunEtcdEndpoint
+	config.SummaryFile = filepath.Join(recertConfigFolder, DryRunSummaryFileName)
+	return utils.MarshalToFile(config, filepath.Join(recertConfigFolder, RecertConfigFile))
+}
+
+func createRecertConfig(seedReconfig *seedreconfig.SeedReconfiguration, seedClusterInfo *seedclusterinfo.SeedClusterInfo,
+	servingCertificates []utils.ServingCertificateFiles, cryptoDir string) (*RecertConfig, error) {
 	config := createBasicEmptyRecertConfig()
 
 	config.ClusterRename = fmt.Sprintf("%s:%s", seedReconfig.ClusterName, seedReconfig.BaseDomain)
@@ -56,8 +116,25 @@ func CreateRecertConfigFile(seedReconfig *seedreconfig.SeedReconfiguration, seed
 		config.Hostname = seedReconfig.Hostname
 	}
 
-	if seedReconfig.NodeIP != seedClusterInfo.NodeIP {
-		config.IP = seedReconfig.NodeIP
+	ipReplacements, err := utils.IPReplacementsByFamily(
+		utils.NodeIPsOrPrimary(seedClusterInfo.NodeIP, seedClusterInfo.NodeIPs),
+		utils.NodeIPsOrPrimary(seedReconfig.NodeIP, seedReconfig.NodeIPs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to match seed node ips with the desired ones: %w", err)
+	}
+	// Any of the node IPs may change, e.g. only the secondary one of a dual-stack node, so the desired IPs are
+	// passed to recert as soon as one of them differs from the seed one
+	if lo.ContainsBy(ipReplacements, func(r utils.IPReplacement) bool { return r.From != r.To }) {
+		config.IP = lo.Map(ipReplacements, func(r utils.IPReplacement, _ int) string { return r.To })
+	}
+
+	serviceNetworkReplacements, err := utils.NetworkReplacements(seedClusterInfo.ServiceNetworks, seedReconfig.ServiceNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match seed service networks with the desired ones: %w", err)
+	}
+	serviceIPReplacements, err := utils.ServiceNetworkIPReplacements(serviceNetworkReplacements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service network ips: %w", err)
 	}
 
 	config.SummaryFile = SummaryFile
@@ -67,17 +144,22 @@ func CreateRecertConfigFile(seedReconfig *seedreconfig.SeedReconfiguration, seed
 	config.CNSanReplaceRules = []string{
 		fmt.Sprintf("system:node:%s,system:node:%s", seedClusterInfo.SNOHostname, seedReconfig.Hostname),
 		fmt.Sprintf("%s,%s", seedClusterInfo.SNOHostname, seedReconfig.Hostname),
-		fmt.Sprintf("%s,%s", seedClusterInfo.NodeIP, seedReconfig.NodeIP),
+	}
+	for _, ipReplacement := range append(ipReplacements, serviceIPReplacements...) {
+		config.CNSanReplaceRules = append(config.CNSanReplaceRules,
+			fmt.Sprintf("%s,%s", ipReplacement.From, ipReplacement.To))
+	}
+	config.CNSanReplaceRules = append(config.CNSanReplaceRules,
 		fmt.Sprintf("api.%s,api.%s", seedFullDomain, clusterFullDomain),
 		fmt.Sprintf("api-int.%s,api-int.%s", seedFullDomain, clusterFullDomain),
 		fmt.Sprintf("*.apps.%s,*.apps.%s", seedFullDomain, clusterFullDomain),
-	}
+	)
 	config.KubeadminPasswordHash = seedReconfig.KubeadminPasswordHash
 
 	if _, err := os.Stat(cryptoDir); err == nil {
 		ingressFile, ingressCN, err := getIngressCNAndFile(cryptoDir)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		config.UseKeyRules = []string{
 			fmt.Sprintf("kube-apiserver-lb-signer %s/loadbalancer-serving-signer.key", cryptoDir),
@@ -88,7 +170,19 @@ func CreateRecertConfigFile(seedReconfig *seedreconfig.SeedReconfiguration, seed
 		config.UseCertRules = []string{filepath.Join(cryptoDir, "admin-kubeconfig-client-ca.crt")}
 	}
 
-	return utils.MarshalToFile(config, filepath.Join(recertConfigFolder, RecertConfigFile))
+	// recert can only install the user certificates in place of the seed ones with the same common name, the others
+	// are installed by their manifests once the cluster is up
+	for _, servingCertificate := range servingCertificates {
+		if servingCertificate.CommonName != fmt.Sprintf("api.%s", clusterFullDomain) &&
+			servingCertificate.CommonName != fmt.Sprintf("*.apps.%s", clusterFullDomain) {
+			continue
+		}
+		config.UseKeyRules = append(config.UseKeyRules,
+			fmt.Sprintf("%s %s", servingCertificate.CommonName, servingCertificate.KeyFile))
+		config.UseCertRules = append(config.UseCertRules, servingCertificate.CertificateFile)
+	}
+
+	return &config, nil
 }
 
 func CreateRecertConfigFileForSeedCreation(path string) error {
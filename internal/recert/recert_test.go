@@ -0,0 +1,61 @@
+package recert
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+)
+
+func TestCreateRecertConfigNodeIPs(t *testing.T) {
+	testcases := []struct {
+		name       string
+		seedIPs    []string
+		desiredIPs []string
+		expectedIP string
+	}{
+		{
+			name:       "same single stack IP",
+			seedIPs:    []string{"192.168.127.10"},
+			desiredIPs: []string{"192.168.127.10"},
+		},
+		{
+			name:       "single stack IP changed",
+			seedIPs:    []string{"192.168.127.10"},
+			desiredIPs: []string{"192.168.127.20"},
+			expectedIP: `"192.168.127.20"`,
+		},
+		{
+			name:       "same dual-stack IPs",
+			seedIPs:    []string{"192.168.127.10", "fd00::10"},
+			desiredIPs: []string{"192.168.127.10", "fd00::10"},
+		},
+		{
+			name:       "only the secondary dual-stack IP changed",
+			seedIPs:    []string{"192.168.127.10", "fd00::10"},
+			desiredIPs: []string{"192.168.127.10", "fd00::20"},
+			expectedIP: `["192.168.127.10","fd00::20"]`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := createRecertConfig(
+				&seedreconfig.SeedReconfiguration{NodeIP: tc.desiredIPs[0], NodeIPs: tc.desiredIPs},
+				&seedclusterinfo.SeedClusterInfo{NodeIP: tc.seedIPs[0], NodeIPs: tc.seedIPs},
+				nil, filepath.Join(t.TempDir(), "missing"))
+			assert.NoError(t, err)
+			if tc.expectedIP == "" {
+				assert.Empty(t, config.IP)
+				return
+			}
+			ip, err := json.Marshal(config.IP)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedIP, string(ip))
+		})
+	}
+}
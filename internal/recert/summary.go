@@ -0,0 +1,114 @@
+package recert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+)
+
+// summary is the part of the summary file written by recert that is digested: the crypto objects it found and
+// regenerated, its configuration, as parsed by recert, and the durations of its phases
+type summary struct {
+	ClusterCrypto *struct {
+		CertKeyPairs []struct {
+			DistributedPrivateKey json.RawMessage `json:"distributed_private_key,omitempty"`
+		} `json:"cert_key_pairs"`
+	} `json:"cluster_crypto"`
+	Config *struct {
+		ClusterCustomizations *struct {
+			ClusterRename *struct {
+				ClusterName       string `json:"cluster_name"`
+				ClusterBaseDomain string `json:"cluster_base_domain"`
+				InfraID           string `json:"infra_id,omitempty"`
+			} `json:"cluster_rename,omitempty"`
+			Hostname string          `json:"hostname,omitempty"`
+			IP       json.RawMessage `json:"ip,omitempty"`
+		} `json:"cluster_customizations"`
+	} `json:"config"`
+	RunTimes map[string]json.RawMessage `json:"run_times,omitempty"`
+}
+
+// DigestSummary digests the summary written by recert: the number of certificates and keys it regenerated, the
+// renames it applied, described from the seed cluster values, and the durations of its phases. An error is returned
+// if the summary doesn't have the expected layout, e.g. after a recert change, rather than an empty digest.
+func DigestSummary(data []byte, seedClusterInfo *seedclusterinfo.SeedClusterInfo) (*lcav1alpha1.RecertSummary, error) {
+	s := &summary{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse recert summary: %w", err)
+	}
+	if s.ClusterCrypto == nil {
+		return nil, fmt.Errorf("recert summary has no cluster_crypto")
+	}
+	if s.Config == nil || s.Config.ClusterCustomizations == nil {
+		return nil, fmt.Errorf("recert summary has no config.cluster_customizations")
+	}
+
+	digest := &lcav1alpha1.RecertSummary{Certificates: len(s.ClusterCrypto.CertKeyPairs)}
+	for _, pair := range s.ClusterCrypto.CertKeyPairs {
+		if len(pair.DistributedPrivateKey) > 0 && string(pair.DistributedPrivateKey) != "null" {
+			digest.Keys++
+		}
+	}
+
+	customizations := s.Config.ClusterCustomizations
+	if rename := customizations.ClusterRename; rename != nil {
+		digest.Renames = append(digest.Renames, fmt.Sprintf("cluster: %s.%s -> %s.%s",
+			seedClusterInfo.ClusterName, seedClusterInfo.BaseDomain, rename.ClusterName, rename.ClusterBaseDomain))
+		if rename.InfraID != "" {
+			digest.Renames = append(digest.Renames, fmt.Sprintf("infra id: %s", rename.InfraID))
+		}
+	}
+	if customizations.Hostname != "" {
+		digest.Renames = append(digest.Renames,
+			fmt.Sprintf("hostname: %s -> %s", seedClusterInfo.SNOHostname, customizations.Hostname))
+	}
+	ips, err := summaryIPs(customizations.IP)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) > 0 {
+		seedIPs := seedClusterInfo.NodeIPs
+		if len(seedIPs) == 0 {
+			seedIPs = []string{seedClusterInfo.NodeIP}
+		}
+		digest.Renames = append(digest.Renames,
+			fmt.Sprintf("ip: %s -> %s", strings.Join(seedIPs, ","), strings.Join(ips, ",")))
+	}
+
+	if len(s.RunTimes) > 0 {
+		digest.Timings = make(map[string]string, len(s.RunTimes))
+		for name, runTime := range s.RunTimes {
+			var value string
+			if err := json.Unmarshal(runTime, &value); err != nil {
+				value = string(runTime)
+			}
+			digest.Timings[name] = value
+		}
+	}
+	return digest, nil
+}
+
+// summaryIPs returns the node IPs recert was configured with, which it reports either as a comma separated string or
+// as a list
+func summaryIPs(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var ip string
+	if err := json.Unmarshal(raw, &ip); err == nil {
+		if ip == "" {
+			return nil, nil
+		}
+		return strings.Split(ip, ","), nil
+	}
+	var ips []string
+	if err := json.Unmarshal(raw, &ips); err != nil {
+		return nil, fmt.Errorf("unexpected ip in recert summary: %s", string(raw))
+	}
+	return ips, nil
+}
@@ -0,0 +1,146 @@
+package recert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+)
+
+// testSummary follows the layout of the summary written by recert, trimmed to a few crypto objects
+const testSummary = `cluster_crypto:
+  cert_key_pairs:
+  - distributed_private_key:
+      locations:
+      - k8s:Secret/openshift-kube-apiserver-operator/localhost-serving-signer:/data/tls.key
+    distributed_cert:
+      certificate_subject: CN=localhost-serving-signer,OU=openshift
+      locations:
+      - k8s:Secret/openshift-kube-apiserver-operator/localhost-serving-signer:/data/tls.crt
+    signees: []
+  - distributed_private_key: null
+    distributed_cert:
+      certificate_subject: CN=admin-kubeconfig-signer,OU=openshift
+      locations:
+      - file:/etc/kubernetes/static-pod-resources/kube-apiserver-certs/configmaps/client-ca/ca-bundle.crt
+    signees: []
+  - distributed_private_key:
+      locations:
+      - k8s:Secret/openshift-ingress/router-certs-default:/data/tls.key
+    distributed_cert:
+      certificate_subject: CN=*.apps.seed.redhat.com
+      locations:
+      - k8s:Secret/openshift-ingress/router-certs-default:/data/tls.crt
+    signees: []
+  distributed_jwts: []
+config:
+  dry_run: false
+  etcd_endpoint: localhost:2379
+  crypto_dirs:
+  - /kubelet
+  - /kubernetes
+  cluster_customizations:
+    dirs:
+    - /kubelet
+    - /kubernetes
+    cluster_rename:
+      cluster_name: sno
+      cluster_base_domain: redhat.com
+      infra_id: sno-4f5kq
+    hostname: sno
+    ip: 192.168.127.20,fd00::20
+  summary_file: /var/tmp/recert-summary.yaml
+run_times:
+  scan_run_time: 1s 212ms
+  rechain_run_time: 4s 530ms
+  ocp_postprocessing_run_time: 2s 15ms
+  commit_to_etcd_and_disk_run_time: 3s 802ms
+`
+
+func TestDigestSummary(t *testing.T) {
+	seedClusterInfo := &seedclusterinfo.SeedClusterInfo{
+		SNOHostname: "seed",
+		ClusterName: "seed",
+		BaseDomain:  "redhat.com",
+		NodeIP:      "192.168.127.10",
+		NodeIPs:     []string{"192.168.127.10", "fd00::10"},
+	}
+	testcases := []struct {
+		name          string
+		summary       string
+		expected      *lcav1alpha1.RecertSummary
+		expectedError bool
+	}{
+		{
+			name:    "full summary with renames",
+			summary: testSummary,
+			expected: &lcav1alpha1.RecertSummary{
+				Certificates: 3,
+				Keys:         2,
+				Renames: []string{
+					"cluster: seed.redhat.com -> sno.redhat.com",
+					"infra id: sno-4f5kq",
+					"hostname: seed -> sno",
+					"ip: 192.168.127.10,fd00::10 -> 192.168.127.20,fd00::20",
+				},
+				Timings: map[string]string{
+					"scan_run_time":                    "1s 212ms",
+					"rechain_run_time":                 "4s 530ms",
+					"ocp_postprocessing_run_time":      "2s 15ms",
+					"commit_to_etcd_and_disk_run_time": "3s 802ms",
+				},
+			},
+		},
+		{
+			name: "nothing renamed and ips as a list",
+			summary: `cluster_crypto:
+  cert_key_pairs: []
+config:
+  cluster_customizations:
+    ip:
+    - 192.168.127.20
+run_times:
+  scan_run_time: {secs: 1, nanos: 0}
+`,
+			expected: &lcav1alpha1.RecertSummary{
+				Renames: []string{"ip: 192.168.127.10,fd00::10 -> 192.168.127.20"},
+				Timings: map[string]string{"scan_run_time": `{"nanos":0,"secs":1}`},
+			},
+		},
+		{
+			name:          "no crypto objects",
+			summary:       "config:\n  cluster_customizations: {}\n",
+			expectedError: true,
+		},
+		{
+			name:          "no customizations",
+			summary:       "cluster_crypto:\n  cert_key_pairs: []\n",
+			expectedError: true,
+		},
+		{
+			name:          "unexpected cert key pairs",
+			summary:       "cluster_crypto:\n  cert_key_pairs: 3\nconfig:\n  cluster_customizations: {}\n",
+			expectedError: true,
+		},
+		{
+			name:          "unexpected ip",
+			summary:       "cluster_crypto:\n  cert_key_pairs: []\nconfig:\n  cluster_customizations:\n    ip: {v4: 192.168.127.20}\n",
+			expectedError: true,
+		},
+		{
+			name:          "invalid yaml",
+			summary:       "cluster_crypto: [",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			digest, err := DigestSummary([]byte(tc.summary), seedClusterInfo)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			assert.Equal(t, tc.expected, digest)
+		})
+	}
+}
@@ -17,9 +17,13 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	v1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
 	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/spf13/cobra"
@@ -29,11 +33,13 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	seedgenv1alpha1 "github.com/openshift-kni/lifecycle-agent/api/seedgenerator/v1alpha1"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	ostree "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcreator"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedrestoration"
+	"github.com/openshift-kni/lifecycle-agent/utils"
 )
 
 var (
@@ -50,13 +56,38 @@ var (
 	recertSkipValidation bool
 
 	skipCleanup bool
+
+	// additionalImages are extra destinations, in the form IMAGE[,AUTHFILE], to push the OCI image to
+	additionalImages []string
+
+	// pushStatusFile is the path to write the per-destination push results to
+	pushStatusFile string
+
+	// ovnKubeNodeShutdownTimeout and containersStopTimeout bound the waits done while shutting down the seed cluster
+	ovnKubeNodeShutdownTimeout time.Duration
+	containersStopTimeout      time.Duration
+
+	// blockingComponentFile is the path to write the component that blocked the seed creation to, if any
+	blockingComponentFile string
+
+	// fromSysroot is the ostree sysroot of a stopped seed SNO to create the OCI image from, instead of the running host
+	fromSysroot string
+
+	// seedClusterInfoFile is the seed cluster info used when creating the OCI image from a sysroot
+	seedClusterInfoFile string
+
+	// containerStorage is the container storage of the seed when creating the OCI image from a sysroot
+	containerStorage string
 )
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(v1.AddToScheme(scheme))
+	utilruntime.Must(mcfgv1.AddToScheme(scheme))
+	utilruntime.Must(operatorv1.AddToScheme(scheme))
 	utilruntime.Must(operatorv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(operatorsv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(seedgenv1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -78,6 +109,19 @@ func init() {
 
 	// Add flags to create command
 	addCommonFlags(createCmd)
+	createCmd.Flags().DurationVarP(&ovnKubeNodeShutdownTimeout, "ovn-shutdown-timeout", "", seedcreator.DefaultOvnKubeNodeShutdownTimeout,
+		"How long to wait for the ovnkube-node pods to stop.")
+	createCmd.Flags().DurationVarP(&containersStopTimeout, "containers-stop-timeout", "", seedcreator.DefaultContainersStopTimeout,
+		"How long to wait for all running containers to stop.")
+	createCmd.Flags().StringVarP(&blockingComponentFile, "blocking-component-file", "", "",
+		"The path to a file where the component that blocked the seed creation is written, if a wait times out.")
+	createCmd.Flags().StringVarP(&fromSysroot, "from-sysroot", "", "",
+		"Create the OCI image from the ostree sysroot of a stopped seed SNO (e.g. a mounted disk image) instead of the running host.")
+	createCmd.Flags().StringVarP(&seedClusterInfoFile, "seed-cluster-info", "", "",
+		"The seed cluster info (manifest.json) describing the seed cluster, required with --from-sysroot.")
+	createCmd.Flags().StringVarP(&containerStorage, "container-storage", "", "",
+		"The seed container storage, used with --from-sysroot (default: <stateroot>/var/lib/containers/storage).")
+	createCmd.MarkFlagsRequiredTogether("from-sysroot", "seed-cluster-info")
 }
 
 func create() error {
@@ -89,10 +133,19 @@ func create() error {
 	op := ops.NewOps(log, hostCommandsExecutor)
 	rpmOstreeClient := ostree.NewClient("lca-cli", hostCommandsExecutor)
 
+	destinations, err := parseAdditionalImages()
+	if err != nil {
+		return err
+	}
+
+	if fromSysroot != "" {
+		return createFromSysroot(op, destinations)
+	}
+
 	if !skipCleanup {
 		defer func() {
 			if err = seedrestoration.NewSeedRestoration(log, op, common.BackupDir, containerRegistry,
-				authFile, recertContainerImage, recertSkipValidation).CleanupSeedCluster(); err != nil {
+				authFile, recertContainerImage, recertSkipValidation, additionalImageNames(destinations)).CleanupSeedCluster(); err != nil {
 				log.Fatalf("Failed to restore seed cluster: %v", err)
 			}
 			log.Info("Seed cluster restored successfully!")
@@ -110,8 +163,15 @@ func create() error {
 	}
 
 	seedCreator := seedcreator.NewSeedCreator(client, log, op, rpmOstreeClient, common.BackupDir, common.KubeconfigFile,
-		containerRegistry, authFile, recertContainerImage, recertSkipValidation)
+		containerRegistry, authFile, recertContainerImage, recertSkipValidation, destinations, pushStatusFile,
+		seedcreator.WaitTimeouts{OvnKubeNodeShutdown: ovnKubeNodeShutdownTimeout, ContainersStop: containersStopTimeout})
 	if err = seedCreator.CreateSeedImage(); err != nil {
+		var blockedErr *seedcreator.BlockedError
+		if errors.As(err, &blockedErr) && blockingComponentFile != "" {
+			if writeErr := utils.MarshalToFile(blockedErr, blockingComponentFile); writeErr != nil {
+				log.Errorf("failed to write blocking component to %s: %v", blockingComponentFile, writeErr)
+			}
+		}
 		err = fmt.Errorf("failed to create seed image: %w", err)
 		log.Errorf(err.Error())
 		return err
@@ -120,3 +180,49 @@ func create() error {
 	log.Info("OCI image created successfully!")
 	return nil
 }
+
+// createFromSysroot creates the OCI image from the ostree sysroot of a stopped seed SNO. There is no running seed
+// cluster to restore afterwards, only the seed image and the backup folders are cleaned up.
+func createFromSysroot(op ops.Ops, destinations []seedcreator.SeedImageDestination) error {
+	seedCreator := seedcreator.NewOfflineSeedCreator(log, op, common.BackupDir, fromSysroot, seedClusterInfoFile,
+		containerStorage, containerRegistry, authFile, recertContainerImage, destinations, pushStatusFile)
+
+	if !skipCleanup {
+		defer func() {
+			if cleanupErr := seedCreator.CleanupOfflineSeedCreation(); cleanupErr != nil {
+				log.Errorf("Failed to cleanup offline seed creation: %v", cleanupErr)
+			}
+		}()
+	}
+
+	if err := seedCreator.CreateSeedImage(); err != nil {
+		err = fmt.Errorf("failed to create seed image from sysroot %s: %w", fromSysroot, err)
+		log.Errorf(err.Error())
+		return err
+	}
+
+	log.Info("OCI image created successfully!")
+	return nil
+}
+
+// parseAdditionalImages parses the --additional-image flags into seed image destinations
+func parseAdditionalImages() ([]seedcreator.SeedImageDestination, error) {
+	var destinations []seedcreator.SeedImageDestination
+	for _, additionalImage := range additionalImages {
+		destination, err := seedcreator.ParseSeedImageDestination(additionalImage, authFile)
+		if err != nil {
+			return nil, err
+		}
+		destinations = append(destinations, destination)
+	}
+	return destinations, nil
+}
+
+// additionalImageNames returns the image names of the given seed image destinations
+func additionalImageNames(destinations []seedcreator.SeedImageDestination) []string {
+	var images []string
+	for _, destination := range destinations {
+		images = append(images, destination.Image)
+	}
+	return images
+}
@@ -38,6 +38,7 @@ var ibi = &cobra.Command{
 var seedImage string
 var seedVersion string
 var pullSecretFile string
+var seedReconfigurationFile string
 
 func init() {
 
@@ -48,6 +49,8 @@ func init() {
 	ibi.Flags().StringVarP(&seedVersion, "seed-version", "", "", "Seed version.")
 	ibi.Flags().StringVarP(&authFile, "authfile", "a", "", "The path to the authentication file of the container registry of seed image.")
 	ibi.Flags().StringVarP(&pullSecretFile, "pullSecretFile", "p", "", "The path to the pull secret file for precache process.")
+	ibi.Flags().StringVarP(&seedReconfigurationFile, "seed-reconfiguration", "", "",
+		"The path to the seed reconfiguration of the installed cluster, to validate it with a recert dry-run.")
 
 }
 
@@ -57,7 +60,8 @@ func runIBI() {
 	rpmOstreeClient := ostree.NewClient("lca-cli", hostCommandsExecutor)
 	ostreeClient := ostreeclient.NewClient(hostCommandsExecutor, true)
 
-	ibiRunner := ibipreparation.NewIBIPrepare(log, ops.NewOps(log, hostCommandsExecutor), rpmOstreeClient, ostreeClient, seedImage, authFile, pullSecretFile, seedVersion)
+	ibiRunner := ibipreparation.NewIBIPrepare(log, ops.NewOps(log, hostCommandsExecutor), rpmOstreeClient, ostreeClient, seedImage, authFile, pullSecretFile, seedVersion,
+		seedReconfigurationFile)
 	if err := ibiRunner.Run(); err != nil {
 		log.Fatal(err)
 	}
@@ -18,7 +18,9 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
@@ -65,7 +67,22 @@ func postPivot() {
 
 	postPivotRunner := postpivot.NewPostPivot(scheme, log, opsClient,
 		common.ImageRegistryAuthFile, common.OptOpenshift, common.KubeconfigFile)
-	if err := postPivotRunner.PostPivotConfiguration(context.TODO()); err != nil {
+	// The auto-rollback configuration is only written during an IBU, only the default step timeouts apply without it
+	rollbackCfg, err := rebootClient.ReadIBUAutoRollbackConfigFile()
+	switch {
+	case err == nil:
+		err = postPivotRunner.SetStepTimeouts(rollbackCfg.PostPivotStepTimeouts)
+	case errors.Is(err, os.ErrNotExist):
+		log.Info("No auto-rollback configuration found, using the default post pivot step timeouts")
+		err = nil
+	default:
+		log.Warnf("Failed to read the auto-rollback configuration, using the default post pivot step timeouts: %v", err)
+		err = nil
+	}
+	if err == nil {
+		err = postPivotRunner.PostPivotConfiguration(context.TODO())
+	}
+	if err != nil {
 		log.Error(err)
 		rebootClient.AutoRollbackIfEnabled(reboot.PostPivotComponent, fmt.Sprintf("Rollback due to postpivot failure: %s", err))
 		log.Fatal("Post pivot operation failed")
@@ -47,8 +47,13 @@ func restore() {
 	hostCommandsExecutor := ops.NewNsenterExecutor(log, true)
 	op := ops.NewOps(log, hostCommandsExecutor)
 
+	destinations, err := parseAdditionalImages()
+	if err != nil {
+		log.Fatalf("Failed to parse additional images: %v", err)
+	}
+
 	seedRestore := seedrestoration.NewSeedRestoration(log, op, common.BackupDir, containerRegistry,
-		authFile, recertContainerImage, recertSkipValidation)
+		authFile, recertContainerImage, recertSkipValidation, additionalImageNames(destinations))
 
 	if err := seedRestore.CleanupSeedCluster(); err != nil {
 		log.Fatalf("Failed to restore seed cluster: %v", err)
@@ -46,6 +46,9 @@ func addCommonFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&recertContainerImage, "recert-image", "e", common.DefaultRecertImage, "The full image name for the recert container tool.")
 	cmd.Flags().BoolVarP(&recertSkipValidation, "skip-recert-validation", "", false, "Skips the validations performed by the recert tool.")
 	cmd.Flags().BoolVarP(&skipCleanup, "skip-cleanup", "", false, "Skips cleanup.")
+	cmd.Flags().StringArrayVarP(&additionalImages, "additional-image", "", nil,
+		"Additional destination to push the OCI image to, in the form IMAGE[,AUTHFILE]. Can be specified multiple times.")
+	cmd.Flags().StringVarP(&pushStatusFile, "push-status-file", "", "", "The path to a file where the per-destination push results are written.")
 
 	// Mark flags as required
 	cmd.MarkFlagRequired("image")
@@ -0,0 +1,103 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedrestoration"
+	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// recoverTimeout is how long to wait for the seed cluster to come back before restoring its resources
+var recoverTimeout time.Duration
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Seed image generation operations",
+}
+
+var seedRecoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Recover the seed cluster from a seed image generation that did not complete",
+	Long: `Recover the seed cluster from a seed image generation that did not complete.
+
+The completed seed creation steps are inspected to work out how far the run got, and each of them is reversed:
+the original certificates are restored via recert, kubelet is re-enabled so the Node is recreated, and the original
+pull-secret and the seedgen CRs are restored. A summary of the actions taken is printed at the end.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := seedRecover(); err != nil {
+			log.Fatalf("Error executing seed recover command: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(seedCmd)
+	seedCmd.AddCommand(seedRecoverCmd)
+
+	seedRecoverCmd.Flags().StringVarP(&authFile, "authfile", "a", common.ImageRegistryAuthFile, "The path to the authentication file of the container registry.")
+	seedRecoverCmd.Flags().StringVarP(&containerRegistry, "image", "i", "", "The full image name of the seed image to remove, if it was built.")
+	seedRecoverCmd.Flags().StringArrayVarP(&additionalImages, "additional-image", "", nil,
+		"Additional seed image tag to remove, in the form IMAGE[,AUTHFILE]. Can be specified multiple times.")
+	seedRecoverCmd.Flags().StringVarP(&recertContainerImage, "recert-image", "e", common.DefaultRecertImage, "The full image name for the recert container tool.")
+	seedRecoverCmd.Flags().BoolVarP(&recertSkipValidation, "skip-recert-validation", "", false, "Skips restoring the original certificates via the recert tool.")
+	seedRecoverCmd.Flags().DurationVarP(&recoverTimeout, "timeout", "t", 30*time.Minute, "How long to wait for the seed cluster to come back.")
+}
+
+func seedRecover() error {
+	log.Info("Seed cluster recovery has started")
+
+	destinations, err := parseAdditionalImages()
+	if err != nil {
+		return err
+	}
+
+	hostCommandsExecutor := ops.NewNsenterExecutor(log, true)
+	op := ops.NewOps(log, hostCommandsExecutor)
+
+	actions := seedrestoration.NewSeedRestoration(log, op, common.BackupDir, containerRegistry,
+		authFile, recertContainerImage, recertSkipValidation, additionalImageNames(destinations)).
+		RecoverSeedCluster(context.Background(), func() (runtimeclient.Client, error) {
+			return lcautils.CreateKubeClient(scheme, common.KubeconfigFile)
+		}, recoverTimeout)
+
+	failed := 0
+	fmt.Println("Seed cluster recovery summary:")
+	for _, action := range actions {
+		if action.Err != nil {
+			failed++
+			fmt.Printf("  [FAILED] %s: %v\n", action.Description, action.Err)
+		} else {
+			fmt.Printf("  [OK]     %s\n", action.Description)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d seed cluster recovery actions failed", failed)
+	}
+
+	log.Info("Seed cluster recovered successfully!")
+	return nil
+}
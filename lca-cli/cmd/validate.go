@@ -0,0 +1,53 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "validate a seed reconfiguration",
+	Long: `Validate a seed reconfiguration offline, before it's used to start an IBI or an IBU: its version, required
+fields, hostname, IPs, NMState config and crypto material are checked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		validate()
+	},
+}
+
+var validateSeedReconfigurationFile string
+
+func init() {
+
+	// Add validate command
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringVarP(&validateSeedReconfigurationFile, "seed-reconfiguration", "f", "",
+		"The path to the seed reconfiguration to validate.")
+	_ = validateCmd.MarkFlagRequired("seed-reconfiguration")
+}
+
+func validate() {
+	if _, err := utils.ReadSeedReconfigurationFromFile(validateSeedReconfigurationFile); err != nil {
+		log.Fatalf("Seed reconfiguration %s is invalid: %v", validateSeedReconfigurationFile, err)
+	}
+	log.Infof("Seed reconfiguration %s is valid", validateSeedReconfigurationFile)
+}
@@ -6,9 +6,10 @@ import (
 	"path/filepath"
 	"syscall"
 
-	"github.com/go-logr/logr"
 	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
 	"github.com/openshift-kni/lifecycle-agent/internal/precache"
@@ -16,6 +17,7 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/internal/prep"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
+	"github.com/openshift-kni/lifecycle-agent/utils"
 )
 
 type IBIPrepare struct {
@@ -27,19 +29,23 @@ type IBIPrepare struct {
 	ostreeClient        ostreeclient.IClient
 	seedExpectedVersion string
 	pullSecretFile      string
+	// seedReconfigurationFile is the seed reconfiguration of the installed cluster, when known in advance, used to
+	// validate it with a recert dry-run
+	seedReconfigurationFile string
 }
 
 func NewIBIPrepare(log *logrus.Logger, ops ops.Ops, rpmostreeClient rpmostreeclient.IClient,
-	ostreeClient ostreeclient.IClient, seedImage, authFile, pullSecretFile, seedExpectedVersion string) *IBIPrepare {
+	ostreeClient ostreeclient.IClient, seedImage, authFile, pullSecretFile, seedExpectedVersion, seedReconfigurationFile string) *IBIPrepare {
 	return &IBIPrepare{
-		log:                 log,
-		ops:                 ops,
-		authFile:            authFile,
-		pullSecretFile:      pullSecretFile,
-		seedImage:           seedImage,
-		rpmostreeClient:     rpmostreeClient,
-		ostreeClient:        ostreeClient,
-		seedExpectedVersion: seedExpectedVersion,
+		log:                     log,
+		ops:                     ops,
+		authFile:                authFile,
+		pullSecretFile:          pullSecretFile,
+		seedImage:               seedImage,
+		rpmostreeClient:         rpmostreeClient,
+		ostreeClient:            ostreeClient,
+		seedExpectedVersion:     seedExpectedVersion,
+		seedReconfigurationFile: seedReconfigurationFile,
 	}
 }
 
@@ -53,15 +59,39 @@ func (i *IBIPrepare) Run() error {
 		return fmt.Errorf("failed to pull image: %w", err)
 	}
 
-	// TODO: change to logrus after refactoring the code in controllers and moving to logrus
-	log := logr.Logger{}
+	log := zap.New(zap.WriteTo(i.log.Out))
 	common.OstreeDeployPathPrefix = "/mnt/"
+	var seedReconfiguration *seedreconfig.SeedReconfiguration
+	var kargs []string
+	if i.seedReconfigurationFile != "" {
+		var err error
+		seedReconfiguration, err = utils.ReadSeedReconfigurationFromFile(i.seedReconfigurationFile)
+		if err != nil {
+			return fmt.Errorf("failed to read seed reconfiguration from %s: %w", i.seedReconfigurationFile, err)
+		}
+		kargs, err = utils.KernelArguments(seedReconfiguration.KernelArguments, seedReconfiguration.MachineConfigs)
+		if err != nil {
+			return fmt.Errorf("failed to get kernel arguments from seed reconfiguration: %w", err)
+		}
+	}
+
 	// Setup state root
 	if err := prep.SetupStateroot(log, i.ops, i.ostreeClient, i.rpmostreeClient,
-		i.seedImage, i.seedExpectedVersion, imageListFile, true); err != nil {
+		i.seedImage, i.seedExpectedVersion, imageListFile, true, kargs); err != nil {
 		return err
 	}
 
+	if seedReconfiguration != nil {
+		summary, err := prep.RecertDryRun(log, i.ops, i.ostreeClient, seedReconfiguration,
+			common.GetStaterootName(i.seedExpectedVersion), i.pullSecretFile)
+		if err != nil {
+			return err
+		}
+		if summary != nil {
+			i.log.Info(utils.RecertDryRunSummaryMessage(summary))
+		}
+	}
+
 	// TODO: add support for mirror registry
 	imageList, err := prep.ReadPrecachingList(imageListFile, "", "", false)
 	if err != nil {
@@ -124,6 +124,25 @@ func (mr *MockOpsMockRecorder) Mount(deviceName, mountFolder any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Mount", reflect.TypeOf((*MockOps)(nil).Mount), deviceName, mountFolder)
 }
 
+// RecertDryRun mocks base method.
+func (m *MockOps) RecertDryRun(recertContainerImage, etcdImage, authFile, configFile, etcdDataDir, staterootPath, deploymentDir string, additionalPodmanParams ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{recertContainerImage, etcdImage, authFile, configFile, etcdDataDir, staterootPath, deploymentDir}
+	for _, a := range additionalPodmanParams {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RecertDryRun", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecertDryRun indicates an expected call of RecertDryRun.
+func (mr *MockOpsMockRecorder) RecertDryRun(recertContainerImage, etcdImage, authFile, configFile, etcdDataDir, staterootPath, deploymentDir any, additionalPodmanParams ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{recertContainerImage, etcdImage, authFile, configFile, etcdDataDir, staterootPath, deploymentDir}, additionalPodmanParams...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecertDryRun", reflect.TypeOf((*MockOps)(nil).RecertDryRun), varargs...)
+}
+
 // RecertFullFlow mocks base method.
 func (m *MockOps) RecertFullFlow(recertContainerImage, authFile, configFile string, preRecertOperations, postRecertOperations func() error, additionalPodmanParams ...string) error {
 	m.ctrl.T.Helper()
@@ -231,17 +250,17 @@ func (mr *MockOpsMockRecorder) RunRecert(recertContainerImage, authFile, recertC
 }
 
 // RunUnauthenticatedEtcdServer mocks base method.
-func (m *MockOps) RunUnauthenticatedEtcdServer(authFile, name string) error {
+func (m *MockOps) RunUnauthenticatedEtcdServer(authFile, name, etcdStaticPodFile, dataDir string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RunUnauthenticatedEtcdServer", authFile, name)
+	ret := m.ctrl.Call(m, "RunUnauthenticatedEtcdServer", authFile, name, etcdStaticPodFile, dataDir)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RunUnauthenticatedEtcdServer indicates an expected call of RunUnauthenticatedEtcdServer.
-func (mr *MockOpsMockRecorder) RunUnauthenticatedEtcdServer(authFile, name any) *gomock.Call {
+func (mr *MockOpsMockRecorder) RunUnauthenticatedEtcdServer(authFile, name, etcdStaticPodFile, dataDir any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunUnauthenticatedEtcdServer", reflect.TypeOf((*MockOps)(nil).RunUnauthenticatedEtcdServer), authFile, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunUnauthenticatedEtcdServer", reflect.TypeOf((*MockOps)(nil).RunUnauthenticatedEtcdServer), authFile, name, etcdStaticPodFile, dataDir)
 }
 
 // SystemctlAction mocks base method.
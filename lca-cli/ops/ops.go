@@ -30,7 +30,7 @@ type Ops interface {
 	RunBashInHostNamespace(command string, args ...string) (string, error)
 	ForceExpireSeedCrypto(recertContainerImage, authFile string) error
 	RestoreOriginalSeedCrypto(recertContainerImage, authFile string) error
-	RunUnauthenticatedEtcdServer(authFile, name string) error
+	RunUnauthenticatedEtcdServer(authFile, name, etcdStaticPodFile, dataDir string) error
 	waitForEtcd(healthzEndpoint string) error
 	RunRecert(recertContainerImage, authFile, recertConfigFile string, additionalPodmanParams ...string) error
 	ExtractTarWithSELinux(srcPath, destPath string) error
@@ -40,6 +40,8 @@ type Ops interface {
 	UnmountAndRemoveImage(img string) error
 	RecertFullFlow(recertContainerImage, authFile, configFile string,
 		preRecertOperations func() error, postRecertOperations func() error, additionalPodmanParams ...string) error
+	RecertDryRun(recertContainerImage, etcdImage, authFile, configFile, etcdDataDir, staterootPath, deploymentDir string,
+		additionalPodmanParams ...string) error
 	ListBlockDevices() ([]BlockDevice, error)
 	Mount(deviceName, mountFolder string) error
 	Umount(deviceName string) error
@@ -121,25 +123,26 @@ func (o *ops) RestoreOriginalSeedCrypto(recertContainerImage, authFile string) e
 }
 
 // RunUnauthenticatedEtcdServer Run unauthenticated etcd server for the recert tool.
-// This runs a small (fake) unauthenticated etcd server backed by the actual etcd database,
-// which is required before running the recert tool.
-func (o *ops) RunUnauthenticatedEtcdServer(authFile, name string) error {
+// This runs a small (fake) unauthenticated etcd server backed by the etcd database in dataDir,
+// which is required before running the recert tool. The etcd image is taken from the given
+// etcd static pod file, so that the server matches the release the etcd data belongs to.
+func (o *ops) RunUnauthenticatedEtcdServer(authFile, name, etcdStaticPodFile, dataDir string) error {
 	// Get etcdImage available for the current release, this is needed by recert to
 	// run an unauthenticated etcd server for running successfully.
-	o.log.Infof("Getting image from %s static pod file", common.EtcdStaticPodFile)
-	etcdImage, err := utils.ReadImageFromStaticPodDefinition(common.EtcdStaticPodFile, common.EtcdStaticPodContainer)
+	o.log.Infof("Getting image from %s static pod file", etcdStaticPodFile)
+	etcdImage, err := utils.ReadImageFromStaticPodDefinition(etcdStaticPodFile, common.EtcdStaticPodContainer)
 	if err != nil {
 		return err
 	}
 
-	o.log.Info("Run unauthenticated etcd server for recert tool")
+	o.log.Infof("Run unauthenticated etcd server on %s", dataDir)
 
 	command := "podman"
 	args := append(podmanRecertArgs,
 		"--authfile", authFile, "--detach",
 		"--name", name,
 		"--entrypoint", "etcd",
-		"-v", "/var/lib/etcd:/store",
+		"-v", fmt.Sprintf("%s:/store", dataDir),
 		etcdImage,
 		"--name", "editor", "--data-dir", "/store")
 
@@ -187,7 +190,7 @@ func (o *ops) waitForEtcd(healthzEndpoint string) error {
 func (o *ops) RunRecert(recertContainerImage, authFile, recertConfigFile string, additionalPodmanParams ...string) error {
 	o.log.Info("Start running recert")
 	command := "podman"
-	args := append(podmanRecertArgs, "--name", "recert",
+	args := append(podmanRecertArgs, "--name", common.RecertContainerName,
 		"-v", "/etc:/host-etc",
 		"-v", "/etc/kubernetes:/kubernetes",
 		"-v", "/var/lib/kubelet:/kubelet",
@@ -291,7 +294,7 @@ func (o *ops) UnmountAndRemoveImage(img string) error {
 
 func (o *ops) RecertFullFlow(recertContainerImage, authFile, configFile string,
 	preRecertOperations func() error, postRecertOperations func() error, additionalPodmanParams ...string) error {
-	if err := o.RunUnauthenticatedEtcdServer(authFile, common.EtcdContainerName); err != nil {
+	if err := o.RunUnauthenticatedEtcdServer(authFile, common.EtcdContainerName, common.EtcdStaticPodFile, "/var/lib/etcd"); err != nil {
 		return fmt.Errorf("failed to run etcd, err: %w", err)
 	}
 
@@ -322,6 +325,57 @@ func (o *ops) RecertFullFlow(recertContainerImage, authFile, configFile string,
 	return nil
 }
 
+// RecertDryRun runs recert in dry-run mode against an unauthenticated etcd server backed by etcdDataDir, listening on
+// recert.DryRunEtcdEndpoint so it can run alongside the etcd of the running cluster. The static dirs and files are
+// taken read-only from the given stateroot and deployment instead of the booted ones.
+func (o *ops) RecertDryRun(recertContainerImage, etcdImage, authFile, configFile, etcdDataDir, staterootPath, deploymentDir string,
+	additionalPodmanParams ...string) error {
+	o.log.Info("Run unauthenticated etcd server for recert dry-run")
+	etcdURL := "http://" + recert.DryRunEtcdEndpoint
+	args := append(podmanRecertArgs,
+		"--authfile", authFile, "--detach",
+		"--name", common.EtcdDryRunContainerName,
+		"--entrypoint", "etcd",
+		"-v", fmt.Sprintf("%s:/store", etcdDataDir),
+		etcdImage,
+		"--name", "editor", "--data-dir", "/store",
+		"--listen-client-urls", etcdURL, "--advertise-client-urls", etcdURL,
+		"--listen-peer-urls", "http://localhost:2480")
+	if _, err := o.RunInHostNamespace("podman", args...); err != nil {
+		return fmt.Errorf("failed to run etcd, err: %w", err)
+	}
+
+	defer func() {
+		o.log.Info("Killing the recert dry-run etcd server")
+		if _, err := o.RunInHostNamespace("podman", "stop", common.EtcdDryRunContainerName); err != nil {
+			o.log.WithError(err).Errorf("failed to kill %s container.", common.EtcdDryRunContainerName)
+		}
+	}()
+
+	if err := o.waitForEtcd(etcdURL + "/health"); err != nil {
+		return fmt.Errorf("failed to wait for unauthenticated etcd server: %w", err)
+	}
+
+	o.log.Info("Start running recert dry-run")
+	args = append(podmanRecertArgs, "--name", "recert_dry_run",
+		"-v", fmt.Sprintf("%s:/host-etc:ro", path.Join(deploymentDir, "etc")),
+		"-v", fmt.Sprintf("%s:/kubernetes:ro", path.Join(deploymentDir, "etc/kubernetes")),
+		"-v", fmt.Sprintf("%s:/kubelet:ro", path.Join(staterootPath, "var/lib/kubelet")),
+		"-v", fmt.Sprintf("%s:/machine-config-daemon:ro", path.Join(deploymentDir, "etc/machine-config-daemon")),
+		"-e", fmt.Sprintf("RECERT_CONFIG=%s", configFile),
+	)
+	if authFile != "" {
+		args = append(args, "--authfile", authFile)
+	}
+	args = append(args, additionalPodmanParams...)
+	args = append(args, recertContainerImage)
+	if _, err := o.hostCommandsExecutor.Execute("podman", args...); err != nil {
+		return fmt.Errorf("failed to run recert dry-run container: %w", err)
+	}
+
+	return nil
+}
+
 // ListBlockDevices runs lsblk command and not using go library cause
 // each library that i was looking into doesn't show label for block device and shows labels only for partitions
 func (o *ops) ListBlockDevices() ([]BlockDevice, error) {
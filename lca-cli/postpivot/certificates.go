@@ -0,0 +1,124 @@
+package postpivot
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	v1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+const (
+	apiServerName                 = "cluster"
+	apiServerFileName             = "apiserver.json"
+	apiNamedCertificateNameFormat = "api-named-certificate-%d"
+	ingressControllerName         = "default"
+	ingressControllerNamespace    = "openshift-ingress-operator"
+	ingressControllerFileName     = "ingress-controller.json"
+	ingressCertificateName        = "ingress-default-certificate"
+	ingressCertificateNamespace   = "openshift-ingress"
+)
+
+// setServingCertificates renders the API named certificates and the ingress certificate provided by the user into the
+// manifests folder, as tls secrets referenced by the APIServer and the default IngressController, to be applied when
+// the cluster is up. The operators then keep serving them, including the ones recert couldn't install.
+func (p *PostPivot) setServingCertificates(ctx context.Context, seedReconfiguration *clusterconfig_api.SeedReconfiguration, manifestsDir string) error {
+	if len(seedReconfiguration.APINamedCertificates) == 0 && seedReconfiguration.IngressCertificate == nil {
+		p.log.Infof("No API or ingress serving certificates were provided, skipping")
+		return nil
+	}
+
+	if len(seedReconfiguration.APINamedCertificates) > 0 {
+		apiServer := v1.APIServer{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: apiServerName,
+			},
+		}
+		for i, namedCertificate := range seedReconfiguration.APINamedCertificates {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			name := fmt.Sprintf(apiNamedCertificateNameFormat, i)
+			if err := p.createTLSSecretManifest(name, common.OpenshiftConfigNamespace,
+				&namedCertificate.ServingCertificate, path.Join(manifestsDir, name+".json")); err != nil {
+				return err
+			}
+			apiServer.Spec.ServingCerts.NamedCertificates = append(apiServer.Spec.ServingCerts.NamedCertificates,
+				v1.APIServerNamedServingCert{
+					Names:              namedCertificate.Names,
+					ServingCertificate: v1.SecretNameReference{Name: name},
+				})
+		}
+		p.log.Infof("Creating apiserver manifest %s", path.Join(manifestsDir, apiServerFileName))
+		if err := p.marshalManifest(&apiServer, path.Join(manifestsDir, apiServerFileName)); err != nil {
+			return fmt.Errorf("failed to create apiserver manifest, err: %w", err)
+		}
+	}
+
+	if seedReconfiguration.IngressCertificate != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := p.createTLSSecretManifest(ingressCertificateName, ingressCertificateNamespace,
+			seedReconfiguration.IngressCertificate, path.Join(manifestsDir, ingressCertificateName+".json")); err != nil {
+			return err
+		}
+		ingressController := operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ingressControllerName,
+				Namespace: ingressControllerNamespace,
+			},
+			Spec: operatorv1.IngressControllerSpec{
+				DefaultCertificate: &corev1.LocalObjectReference{Name: ingressCertificateName},
+			},
+		}
+		p.log.Infof("Creating ingress controller manifest %s", path.Join(manifestsDir, ingressControllerFileName))
+		if err := p.marshalManifest(&ingressController, path.Join(manifestsDir, ingressControllerFileName)); err != nil {
+			return fmt.Errorf("failed to create ingress controller manifest, err: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// createTLSSecretManifest validates the serving certificate and creates its tls secret in the manifests folder
+func (p *PostPivot) createTLSSecretManifest(name, namespace string, servingCertificate *clusterconfig_api.ServingCertificate, manifest string) error {
+	if _, err := utils.ServingCertificateCommonName(servingCertificate); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	p.log.Infof("Creating tls secret manifest %s", manifest)
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte(servingCertificate.Certificate),
+			corev1.TLSPrivateKeyKey: []byte(servingCertificate.Key),
+		},
+	}
+	if err := p.marshalManifest(&secret, manifest); err != nil {
+		return fmt.Errorf("failed to create %s secret manifest, err: %w", name, err)
+	}
+	return nil
+}
+
+// marshalManifest sets the type meta of the object and writes it to the manifest file
+func (p *PostPivot) marshalManifest(obj runtime.Object, manifest string) error {
+	typeMeta, err := utils.TypeMetaForObject(p.scheme, obj)
+	if err != nil {
+		return err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(typeMeta.GroupVersionKind())
+	return utils.MarshalToFile(obj, manifest)
+}
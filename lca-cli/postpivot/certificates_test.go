@@ -0,0 +1,142 @@
+package postpivot
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	v1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+func testServingCertificate(t *testing.T, commonName string) clusterconfig_api.ServingCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return clusterconfig_api.ServingCertificate{
+		Certificate: clusterconfig_api.PEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		Key:         clusterconfig_api.PEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+func TestSetServingCertificates(t *testing.T) {
+	apiCertificate := testServingCertificate(t, "api.test.example.com")
+	ingressCertificate := testServingCertificate(t, "*.apps.test.example.com")
+
+	testcases := []struct {
+		name                 string
+		apiNamedCertificates []clusterconfig_api.NamedCertificate
+		ingressCertificate   *clusterconfig_api.ServingCertificate
+		expectedError        bool
+	}{
+		{
+			name: "Nothing provided",
+		},
+		{
+			name: "API named certificates",
+			apiNamedCertificates: []clusterconfig_api.NamedCertificate{
+				{Names: []string{"api.test.example.com"}, ServingCertificate: apiCertificate},
+			},
+		},
+		{
+			name:               "Ingress certificate",
+			ingressCertificate: &ingressCertificate,
+		},
+		{
+			name: "API and ingress certificates",
+			apiNamedCertificates: []clusterconfig_api.NamedCertificate{
+				{Names: []string{"api.test.example.com"}, ServingCertificate: apiCertificate},
+			},
+			ingressCertificate: &ingressCertificate,
+		},
+		{
+			name:               "Key not matching the certificate",
+			ingressCertificate: &clusterconfig_api.ServingCertificate{Certificate: ingressCertificate.Certificate, Key: apiCertificate.Key},
+			expectedError:      true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifestsDir := t.TempDir()
+			scheme := runtime.NewScheme()
+			assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+			assert.NoError(t, v1.AddToScheme(scheme))
+			assert.NoError(t, operatorv1.AddToScheme(scheme))
+			pp := NewPostPivot(scheme, &logrus.Logger{}, nil, "", manifestsDir, "")
+
+			err := pp.setServingCertificates(context.TODO(), &clusterconfig_api.SeedReconfiguration{
+				APINamedCertificates: tc.apiNamedCertificates,
+				IngressCertificate:   tc.ingressCertificate,
+			}, manifestsDir)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			if tc.expectedError {
+				return
+			}
+
+			apiServer := &v1.APIServer{}
+			err = utils.ReadYamlOrJSONFile(path.Join(manifestsDir, apiServerFileName), apiServer)
+			if len(tc.apiNamedCertificates) == 0 {
+				assert.True(t, os.IsNotExist(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "APIServer", apiServer.Kind)
+				assert.Equal(t, apiServerName, apiServer.Name)
+				assert.Equal(t, []v1.APIServerNamedServingCert{{
+					Names:              []string{"api.test.example.com"},
+					ServingCertificate: v1.SecretNameReference{Name: "api-named-certificate-0"},
+				}}, apiServer.Spec.ServingCerts.NamedCertificates)
+
+				secret := &corev1.Secret{}
+				assert.NoError(t, utils.ReadYamlOrJSONFile(path.Join(manifestsDir, "api-named-certificate-0.json"), secret))
+				assert.Equal(t, "openshift-config", secret.Namespace)
+				assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+				assert.Equal(t, string(apiCertificate.Certificate), string(secret.Data[corev1.TLSCertKey]))
+				assert.Equal(t, string(apiCertificate.Key), string(secret.Data[corev1.TLSPrivateKeyKey]))
+			}
+
+			ingressController := &operatorv1.IngressController{}
+			err = utils.ReadYamlOrJSONFile(path.Join(manifestsDir, ingressControllerFileName), ingressController)
+			if tc.ingressCertificate == nil {
+				assert.True(t, os.IsNotExist(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "IngressController", ingressController.Kind)
+				assert.Equal(t, ingressControllerNamespace, ingressController.Namespace)
+				assert.Equal(t, &corev1.LocalObjectReference{Name: ingressCertificateName}, ingressController.Spec.DefaultCertificate)
+
+				secret := &corev1.Secret{}
+				assert.NoError(t, utils.ReadYamlOrJSONFile(path.Join(manifestsDir, ingressCertificateName+".json"), secret))
+				assert.Equal(t, ingressCertificateNamespace, secret.Namespace)
+				assert.Equal(t, string(ingressCertificate.Certificate), string(secret.Data[corev1.TLSCertKey]))
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package postpivot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+const (
+	// IdentityRecordKey is the key of the identity record in the identity record configmap
+	IdentityRecordKey = "identity.json"
+	// IdentityRecordSignatureKey is the key of the hex encoded HMAC-SHA256 of the identity record
+	IdentityRecordSignatureKey = "identity.json.hmac-sha256"
+	// IdentityRecordSigningKey is the key of the identity record signing key in the identity record key secret
+	IdentityRecordSigningKey = "key"
+
+	identityRecordSigningKeySize = 32
+)
+
+// IdentityRecord maps the identifiers of the seed cluster to the ones of the cluster it was reconfigured into
+type IdentityRecord struct {
+	// The time the record was created at, once the cluster got its new identifiers
+	Timestamp time.Time `json:"timestamp"`
+	// The cluster ID of the seed cluster, empty for seed images created before it was recorded
+	SeedClusterID string `json:"seed_cluster_id,omitempty"`
+	// The cluster ID of the cluster, either the desired one or the one generated by LCA
+	ClusterID string `json:"cluster_id"`
+	// The infra ID of the cluster, as renamed by recert
+	InfraID  string   `json:"infra_id"`
+	Hostname string   `json:"hostname"`
+	NodeIPs  []string `json:"node_ips"`
+}
+
+// recordIdentity writes the identity record, along with its signature, into the identity record configmap of the LCA
+// namespace, so that telemetry and inventory tooling can map the seed cluster to the new one
+func (p *PostPivot) recordIdentity(ctx context.Context, client runtimeclient.Client,
+	seedReconfiguration *clusterconfig_api.SeedReconfiguration, seedClusterInfo *seedclusterinfo.SeedClusterInfo) error {
+	p.log.Info("Recording the cluster identity")
+	clusterVersion := &v1.ClusterVersion{}
+	if err := client.Get(ctx, types.NamespacedName{Name: "version"}, clusterVersion); err != nil {
+		return fmt.Errorf("failed to get clusterversion, err: %w", err)
+	}
+	infra, err := utils.GetInfrastructure(ctx, client)
+	if err != nil {
+		return fmt.Errorf("failed to get infrastructure, err: %w", err)
+	}
+
+	record := &IdentityRecord{
+		Timestamp:     time.Now().UTC(),
+		SeedClusterID: seedClusterInfo.ClusterID,
+		ClusterID:     string(clusterVersion.Spec.ClusterID),
+		InfraID:       infra.Status.InfrastructureName,
+		Hostname:      seedReconfiguration.Hostname,
+		NodeIPs:       utils.NodeIPsOrPrimary(seedReconfiguration.NodeIP, seedReconfiguration.NodeIPs),
+	}
+	if record.SeedClusterID == "" {
+		p.log.Warnf("The seed image has no seed cluster id, it is not recorded")
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal identity record, err: %w", err)
+	}
+	key, err := identityRecordSigningKey(ctx, client)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      common.IdentityRecordConfigMapName,
+		Namespace: common.LcaNamespace,
+	}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, client, cm, func() error {
+		cm.Data = map[string]string{
+			IdentityRecordKey:          string(data),
+			IdentityRecordSignatureKey: hex.EncodeToString(mac.Sum(nil)),
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to write identity record configmap %s/%s, err: %w",
+			common.LcaNamespace, common.IdentityRecordConfigMapName, err)
+	}
+	return nil
+}
+
+// identityRecordSigningKey generates the key the identity record is signed with. It is kept in a secret of the LCA
+// namespace, so only those allowed to read the secret can verify or forge a signature. The record is made on the first
+// boot of the cluster, so any key found then was inherited from the seed cluster, and is replaced so that the clusters
+// reconfigured from a seed image don't share the key.
+func identityRecordSigningKey(ctx context.Context, client runtimeclient.Client) ([]byte, error) {
+	key := make([]byte, identityRecordSigningKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate identity record signing key, err: %w", err)
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      common.IdentityRecordKeySecretName,
+		Namespace: common.LcaNamespace,
+	}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, client, secret, func() error {
+		secret.Data = map[string][]byte{IdentityRecordSigningKey: key}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write secret %s/%s, err: %w",
+			common.LcaNamespace, common.IdentityRecordKeySecretName, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,108 @@
+package postpivot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	v1 "github.com/openshift/api/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+)
+
+func TestRecordIdentity(t *testing.T) {
+	seedKey := []byte("0123456789abcdef0123456789abcdef")
+
+	testcases := []struct {
+		name                  string
+		seedClusterID         string
+		signingKey            []byte
+		expectedSeedClusterID string
+	}{
+		{
+			name:                  "Seed image with a cluster id",
+			seedClusterID:         "f0a3c9b2-3c0e-4b5a-8c1d-6f3a9e2b7d41",
+			expectedSeedClusterID: "f0a3c9b2-3c0e-4b5a-8c1d-6f3a9e2b7d41",
+		},
+		{
+			name: "Seed image without a cluster id",
+		},
+		{
+			name:                  "Signing key inherited from the seed cluster is replaced",
+			seedClusterID:         "f0a3c9b2-3c0e-4b5a-8c1d-6f3a9e2b7d41",
+			signingKey:            seedKey,
+			expectedSeedClusterID: "f0a3c9b2-3c0e-4b5a-8c1d-6f3a9e2b7d41",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+
+			scheme := runtime.NewScheme()
+			assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+			assert.NoError(t, v1.AddToScheme(scheme))
+			objects := []client.Object{
+				&v1.ClusterVersion{
+					ObjectMeta: metav1.ObjectMeta{Name: "version"},
+					Spec:       v1.ClusterVersionSpec{ClusterID: "5d4a5b1e-7f0c-4d4e-9d8b-2a3c1e6f9b10"},
+				},
+				&v1.Infrastructure{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+					Status:     v1.InfrastructureStatus{InfrastructureName: "sno-x7k2p"},
+				},
+			}
+			if tc.signingKey != nil {
+				objects = append(objects, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: common.IdentityRecordKeySecretName, Namespace: common.LcaNamespace},
+					Data: map[string][]byte{IdentityRecordSigningKey: tc.signingKey},
+				})
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+
+			pp := NewPostPivot(scheme, logrus.New(), nil, "", tmpDir, "")
+			assert.NoError(t, pp.recordIdentity(context.TODO(), c, &clusterconfig_api.SeedReconfiguration{
+				Hostname: "sno",
+				NodeIP:   "192.168.127.10",
+			}, &seedclusterinfo.SeedClusterInfo{ClusterID: tc.seedClusterID}))
+
+			secret := &corev1.Secret{}
+			assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{
+				Name: common.IdentityRecordKeySecretName, Namespace: common.LcaNamespace}, secret))
+			key := secret.Data[IdentityRecordSigningKey]
+			assert.Len(t, key, identityRecordSigningKeySize)
+			assert.NotEqual(t, seedKey, key)
+
+			cm := &corev1.ConfigMap{}
+			assert.NoError(t, c.Get(context.TODO(), types.NamespacedName{
+				Name: common.IdentityRecordConfigMapName, Namespace: common.LcaNamespace}, cm))
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(cm.Data[IdentityRecordKey]))
+			assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), cm.Data[IdentityRecordSignatureKey])
+
+			record := &IdentityRecord{}
+			assert.NoError(t, json.Unmarshal([]byte(cm.Data[IdentityRecordKey]), record))
+			assert.False(t, record.Timestamp.IsZero())
+			assert.Equal(t, tc.expectedSeedClusterID, record.SeedClusterID)
+			assert.Equal(t, "5d4a5b1e-7f0c-4d4e-9d8b-2a3c1e6f9b10", record.ClusterID)
+			assert.Equal(t, "sno-x7k2p", record.InfraID)
+			assert.Equal(t, "sno", record.Hostname)
+			assert.Equal(t, []string{"192.168.127.10"}, record.NodeIPs)
+		})
+	}
+}
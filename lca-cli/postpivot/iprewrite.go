@@ -0,0 +1,446 @@
+package postpivot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/tools/clientcmd"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// fileFormat is the format of a file the ips are rewritten in, it decides which parts of the file are rewritten and
+// how the result is validated
+type fileFormat int
+
+const (
+	formatText fileFormat = iota
+	formatEnv
+	formatINI
+	formatYAML
+	formatKubeconfig
+)
+
+func (f fileFormat) String() string {
+	switch f {
+	case formatEnv:
+		return "env"
+	case formatINI:
+		return "ini"
+	case formatYAML:
+		return "yaml"
+	case formatKubeconfig:
+		return "kubeconfig"
+	default:
+		return "text"
+	}
+}
+
+// detectFileFormat returns the format of a file based on its name and, for yaml and configuration files, its content
+func detectFileFormat(name string, content []byte) fileFormat {
+	base := filepath.Base(name)
+	switch {
+	case base == "kubeconfig" || strings.HasSuffix(base, ".kubeconfig"):
+		return formatKubeconfig
+	case strings.HasSuffix(base, ".env"):
+		return formatEnv
+	case strings.HasSuffix(base, ".yaml") || strings.HasSuffix(base, ".yml") || strings.HasSuffix(base, ".json"):
+		if bytes.Contains(content, []byte("kind: Config\n")) && bytes.Contains(content, []byte("clusters:")) {
+			return formatKubeconfig
+		}
+		return formatYAML
+	case strings.HasSuffix(base, ".conf") || strings.HasSuffix(base, ".ini") || strings.HasSuffix(base, ".cfg"):
+		// e.g. kubelet.conf is a yaml KubeletConfiguration while cloud.conf is an ini file
+		if isYAMLCollection(content) {
+			return formatYAML
+		}
+		return formatINI
+	default:
+		return formatText
+	}
+}
+
+// isYAMLCollection returns whether the content is a yaml, or json, document holding a mapping or a sequence
+func isYAMLCollection(content []byte) bool {
+	nodes, err := yamlNodes(string(content))
+	if err != nil || len(nodes) == 0 {
+		return false
+	}
+	return nodes[0].Kind == yaml.MappingNode || nodes[0].Kind == yaml.SequenceNode
+}
+
+// rewriteIPs replaces the ips in all the regular files under dir, logging a diff of every file changed.
+// Binary files and symlinks are left untouched.
+func (p *PostPivot) rewriteIPs(dir string, ipReplacements []utils.IPReplacement) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if bytes.IndexByte(content, 0) != -1 {
+			return nil
+		}
+
+		format := detectFileFormat(path, content)
+		rewritten, err := rewriteIPsInContent(format, string(content), ipReplacements)
+		if err != nil {
+			return fmt.Errorf("failed to rewrite ips in %s %s: %w", format, path, err)
+		}
+		if rewritten == string(content) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if err := os.WriteFile(path, []byte(rewritten), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		p.log.Infof("Rewrote ips in %s:\n%s", path, lineDiff(string(content), rewritten))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rewrite ips in %s: %w", dir, err)
+	}
+	return nil
+}
+
+// rewriteIPsInContent replaces the ips in the content of a file of the given format. Yaml, json and kubeconfig files
+// are parsed and only their scalar values are rewritten, in place so that the rest of the file is kept as is. Only
+// the values of env and ini files are rewritten, leaving out keys, sections and comments. Within a value, or a line
+// of a text file, only the parts that parse as one of the ips or cidrs are replaced, see replaceIPs.
+func rewriteIPsInContent(format fileFormat, content string, ipReplacements []utils.IPReplacement) (string, error) {
+	var rewritten string
+	switch format {
+	case formatYAML, formatKubeconfig:
+		rewritten = rewriteYAMLValues(content, ipReplacements)
+	case formatEnv, formatINI:
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "[") {
+				continue
+			}
+			key, value, found := strings.Cut(line, "=")
+			if !found {
+				continue
+			}
+			lines[i] = key + "=" + replaceIPs(value, ipReplacements)
+		}
+		rewritten = strings.Join(lines, "\n")
+	default:
+		rewritten = replaceIPs(content, ipReplacements)
+	}
+
+	if rewritten == content {
+		return content, nil
+	}
+
+	switch format {
+	case formatYAML:
+		var before, after any
+		if k8syaml.Unmarshal([]byte(content), &before) == nil {
+			if err := k8syaml.Unmarshal([]byte(rewritten), &after); err != nil {
+				return "", fmt.Errorf("rewritten content is no longer valid: %w", err)
+			}
+		}
+	case formatKubeconfig:
+		if _, err := clientcmd.Load([]byte(content)); err == nil {
+			if _, err := clientcmd.Load([]byte(rewritten)); err != nil {
+				return "", fmt.Errorf("rewritten content is no longer valid: %w", err)
+			}
+		}
+	}
+	return rewritten, nil
+}
+
+// rewriteYAMLValues replaces the ips in the scalar values, and keys, of a yaml or json content. The values are
+// located in the content from their position in the parsed documents and rewritten in place, so the formatting,
+// quoting and comments of the file are kept. Content that doesn't parse is rewritten as text.
+func rewriteYAMLValues(content string, ipReplacements []utils.IPReplacement) string {
+	nodes, err := yamlNodes(content)
+	if err != nil {
+		return replaceIPs(content, ipReplacements)
+	}
+
+	lineStarts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	offset := func(n *yaml.Node) int {
+		if n.Line-1 >= len(lineStarts) {
+			return len(content)
+		}
+		o := lineStarts[n.Line-1]
+		for col := 1; col < n.Column && o < len(content) && content[o] != '\n'; col++ {
+			_, size := utf8.DecodeRuneInString(content[o:])
+			o += size
+		}
+		return o
+	}
+
+	starts := make([]int, len(nodes))
+	for i, n := range nodes {
+		starts[i] = offset(n)
+	}
+	sortedStarts := append([]int{}, starts...)
+	sort.Ints(sortedStarts)
+	// nextStart is where the node following the one starting at start begins, which bounds the spans of the values
+	// that can't be delimited on their own, e.g. block scalars
+	nextStart := func(start int) int {
+		i := sort.SearchInts(sortedStarts, start+1)
+		if i == len(sortedStarts) {
+			return len(content)
+		}
+		return sortedStarts[i]
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	for i, n := range nodes {
+		if n.Kind != yaml.ScalarNode {
+			continue
+		}
+		start := starts[i]
+		end := -1
+		switch {
+		case n.Style&yaml.DoubleQuotedStyle != 0:
+			end = quotedScalarEnd(content, start, '"')
+		case n.Style&yaml.SingleQuotedStyle != 0:
+			end = quotedScalarEnd(content, start, '\'')
+		case n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		default:
+			if strings.HasPrefix(content[start:], n.Value) {
+				end = start + len(n.Value)
+			}
+		}
+		if end == -1 {
+			// Block scalars, multi-line or tagged values span up to the next node
+			end = nextStart(start)
+		}
+		spans = append(spans, span{start: start, end: end})
+	}
+
+	var b strings.Builder
+	last := 0
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	for _, s := range spans {
+		if s.start < last {
+			continue
+		}
+		b.WriteString(content[last:s.start])
+		b.WriteString(replaceIPs(content[s.start:s.end], ipReplacements))
+		last = s.end
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// yamlNodes returns all the nodes of the yaml documents in content, in document order
+func yamlNodes(content string) ([]*yaml.Node, error) {
+	var nodes []*yaml.Node
+	var collect func(n *yaml.Node)
+	collect = func(n *yaml.Node) {
+		if n.Kind != yaml.DocumentNode {
+			nodes = append(nodes, n)
+		}
+		for _, child := range n.Content {
+			collect(child)
+		}
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		doc := &yaml.Node{}
+		if err := decoder.Decode(doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nodes, nil
+			}
+			return nil, fmt.Errorf("failed to parse yaml: %w", err)
+		}
+		collect(doc)
+	}
+}
+
+// quotedScalarEnd returns the offset right after the closing quote of the quoted scalar starting at start, or -1
+func quotedScalarEnd(content string, start int, quote byte) int {
+	if start >= len(content) || content[start] != quote {
+		return -1
+	}
+	for i := start + 1; i < len(content); i++ {
+		switch {
+		case quote == '"' && content[i] == '\\':
+			i++
+		case quote == '\'' && content[i] == '\'' && i+1 < len(content) && content[i+1] == '\'':
+			i++
+		case content[i] == quote:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// replaceIPs replaces the ips, or cidrs, in s with the to ones in a single pass, so that the result of a replacement
+// is never replaced again, e.g. when swapping two ips. s is split into words at whitespaces, quotes, commas, equal
+// signs and the like, and only the words that parse as one of the from ips or cidrs are replaced, along with the ip
+// host of the words that parse as an address with a port, e.g. [fd00::1]:6443, or as a url. The ips are compared once
+// parsed, so 10.0.0.1 is never replaced in 10.0.0.12 and ipv6 addresses match whatever their notation.
+func replaceIPs(s string, ipReplacements []utils.IPReplacement) string {
+	replacements := map[string]string{}
+	for _, r := range ipReplacements {
+		if from := canonicalIPValue(r.From); from != "" && r.From != r.To {
+			replacements[from] = r.To
+		}
+	}
+	if len(replacements) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	wordStart := 0
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if isWordSeparator(c) {
+			b.WriteString(replaceIPWord(s[wordStart:i], replacements))
+			b.WriteString(s[i : i+size])
+			wordStart = i + size
+		}
+		i += size
+	}
+	b.WriteString(replaceIPWord(s[wordStart:], replacements))
+	return b.String()
+}
+
+func isWordSeparator(c rune) bool {
+	return unicode.IsSpace(c) || strings.ContainsRune(",;=\"'(){}<>|`", c)
+}
+
+// replaceIPWord returns the word with its ip replaced, if it is one of the from ips or cidrs, an address with a port
+// or a url with one of the from ips as host
+func replaceIPWord(word string, replacements map[string]string) string {
+	if word == "" {
+		return word
+	}
+	if to, ok := replaceAddress(word, replacements); ok {
+		return to
+	}
+
+	if i := strings.Index(word, "://"); i != -1 {
+		rest := word[i+len("://"):]
+		authorityEnd := strings.IndexAny(rest, "/?#")
+		if authorityEnd == -1 {
+			authorityEnd = len(rest)
+		}
+		userInfo, hostPort := "", rest[:authorityEnd]
+		if at := strings.LastIndex(hostPort, "@"); at != -1 {
+			userInfo, hostPort = hostPort[:at+1], hostPort[at+1:]
+		}
+		if to, ok := replaceAddress(hostPort, replacements); ok {
+			return word[:i+len("://")] + userInfo + to + rest[authorityEnd:]
+		}
+		return word
+	}
+
+	// An address with a prefix length, e.g. 10.0.0.1/24, when only the address is replaced
+	if ip, prefixLength, found := strings.Cut(word, "/"); found && isNumber(prefixLength) {
+		if to, ok := replacements[canonicalIPValue(ip)]; ok && net.ParseIP(to) != nil {
+			return to + "/" + prefixLength
+		}
+	}
+	return word
+}
+
+// replaceAddress returns the replacement of an ip or cidr, of a bracketed ipv6 address or of an address with a port
+func replaceAddress(address string, replacements map[string]string) (string, bool) {
+	if to, ok := replacements[canonicalIPValue(address)]; ok {
+		return to, true
+	}
+	if inner, found := strings.CutPrefix(address, "["); found {
+		if inner, found := strings.CutSuffix(inner, "]"); found {
+			if to, ok := replacements[canonicalIPValue(inner)]; ok && net.ParseIP(to) != nil {
+				return "[" + to + "]", true
+			}
+		}
+	}
+	if host, port, err := net.SplitHostPort(address); err == nil && isNumber(port) {
+		if to, ok := replacements[canonicalIPValue(host)]; ok && net.ParseIP(to) != nil {
+			return net.JoinHostPort(to, port), true
+		}
+	}
+	return "", false
+}
+
+// canonicalIPValue returns the canonical form of an ip, a cidr or a cidr with a host prefix as used by
+// OVN-Kubernetes, e.g. 10.128.0.0/14/23, or an empty string if value is none of them
+func canonicalIPValue(value string) string {
+	parts := strings.Split(value, "/")
+	if len(parts) > 3 {
+		return ""
+	}
+	ip := net.ParseIP(parts[0])
+	if ip == nil {
+		return ""
+	}
+	canonical := ip.String()
+	for _, part := range parts[1:] {
+		if !isNumber(part) {
+			return ""
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return ""
+		}
+		canonical = fmt.Sprintf("%s/%d", canonical, n)
+	}
+	return canonical
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// lineDiff returns the lines that differ between before and after, which must have the same number of lines
+func lineDiff(before, after string) string {
+	beforeLines := strings.Split(before, "\n")
+	afterLines := strings.Split(after, "\n")
+
+	var diff []string
+	for i := range beforeLines {
+		if i >= len(afterLines) || beforeLines[i] == afterLines[i] {
+			continue
+		}
+		diff = append(diff, fmt.Sprintf("@@ line %d\n-%s\n+%s", i+1, beforeLines[i], afterLines[i]))
+	}
+	return strings.Join(diff, "\n")
+}
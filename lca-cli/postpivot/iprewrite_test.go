@@ -0,0 +1,296 @@
+package postpivot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+func TestReplaceIPsBoundaries(t *testing.T) {
+	testcases := []struct {
+		name     string
+		content  string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name:     "ipv4 in url",
+			content:  "server: https://10.0.0.1:6443",
+			from:     "10.0.0.1",
+			to:       "192.168.1.5",
+			expected: "server: https://192.168.1.5:6443",
+		},
+		{
+			name:     "ipv4 prefix of a longer address is kept",
+			content:  "10.0.0.12,10.0.0.1,110.0.0.1,10.0.0.1.5",
+			from:     "10.0.0.1",
+			to:       "192.168.1.5",
+			expected: "10.0.0.12,192.168.1.5,110.0.0.1,10.0.0.1.5",
+		},
+		{
+			name:     "ipv4 followed by a dot is not an ip",
+			content:  "listening on 10.0.0.1.",
+			from:     "10.0.0.1",
+			to:       "192.168.1.5",
+			expected: "listening on 10.0.0.1.",
+		},
+		{
+			name:     "ipv4 with a port, a prefix length or in a flag",
+			content:  "10.0.0.1:2379 10.0.0.1/24 --advertise-address=10.0.0.1 sno=https://10.0.0.1:2380",
+			from:     "10.0.0.1",
+			to:       "192.168.1.5",
+			expected: "192.168.1.5:2379 192.168.1.5/24 --advertise-address=192.168.1.5 sno=https://192.168.1.5:2380",
+		},
+		{
+			name:     "ipv4 in a url with user info and a path",
+			content:  "https://user@10.0.0.1/healthz https://10.0.0.1.example.com",
+			from:     "10.0.0.1",
+			to:       "192.168.1.5",
+			expected: "https://user@192.168.1.5/healthz https://10.0.0.1.example.com",
+		},
+		{
+			name:     "ipv6 keeps brackets",
+			content:  "https://[fd00::1]:6443 fd00::1",
+			from:     "fd00::1",
+			to:       "fd01::5",
+			expected: "https://[fd01::5]:6443 fd01::5",
+		},
+		{
+			name:     "ipv6 in another notation",
+			content:  "fd00:0:0::1 [FD00::1]:6443",
+			from:     "fd00::1",
+			to:       "fd01::5",
+			expected: "fd01::5 [fd01::5]:6443",
+		},
+		{
+			name:     "ipv6 prefix of a longer address is kept",
+			content:  "fd00::10 fd00::1:2 fd00::1a afd00::1",
+			from:     "fd00::1",
+			to:       "fd01::5",
+			expected: "fd00::10 fd00::1:2 fd00::1a afd00::1",
+		},
+		{
+			name:     "same ip",
+			content:  "10.0.0.1",
+			from:     "10.0.0.1",
+			to:       "10.0.0.1",
+			expected: "10.0.0.1",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, replaceIPs(tc.content, []utils.IPReplacement{{From: tc.from, To: tc.to}}))
+		})
+	}
+}
+
+func TestReplaceIPs(t *testing.T) {
+	testcases := []struct {
+		name           string
+		content        string
+		ipReplacements []utils.IPReplacement
+		expected       string
+	}{
+		{
+			name:    "replaced ips are not replaced again",
+			content: "10.0.0.1 10.0.0.2",
+			ipReplacements: []utils.IPReplacement{
+				{From: "10.0.0.1", To: "10.0.0.2"},
+				{From: "10.0.0.2", To: "10.0.0.3"},
+			},
+			expected: "10.0.0.2 10.0.0.3",
+		},
+		{
+			name:    "swapped ips",
+			content: "https://[fd00::1]:6443,https://[fd00::2]:6443",
+			ipReplacements: []utils.IPReplacement{
+				{From: "fd00::1", To: "fd00::2"},
+				{From: "fd00::2", To: "fd00::1"},
+			},
+			expected: "https://[fd00::2]:6443,https://[fd00::1]:6443",
+		},
+		{
+			name:    "cidrs with and without host prefix and network address",
+			content: "cluster-subnets = \"10.128.0.0/14/23\" cidr=10.128.0.0/14 network=10.128.0.0",
+			ipReplacements: []utils.IPReplacement{
+				{From: "10.128.0.0/14", To: "10.132.0.0/14"},
+				{From: "10.128.0.0/14/23", To: "10.132.0.0/14/24"},
+				{From: "10.128.0.0", To: "10.132.0.0"},
+			},
+			expected: "cluster-subnets = \"10.132.0.0/14/24\" cidr=10.132.0.0/14 network=10.132.0.0",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, replaceIPs(tc.content, tc.ipReplacements))
+		})
+	}
+}
+
+func TestDetectFileFormat(t *testing.T) {
+	assert.Equal(t, formatKubeconfig, detectFileFormat("/etc/kubernetes/kubeconfig", nil))
+	assert.Equal(t, formatKubeconfig, detectFileFormat("/etc/kubernetes/static-pod-resources/kube-apiserver-certs/secrets/node-kubeconfigs/lb-ext.kubeconfig", nil))
+	assert.Equal(t, formatKubeconfig, detectFileFormat("/etc/kubernetes/kubeconfig.yaml", []byte("apiVersion: v1\nclusters:\nkind: Config\n")))
+	assert.Equal(t, formatYAML, detectFileFormat("/etc/kubernetes/manifests/etcd-pod.yaml", []byte("apiVersion: v1\nkind: Pod\n")))
+	assert.Equal(t, formatEnv, detectFileFormat("/etc/kubernetes/apiserver-url.env", nil))
+	assert.Equal(t, formatYAML, detectFileFormat("/etc/kubernetes/kubelet.conf", []byte("kind: KubeletConfiguration\naddress: 0.0.0.0\n")))
+	assert.Equal(t, formatINI, detectFileFormat("/etc/kubernetes/cloud.conf", []byte("[Global]\nsecret-name = vsphere-creds\n")))
+	assert.Equal(t, formatText, detectFileFormat("/etc/kubernetes/static-pod-resources/etcd-pod-3/configmaps/etcd-endpoints/1234", nil))
+}
+
+func TestRewriteIPsInContent(t *testing.T) {
+	ipReplacements := []utils.IPReplacement{
+		{From: "10.0.0.1", To: "192.168.1.5"},
+		{From: "fd00::1", To: "fd01::5"},
+	}
+
+	testcases := []struct {
+		name          string
+		format        fileFormat
+		content       string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "env file values only",
+			format:   formatEnv,
+			content:  "# 10.0.0.1\nKUBERNETES_SERVICE_HOST=10.0.0.1\nIPV6=[fd00::1]\n",
+			expected: "# 10.0.0.1\nKUBERNETES_SERVICE_HOST=192.168.1.5\nIPV6=[fd01::5]\n",
+		},
+		{
+			name:     "ini file values only",
+			format:   formatINI,
+			content:  "[Global]\n; 10.0.0.1\nserver = 10.0.0.1\nserver-10.0.0.1 = \"[fd00::1]:6443\"\n",
+			expected: "[Global]\n; 10.0.0.1\nserver = 192.168.1.5\nserver-10.0.0.1 = \"[fd01::5]:6443\"\n",
+		},
+		{
+			name:     "yaml comments are kept",
+			format:   formatYAML,
+			content:  "# seed ip 10.0.0.1\naddress: 10.0.0.1 # 10.0.0.1\nquoted: \"10.0.0.1\"\nsingle: '10.0.0.1'\n",
+			expected: "# seed ip 10.0.0.1\naddress: 192.168.1.5 # 10.0.0.1\nquoted: \"192.168.1.5\"\nsingle: '192.168.1.5'\n",
+		},
+		{
+			name:     "json on a single line",
+			format:   formatYAML,
+			content:  `{"ip":"10.0.0.1","peers":["10.0.0.12","https://[fd00::1]:2380"],"10.0.0.1":"key"}`,
+			expected: `{"ip":"192.168.1.5","peers":["10.0.0.12","https://[fd01::5]:2380"],"192.168.1.5":"key"}`,
+		},
+		{
+			name:   "literal block script and several documents",
+			format: formatYAML,
+			content: `command:
+- /bin/bash
+- -c
+- |
+  exec etcd \
+    --listen-peer-urls=https://10.0.0.1:2380 \
+    --peer=10.0.0.12
+name: etcd
+---
+ip: 10.0.0.1
+`,
+			expected: `command:
+- /bin/bash
+- -c
+- |
+  exec etcd \
+    --listen-peer-urls=https://192.168.1.5:2380 \
+    --peer=10.0.0.12
+name: etcd
+---
+ip: 192.168.1.5
+`,
+		},
+		{
+			name:     "yaml that does not parse is rewritten as text",
+			format:   formatYAML,
+			content:  "{{ .Template }}\naddress: 10.0.0.1\n",
+			expected: "{{ .Template }}\naddress: 192.168.1.5\n",
+		},
+		{
+			name:     "static pod yaml",
+			format:   formatYAML,
+			content:  "spec:\n  containers:\n  - args:\n    - --advertise-address=10.0.0.1\n    - --peer=10.0.0.12\n",
+			expected: "spec:\n  containers:\n  - args:\n    - --advertise-address=192.168.1.5\n    - --peer=10.0.0.12\n",
+		},
+		{
+			name:          "kubeconfig that did not load is still rewritten",
+			format:        formatKubeconfig,
+			content:       "server: https://10.0.0.1:6443\n",
+			expected:      "server: https://192.168.1.5:6443\n",
+			expectedError: false,
+		},
+		{
+			name:   "kubeconfig",
+			format: formatKubeconfig,
+			content: `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://[fd00::1]:6443
+  name: cluster
+`,
+			expected: `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://[fd01::5]:6443
+  name: cluster
+`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rewritten, err := rewriteIPsInContent(tc.format, tc.content, ipReplacements)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			assert.Equal(t, tc.expected, rewritten)
+		})
+	}
+}
+
+func TestRewriteIPs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"manifests/kube-apiserver-pod.yaml": "args:\n- --advertise-address=10.0.0.1\n",
+		"apiserver-url.env":                 "KUBERNETES_SERVICE_HOST=10.0.0.1\n",
+		"unchanged.conf":                    "10.0.0.12\n",
+		"binary":                            "10.0.0.1\x00",
+	}
+	for name, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o640))
+	}
+
+	pp := NewPostPivot(nil, &logrus.Logger{}, nil, "", "", "")
+	assert.NoError(t, pp.rewriteIPs(dir, []utils.IPReplacement{{From: "10.0.0.1", To: "192.168.1.5"}}))
+
+	expected := map[string]string{
+		"manifests/kube-apiserver-pod.yaml": "args:\n- --advertise-address=192.168.1.5\n",
+		"apiserver-url.env":                 "KUBERNETES_SERVICE_HOST=192.168.1.5\n",
+		"unchanged.conf":                    "10.0.0.12\n",
+		"binary":                            "10.0.0.1\x00",
+	}
+	for name, content := range expected {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(b), name)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "apiserver-url.env"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+}
+
+func TestLineDiff(t *testing.T) {
+	assert.Equal(t, "@@ line 2\n-b: 10.0.0.1\n+b: 192.168.1.5",
+		lineDiff("a: 1\nb: 10.0.0.1\n", "a: 1\nb: 192.168.1.5\n"))
+}
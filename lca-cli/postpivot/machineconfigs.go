@@ -0,0 +1,118 @@
+package postpivot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+const (
+	// machineConfigFileName is the name of the manifest of the nth user provided MachineConfig
+	machineConfigFileName = "99-lca-machine-config-%d.yaml"
+	// kernelArgumentsMachineConfig is the MachineConfig holding the kernel arguments of the seed reconfiguration
+	kernelArgumentsMachineConfig = "99-master-lca-kernel-arguments"
+
+	masterPool = "master"
+)
+
+// kernelCmdlineFile is where the kernel arguments of the running kernel are read from
+var kernelCmdlineFile = "/proc/cmdline"
+
+// setMachineConfigs writes the MachineConfigs provided by the user, and a MachineConfig holding the kernel arguments
+// of the seed reconfiguration, into the manifests folder, to be applied when the cluster is up. The kernel arguments
+// are expected to have been added when the stateroot was set up, as they can't be added to the running kernel, the
+// missing ones are reported.
+func (p *PostPivot) setMachineConfigs(ctx context.Context, seedReconfiguration *clusterconfig_api.SeedReconfiguration, manifestsDir string) error {
+	for i, machineConfig := range seedReconfiguration.MachineConfigs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		manifest := path.Join(manifestsDir, fmt.Sprintf(machineConfigFileName, i))
+		p.log.Infof("Creating machine config manifest %s", manifest)
+		if err := os.WriteFile(manifest, []byte(machineConfig), 0o600); err != nil {
+			return fmt.Errorf("failed to write machine config manifest %s, err: %w", manifest, err)
+		}
+	}
+
+	if len(seedReconfiguration.KernelArguments) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := p.createKernelArgumentsMachineConfig(seedReconfiguration.KernelArguments, manifestsDir); err != nil {
+			return err
+		}
+	}
+
+	kargs, err := utils.KernelArguments(seedReconfiguration.KernelArguments, seedReconfiguration.MachineConfigs)
+	if err != nil {
+		return fmt.Errorf("failed to get kernel arguments from seed reconfiguration, err: %w", err)
+	}
+	if len(kargs) == 0 {
+		return nil
+	}
+	running, err := runningKernelArguments()
+	if err != nil {
+		return err
+	}
+	for _, karg := range kargs {
+		if !containsKernelArgument(running, karg) {
+			p.log.Warnf("Kernel argument %s is not set on the running kernel, it was not provided when the "+
+				"stateroot was set up", karg)
+		}
+	}
+	return nil
+}
+
+// createKernelArgumentsMachineConfig creates the master MachineConfig of the given kernel arguments, so that the
+// machine-config-operator keeps them. As for any MachineConfig, the machine-config-daemon reboots the node to roll it
+// out, even though the running kernel already has these kernel arguments.
+func (p *PostPivot) createKernelArgumentsMachineConfig(kargs []string, manifestsDir string) error {
+	p.log.Info("Creating master machine config with the provided kernel arguments")
+	mc := &mcfgv1.MachineConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: mcfgv1.SchemeGroupVersion.String(),
+			Kind:       "MachineConfig",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: kernelArgumentsMachineConfig,
+			Labels: map[string]string{
+				"machineconfiguration.openshift.io/role": masterPool,
+			},
+		},
+		Spec: mcfgv1.MachineConfigSpec{
+			KernelArguments: kargs,
+		},
+	}
+	if err := utils.MarshalToFile(mc, path.Join(manifestsDir, kernelArgumentsMachineConfig+".json")); err != nil {
+		return fmt.Errorf("failed to marshal kernel arguments into file, err: %w", err)
+	}
+	return nil
+}
+
+// runningKernelArguments returns the kernel arguments of the running kernel
+func runningKernelArguments() ([]string, error) {
+	cmdline, err := os.ReadFile(kernelCmdlineFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s, err: %w", kernelCmdlineFile, err)
+	}
+	return strings.Fields(string(cmdline)), nil
+}
+
+// containsKernelArgument returns whether the kernel argument is among the running ones, the quotes added when
+// deploying the stateroot being removed by the kernel
+func containsKernelArgument(running []string, karg string) bool {
+	for _, arg := range running {
+		if arg == karg || strings.ReplaceAll(arg, `"`, "") == strings.ReplaceAll(karg, `"`, "") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package postpivot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+func TestSetMachineConfigs(t *testing.T) {
+	defer func(cmdline string) { kernelCmdlineFile = cmdline }(kernelCmdlineFile)
+
+	machineConfig := `apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfig
+metadata:
+  name: 99-master-kargs
+  labels:
+    machineconfiguration.openshift.io/role: master
+spec:
+  kernelArguments:
+  - hugepages=16
+`
+
+	testcases := []struct {
+		name           string
+		machineConfigs []string
+		kargs          []string
+		cmdline        string
+	}{
+		{
+			name: "Nothing provided",
+		},
+		{
+			name:           "Kernel arguments set on the running kernel",
+			machineConfigs: []string{machineConfig},
+			kargs:          []string{"isolcpus=2-3"},
+			cmdline:        "BOOT_IMAGE=(hd0,gpt3)/ostree/vmlinuz isolcpus=2-3 hugepages=16\n",
+		},
+		{
+			name:    "Kernel arguments missing from the running kernel",
+			kargs:   []string{"isolcpus=2-3"},
+			cmdline: "BOOT_IMAGE=(hd0,gpt3)/ostree/vmlinuz\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			manifestsDir := path.Join(tmpDir, "manifests")
+			assert.NoError(t, os.MkdirAll(manifestsDir, 0o700))
+			kernelCmdlineFile = path.Join(tmpDir, "cmdline")
+			assert.NoError(t, os.WriteFile(kernelCmdlineFile, []byte(tc.cmdline), 0o600))
+
+			pp := NewPostPivot(nil, &logrus.Logger{}, nil, "", tmpDir, "")
+			assert.NoError(t, pp.setMachineConfigs(context.TODO(), &clusterconfig_api.SeedReconfiguration{
+				KernelArguments: tc.kargs,
+				MachineConfigs:  tc.machineConfigs,
+			}, manifestsDir))
+
+			manifests, err := os.ReadDir(manifestsDir)
+			assert.NoError(t, err)
+			expectedManifests := len(tc.machineConfigs)
+			if len(tc.kargs) > 0 {
+				expectedManifests++
+				mc := &mcfgv1.MachineConfig{}
+				assert.NoError(t, utils.ReadYamlOrJSONFile(path.Join(manifestsDir, kernelArgumentsMachineConfig+".json"), mc))
+				assert.Equal(t, tc.kargs, mc.Spec.KernelArguments)
+				assert.Equal(t, "master", mc.Labels["machineconfiguration.openshift.io/role"])
+			}
+			assert.Len(t, manifests, expectedManifests)
+			for i, machineConfig := range tc.machineConfigs {
+				manifest, err := os.ReadFile(path.Join(manifestsDir, fmt.Sprintf(machineConfigFileName, i)))
+				assert.NoError(t, err)
+				assert.Equal(t, machineConfig, string(manifest))
+			}
+		})
+	}
+}
+
+func TestContainsKernelArgument(t *testing.T) {
+	running := []string{"ip=dhcp", `foo="bar"`}
+	assert.True(t, containsKernelArgument(running, "ip=dhcp"))
+	assert.True(t, containsKernelArgument(running, "foo=bar"))
+	assert.False(t, containsKernelArgument(running, "nosmt"))
+}
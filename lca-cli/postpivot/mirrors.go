@@ -0,0 +1,187 @@
+package postpivot
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	v1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+var (
+	imageDigestSourcesDropInFile = "/etc/containers/registries.conf.d/98-lca-image-digest-sources.conf"
+	registriesConfDropInFile     = "/etc/containers/registries.conf.d/99-lca-seed-reconfiguration.conf"
+)
+
+const mirrorRegistriesMachineConfig = "99-%s-lca-registries"
+
+// setMirrorRegistries configures the mirror registries provided by the user, so that images are pulled from them
+// starting from the first kubelet start. The image digest sources are rendered as registries.conf drop-in, leaving the
+// seed registries.conf untouched, and into an ImageDigestMirrorSet manifest to be applied when the cluster is up,
+// which replaces the one created by the installer of the seed cluster. The registries.conf snippet is written as a
+// drop-in taking precedence over both. The drop-ins are also rendered into MachineConfigs, so that they are owned by the
+// machine-config-operator instead of being left on the node as unmanaged files.
+func (p *PostPivot) setMirrorRegistries(ctx context.Context, seedReconfiguration *clusterconfig_api.SeedReconfiguration, manifestsDir string) error {
+	if len(seedReconfiguration.ImageDigestSources) == 0 && seedReconfiguration.RegistriesConf == "" {
+		p.log.Infof("No mirror registries configuration was provided, skipping")
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dropIns := map[string]string{}
+	if len(seedReconfiguration.ImageDigestSources) > 0 {
+		registriesConf, err := renderRegistriesConf(seedReconfiguration.ImageDigestSources)
+		if err != nil {
+			return err
+		}
+		p.log.Infof("Writing image digest sources to %s", imageDigestSourcesDropInFile)
+		if err := writeRegistriesConfDropIn(imageDigestSourcesDropInFile, registriesConf); err != nil {
+			return err
+		}
+		dropIns[imageDigestSourcesDropInFile] = registriesConf
+
+		if err := p.createIDMSManifest(seedReconfiguration.ImageDigestSources, path.Join(manifestsDir, common.IDMSFileName)); err != nil {
+			return err
+		}
+	}
+
+	if seedReconfiguration.RegistriesConf != "" {
+		p.log.Infof("Writing registries.conf snippet to %s", registriesConfDropInFile)
+		if err := writeRegistriesConfDropIn(registriesConfDropInFile, seedReconfiguration.RegistriesConf); err != nil {
+			return err
+		}
+		dropIns[registriesConfDropInFile] = seedReconfiguration.RegistriesConf
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.createMirrorRegistriesMachineConfigs(dropIns, manifestsDir)
+}
+
+// createMirrorRegistriesMachineConfigs creates the worker and master MachineConfigs of the registries.conf drop-ins
+func (p *PostPivot) createMirrorRegistriesMachineConfigs(dropIns map[string]string, manifestsDir string) error {
+	p.log.Info("Creating worker and master machine configs with the registries.conf drop-ins")
+	files := make([]any, 0, len(dropIns))
+	for _, file := range []string{imageDigestSourcesDropInFile, registriesConfDropInFile} {
+		content, ok := dropIns[file]
+		if !ok {
+			continue
+		}
+		files = append(files, map[string]any{
+			"path":      file,
+			"mode":      0o644,
+			"overwrite": true,
+			"contents": map[string]string{
+				"source": "data:text/plain;charset=utf-8;base64," + base64.StdEncoding.EncodeToString([]byte(content)),
+			},
+		})
+	}
+	rawExt, err := utils.ConvertToRawExtension(map[string]any{
+		"ignition": map[string]string{"version": "3.2.0"},
+		"storage":  map[string]any{"files": files},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, role := range []string{"master", "worker"} {
+		mc := &mcfgv1.MachineConfig{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: mcfgv1.SchemeGroupVersion.String(),
+				Kind:       "MachineConfig",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf(mirrorRegistriesMachineConfig, role),
+				Labels: map[string]string{
+					"machineconfiguration.openshift.io/role": role,
+				},
+			},
+			Spec: mcfgv1.MachineConfigSpec{
+				Config: rawExt,
+			},
+		}
+		if err := utils.MarshalToFile(mc, path.Join(manifestsDir, fmt.Sprintf(mirrorRegistriesMachineConfig, role)+".json")); err != nil {
+			return fmt.Errorf("failed to marshal registries.conf drop-ins into file for role %s, err: %w", role, err)
+		}
+	}
+	return nil
+}
+
+func writeRegistriesConfDropIn(file, content string) error {
+	if err := os.MkdirAll(path.Dir(file), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s, err: %w", path.Dir(file), err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s, err: %w", file, err)
+	}
+	return nil
+}
+
+// renderRegistriesConf renders the registry entries of the image digest sources in the registries.conf format of the
+// machine-config-operator, images being pulled from the mirrors by digest only. Only the registry entries are
+// rendered, the global settings being kept from the seed registries.conf.
+func renderRegistriesConf(imageDigestSources []clusterconfig_api.ImageDigestSource) (string, error) {
+	var b strings.Builder
+	for i, source := range imageDigestSources {
+		if source.Source == "" || len(source.Mirrors) == 0 {
+			return "", fmt.Errorf("image digest source %q must have a source and at least one mirror", source.Source)
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[[registry]]\n  prefix = \"\"\n  location = %q\n", source.Source)
+		for _, mirror := range source.Mirrors {
+			fmt.Fprintf(&b, "\n  [[registry.mirror]]\n    location = %q\n    pull-from-mirror = \"digest-only\"\n", mirror)
+		}
+	}
+	return b.String(), nil
+}
+
+// createIDMSManifest creates the ImageDigestMirrorSet of the image digest sources in the manifests folder
+func (p *PostPivot) createIDMSManifest(imageDigestSources []clusterconfig_api.ImageDigestSource, manifest string) error {
+	p.log.Infof("Creating image digest mirror set manifest %s", manifest)
+	idms := v1.ImageDigestMirrorSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: common.InstallerIDMSName,
+		},
+	}
+	for _, source := range imageDigestSources {
+		mirrors := make([]v1.ImageMirror, 0, len(source.Mirrors))
+		for _, mirror := range source.Mirrors {
+			mirrors = append(mirrors, v1.ImageMirror(mirror))
+		}
+		idms.Spec.ImageDigestMirrors = append(idms.Spec.ImageDigestMirrors, v1.ImageDigestMirrors{
+			Source:  source.Source,
+			Mirrors: mirrors,
+		})
+	}
+	typeMeta, err := utils.TypeMetaForObject(p.scheme, &idms)
+	if err != nil {
+		return fmt.Errorf("failed to create typeMeta for image digest mirror set, err: %w", err)
+	}
+	idms.TypeMeta = *typeMeta
+
+	idmsList := v1.ImageDigestMirrorSetList{Items: []v1.ImageDigestMirrorSet{idms}}
+	typeMeta, err = utils.TypeMetaForObject(p.scheme, &idmsList)
+	if err != nil {
+		return fmt.Errorf("failed to create typeMeta for image digest mirror set list, err: %w", err)
+	}
+	idmsList.TypeMeta = *typeMeta
+
+	if err := utils.MarshalToFile(idmsList, manifest); err != nil {
+		return fmt.Errorf("failed to marshal image digest mirror set into file, err: %w", err)
+	}
+	return nil
+}
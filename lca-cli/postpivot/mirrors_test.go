@@ -0,0 +1,153 @@
+package postpivot
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	v1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+func TestRenderRegistriesConf(t *testing.T) {
+	registriesConf, err := renderRegistriesConf([]clusterconfig_api.ImageDigestSource{
+		{
+			Source:  "quay.io/openshift-release-dev/ocp-release",
+			Mirrors: []string{"mirror.example.com:5000/ocp-release", "mirror2.example.com/ocp-release"},
+		},
+		{
+			Source:  "quay.io/openshift-release-dev/ocp-v4.0-art-dev",
+			Mirrors: []string{"mirror.example.com:5000/ocp-v4.0-art-dev"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `[[registry]]
+  prefix = ""
+  location = "quay.io/openshift-release-dev/ocp-release"
+
+  [[registry.mirror]]
+    location = "mirror.example.com:5000/ocp-release"
+    pull-from-mirror = "digest-only"
+
+  [[registry.mirror]]
+    location = "mirror2.example.com/ocp-release"
+    pull-from-mirror = "digest-only"
+
+[[registry]]
+  prefix = ""
+  location = "quay.io/openshift-release-dev/ocp-v4.0-art-dev"
+
+  [[registry.mirror]]
+    location = "mirror.example.com:5000/ocp-v4.0-art-dev"
+    pull-from-mirror = "digest-only"
+`, registriesConf)
+
+	_, err = renderRegistriesConf([]clusterconfig_api.ImageDigestSource{{Source: "quay.io/openshift-release-dev/ocp-release"}})
+	assert.Error(t, err)
+}
+
+func TestSetMirrorRegistries(t *testing.T) {
+	defer func(imageDigestSources, dropIn string) {
+		imageDigestSourcesDropInFile = imageDigestSources
+		registriesConfDropInFile = dropIn
+	}(imageDigestSourcesDropInFile, registriesConfDropInFile)
+
+	imageDigestSources := []clusterconfig_api.ImageDigestSource{
+		{Source: "quay.io/openshift-release-dev/ocp-release", Mirrors: []string{"mirror.example.com:5000/ocp-release"}},
+	}
+	snippet := "[[registry]]\n  location = \"mirror.example.com:5000\"\n  insecure = true\n"
+
+	testcases := []struct {
+		name               string
+		imageDigestSources []clusterconfig_api.ImageDigestSource
+		registriesConf     string
+	}{
+		{
+			name: "Nothing provided",
+		},
+		{
+			name:               "Image digest sources",
+			imageDigestSources: imageDigestSources,
+		},
+		{
+			name:               "Image digest sources and registries.conf snippet",
+			imageDigestSources: imageDigestSources,
+			registriesConf:     snippet,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			manifestsDir := path.Join(tmpDir, "manifests")
+			assert.NoError(t, os.MkdirAll(manifestsDir, 0o700))
+			imageDigestSourcesDropInFile = path.Join(tmpDir, "registries.conf.d", "98-lca-image-digest-sources.conf")
+			registriesConfDropInFile = path.Join(tmpDir, "registries.conf.d", "99-lca-seed-reconfiguration.conf")
+
+			scheme := runtime.NewScheme()
+			assert.NoError(t, v1.AddToScheme(scheme))
+			pp := NewPostPivot(scheme, &logrus.Logger{}, nil, "", tmpDir, "")
+
+			assert.NoError(t, pp.setMirrorRegistries(context.TODO(), &clusterconfig_api.SeedReconfiguration{
+				ImageDigestSources: tc.imageDigestSources,
+				RegistriesConf:     tc.registriesConf,
+			}, manifestsDir))
+
+			registriesConf, err := os.ReadFile(imageDigestSourcesDropInFile)
+			idmsList := &v1.ImageDigestMirrorSetList{}
+			idmsErr := utils.ReadYamlOrJSONFile(path.Join(manifestsDir, common.IDMSFileName), idmsList)
+			if len(tc.imageDigestSources) == 0 {
+				assert.True(t, os.IsNotExist(err))
+				assert.True(t, os.IsNotExist(idmsErr))
+			} else {
+				assert.NoError(t, err)
+				assert.Contains(t, string(registriesConf), `location = "mirror.example.com:5000/ocp-release"`)
+				assert.NoError(t, idmsErr)
+				if assert.Len(t, idmsList.Items, 1) {
+					assert.Equal(t, common.InstallerIDMSName, idmsList.Items[0].Name)
+					assert.Equal(t, []v1.ImageDigestMirrors{{
+						Source:  "quay.io/openshift-release-dev/ocp-release",
+						Mirrors: []v1.ImageMirror{"mirror.example.com:5000/ocp-release"},
+					}}, idmsList.Items[0].Spec.ImageDigestMirrors)
+				}
+			}
+
+			dropIn, err := os.ReadFile(registriesConfDropInFile)
+			if tc.registriesConf == "" {
+				assert.True(t, os.IsNotExist(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, snippet, string(dropIn))
+			}
+
+			mc := &mcfgv1.MachineConfig{}
+			mcErr := utils.ReadYamlOrJSONFile(path.Join(manifestsDir, fmt.Sprintf(mirrorRegistriesMachineConfig, "master")+".json"), mc)
+			if len(tc.imageDigestSources) == 0 && tc.registriesConf == "" {
+				assert.True(t, os.IsNotExist(mcErr))
+				return
+			}
+			assert.NoError(t, mcErr)
+			assert.Equal(t, "master", mc.Labels["machineconfiguration.openshift.io/role"])
+			assert.Contains(t, string(mc.Spec.Config.Raw), imageDigestSourcesDropInFile)
+			assert.Contains(t, string(mc.Spec.Config.Raw), base64.StdEncoding.EncodeToString(registriesConf))
+			if tc.registriesConf != "" {
+				assert.Contains(t, string(mc.Spec.Config.Raw), registriesConfDropInFile)
+				assert.Contains(t, string(mc.Spec.Config.Raw), base64.StdEncoding.EncodeToString([]byte(snippet)))
+			} else {
+				assert.NotContains(t, string(mc.Spec.Config.Raw), registriesConfDropInFile)
+			}
+			_, err = os.Stat(path.Join(manifestsDir, fmt.Sprintf(mirrorRegistriesMachineConfig, "worker")+".json"))
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package postpivot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	etcdClient "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+const (
+	etcdNetworkConfigKey         = "/kubernetes.io/config.openshift.io/networks/cluster"
+	etcdNetworkOperatorConfigKey = "/kubernetes.io/operator.openshift.io/networks/cluster"
+	etcdInstallConfigKey         = "/kubernetes.io/configmaps/" + common.InstallConfigCMNamespace + "/" + common.InstallConfigCM
+	etcdOVNKubeConfigKey         = "/kubernetes.io/configmaps/openshift-ovn-kubernetes/ovnkube-config"
+	etcdServicesPrefix           = "/kubernetes.io/services/specs/"
+)
+
+// etcdServiceIPRangeKeys are the service ip allocations, they are rebuilt by kube-apiserver for the new service network
+var etcdServiceIPRangeKeys = []string{"/kubernetes.io/ranges/serviceips", "/kubernetes.io/ranges/secondaryserviceips"}
+
+// networkReplacements are the seed network CIDRs replaced by the desired ones, paired by IP family
+type networkReplacements struct {
+	machineNetworks []utils.IPReplacement
+	clusterNetworks []utils.IPReplacement
+	serviceNetworks []utils.IPReplacement
+}
+
+func newNetworkReplacements(seedReconfiguration *clusterconfig_api.SeedReconfiguration,
+	seedClusterInfo *seedclusterinfo.SeedClusterInfo) (*networkReplacements, error) {
+	machineNetworks, err := utils.NetworkReplacements(seedClusterInfo.MachineNetworks, seedReconfiguration.MachineNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match seed machine networks with the desired ones: %w", err)
+	}
+	clusterNetworks, err := utils.ClusterNetworkReplacements(seedClusterInfo.ClusterNetworks, seedReconfiguration.ClusterNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match seed cluster networks with the desired ones: %w", err)
+	}
+	serviceNetworks, err := utils.NetworkReplacements(seedClusterInfo.ServiceNetworks, seedReconfiguration.ServiceNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match seed service networks with the desired ones: %w", err)
+	}
+	return &networkReplacements{
+		machineNetworks: machineNetworks,
+		clusterNetworks: clusterNetworks,
+		serviceNetworks: serviceNetworks,
+	}, nil
+}
+
+func (n *networkReplacements) empty() bool {
+	return len(n.machineNetworks) == 0 && len(n.clusterNetworks) == 0 && len(n.serviceNetworks) == 0
+}
+
+// fileReplacements returns the replacements of the network CIDRs and of the well known service network ips to apply
+// to the static pod files
+func (n *networkReplacements) fileReplacements() ([]utils.IPReplacement, error) {
+	serviceIPs, err := utils.ServiceNetworkIPReplacements(n.serviceNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service network ips: %w", err)
+	}
+
+	var replacements []utils.IPReplacement
+	replacements = append(replacements, n.clusterNetworks...)
+	replacements = append(replacements, n.serviceNetworks...)
+	replacements = append(replacements, serviceIPs...)
+	replacements = append(replacements, n.machineNetworks...)
+	return replacements, nil
+}
+
+// etcdNetworkOperations applies the desired networks to the cluster configuration stored in etcd: the network.config
+// and network.operator objects, the install config, the OVN-Kubernetes configuration and the service ips
+func (p *PostPivot) etcdNetworkOperations(ctx context.Context, seedReconfiguration *clusterconfig_api.SeedReconfiguration,
+	replacements *networkReplacements) error {
+	p.log.Info("Start applying the desired networks in etcd")
+	cli, err := etcdClient.New(etcdClient.Config{
+		Endpoints:   []string{common.EtcdDefaultEndpoint},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	updates := []struct {
+		key    string
+		update func([]byte) ([]byte, error)
+	}{
+		{etcdNetworkConfigKey, updateJSON(func(obj map[string]any) error {
+			return updateNetworkConfig(obj, seedReconfiguration, replacements)
+		})},
+		{etcdNetworkOperatorConfigKey, updateJSON(func(obj map[string]any) error {
+			return updateNetworkOperatorConfig(obj, seedReconfiguration, replacements)
+		})},
+		{etcdInstallConfigKey, updateConfigMap(func(cm *corev1.ConfigMap) error {
+			return updateInstallConfig(cm, seedReconfiguration, replacements)
+		})},
+		{etcdOVNKubeConfigKey, updateConfigMap(func(cm *corev1.ConfigMap) error {
+			return updateOVNKubeConfig(cm, replacements)
+		})},
+	}
+	for _, u := range updates {
+		if err := p.updateEtcdValue(ctx, cli, u.key, u.update); err != nil {
+			return err
+		}
+	}
+
+	if len(replacements.serviceNetworks) == 0 {
+		return nil
+	}
+	return p.updateServiceIPs(ctx, cli, replacements.serviceNetworks)
+}
+
+// updateServiceIPs moves the cluster ips of all the services to the same offset in the desired service networks
+func (p *PostPivot) updateServiceIPs(ctx context.Context, cli *etcdClient.Client, serviceNetworks []utils.IPReplacement) error {
+	resp, err := cli.Get(ctx, etcdServicesPrefix, etcdClient.WithPrefix(), etcdClient.WithKeysOnly())
+	if err != nil {
+		return fmt.Errorf("failed to list services in etcd: %w", err)
+	}
+	for _, kv := range resp.Kvs {
+		if err := p.updateEtcdValue(ctx, cli, string(kv.Key), updateService(func(svc *corev1.Service) error {
+			return translateServiceIPs(svc, serviceNetworks)
+		})); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range etcdServiceIPRangeKeys {
+		if _, err := cli.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete %s from etcd: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// updateEtcdValue updates the value of an etcd key, a missing key is skipped
+func (p *PostPivot) updateEtcdValue(ctx context.Context, cli *etcdClient.Client, key string,
+	update func([]byte) ([]byte, error)) error {
+	resp, err := cli.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to get %s from etcd: %w", key, err)
+	}
+	if len(resp.Kvs) == 0 {
+		p.log.Infof("%s not found in etcd, skipping", key)
+		return nil
+	}
+
+	value, err := update(resp.Kvs[0].Value)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", key, err)
+	}
+	if _, err := cli.Put(ctx, key, string(value)); err != nil {
+		return fmt.Errorf("failed to put %s to etcd: %w", key, err)
+	}
+	p.log.Infof("Updated %s in etcd", key)
+	return nil
+}
+
+// updateJSON returns an update of a custom resource, which etcd stores as json
+func updateJSON(update func(map[string]any) error) func([]byte) ([]byte, error) {
+	return func(value []byte) ([]byte, error) {
+		obj := map[string]any{}
+		if err := json.Unmarshal(value, &obj); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal: %w", err)
+		}
+		if err := update(obj); err != nil {
+			return nil, err
+		}
+		return json.Marshal(obj)
+	}
+}
+
+// updateConfigMap returns an update of a configmap, which etcd stores as protobuf
+func updateConfigMap(update func(*corev1.ConfigMap) error) func([]byte) ([]byte, error) {
+	return func(value []byte) ([]byte, error) {
+		cm := &corev1.ConfigMap{}
+		return updateProtobuf(value, cm, func() error { return update(cm) })
+	}
+}
+
+// updateService returns an update of a service, which etcd stores as protobuf
+func updateService(update func(*corev1.Service) error) func([]byte) ([]byte, error) {
+	return func(value []byte) ([]byte, error) {
+		svc := &corev1.Service{}
+		return updateProtobuf(value, svc, func() error { return update(svc) })
+	}
+}
+
+func updateProtobuf(value []byte, obj runtime.Object, update func() error) ([]byte, error) {
+	serializer := protobuf.NewSerializer(clientgoscheme.Scheme, clientgoscheme.Scheme)
+	_, gvk, err := serializer.Decode(value, nil, obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+	if err := update(); err != nil {
+		return nil, err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(*gvk)
+	return runtime.Encode(serializer, obj)
+}
+
+func clusterNetworksJSON(clusterNetworks []clusterconfig_api.ClusterNetworkEntry) []any {
+	var entries []any
+	for _, clusterNetwork := range clusterNetworks {
+		entries = append(entries, map[string]any{"cidr": clusterNetwork.CIDR, "hostPrefix": clusterNetwork.HostPrefix})
+	}
+	return entries
+}
+
+func serviceNetworksJSON(serviceNetworks []string) []any {
+	var entries []any
+	for _, serviceNetwork := range serviceNetworks {
+		entries = append(entries, serviceNetwork)
+	}
+	return entries
+}
+
+// setNestedField sets a field of a json object, creating the intermediate objects
+func setNestedField(obj map[string]any, value any, fields ...string) error {
+	for _, field := range fields[:len(fields)-1] {
+		child, ok := obj[field]
+		if !ok {
+			child = map[string]any{}
+			obj[field] = child
+		}
+		if obj, ok = child.(map[string]any); !ok {
+			return fmt.Errorf("field %s is not an object", field)
+		}
+	}
+	obj[fields[len(fields)-1]] = value
+	return nil
+}
+
+// updateNetworkConfig sets the desired cluster and service networks in the network.config object spec and status
+func updateNetworkConfig(obj map[string]any, seedReconfiguration *clusterconfig_api.SeedReconfiguration,
+	replacements *networkReplacements) error {
+	for _, section := range []string{"spec", "status"} {
+		if len(replacements.clusterNetworks) > 0 {
+			if err := setNestedField(obj, clusterNetworksJSON(seedReconfiguration.ClusterNetworks), section, "clusterNetwork"); err != nil {
+				return err
+			}
+		}
+		if len(replacements.serviceNetworks) > 0 {
+			if err := setNestedField(obj, serviceNetworksJSON(seedReconfiguration.ServiceNetworks), section, "serviceNetwork"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// updateNetworkOperatorConfig sets the desired cluster and service networks in the network.operator object spec, from
+// which the cluster network operator renders the OVN-Kubernetes configuration
+func updateNetworkOperatorConfig(obj map[string]any, seedReconfiguration *clusterconfig_api.SeedReconfiguration,
+	replacements *networkReplacements) error {
+	if len(replacements.clusterNetworks) > 0 {
+		if err := setNestedField(obj, clusterNetworksJSON(seedReconfiguration.ClusterNetworks), "spec", "clusterNetwork"); err != nil {
+			return err
+		}
+	}
+	if len(replacements.serviceNetworks) > 0 {
+		if err := setNestedField(obj, serviceNetworksJSON(seedReconfiguration.ServiceNetworks), "spec", "serviceNetwork"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateInstallConfig sets the desired networks in the install config
+func updateInstallConfig(cm *corev1.ConfigMap, seedReconfiguration *clusterconfig_api.SeedReconfiguration,
+	replacements *networkReplacements) error {
+	data, ok := cm.Data["install-config"]
+	if !ok {
+		return fmt.Errorf("did not find key install-config in configmap")
+	}
+	installConfig := map[string]any{}
+	if err := yaml.Unmarshal([]byte(data), &installConfig); err != nil {
+		return fmt.Errorf("failed to unmarshal install config: %w", err)
+	}
+
+	if len(replacements.machineNetworks) > 0 {
+		var machineNetworks []any
+		for _, machineNetwork := range seedReconfiguration.MachineNetworks {
+			machineNetworks = append(machineNetworks, map[string]any{"cidr": machineNetwork})
+		}
+		if err := setNestedField(installConfig, machineNetworks, "networking", "machineNetwork"); err != nil {
+			return err
+		}
+	}
+	if len(replacements.clusterNetworks) > 0 {
+		if err := setNestedField(installConfig, clusterNetworksJSON(seedReconfiguration.ClusterNetworks), "networking", "clusterNetwork"); err != nil {
+			return err
+		}
+	}
+	if len(replacements.serviceNetworks) > 0 {
+		if err := setNestedField(installConfig, serviceNetworksJSON(seedReconfiguration.ServiceNetworks), "networking", "serviceNetwork"); err != nil {
+			return err
+		}
+	}
+
+	updated, err := yaml.Marshal(installConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal install config: %w", err)
+	}
+	cm.Data["install-config"] = string(updated)
+	return nil
+}
+
+// updateOVNKubeConfig replaces the cluster and service networks in the OVN-Kubernetes configuration, in a single pass
+// over each value, until the cluster network operator renders it again from the network.operator object
+func updateOVNKubeConfig(cm *corev1.ConfigMap, replacements *networkReplacements) error {
+	var cidrReplacements []utils.IPReplacement
+	cidrReplacements = append(cidrReplacements, replacements.clusterNetworks...)
+	cidrReplacements = append(cidrReplacements, replacements.serviceNetworks...)
+	for key, value := range cm.Data {
+		cm.Data[key] = replaceIPs(value, cidrReplacements)
+	}
+	return nil
+}
+
+// translateServiceIPs moves the cluster ips of a service within the replaced service networks to the same offset in
+// the desired ones
+func translateServiceIPs(svc *corev1.Service, serviceNetworks []utils.IPReplacement) error {
+	translate := func(ip string) (string, error) {
+		for _, serviceNetwork := range serviceNetworks {
+			_, from, err := net.ParseCIDR(serviceNetwork.From)
+			if err != nil {
+				return "", fmt.Errorf("failed to parse cidr %s: %w", serviceNetwork.From, err)
+			}
+			_, to, err := net.ParseCIDR(serviceNetwork.To)
+			if err != nil {
+				return "", fmt.Errorf("failed to parse cidr %s: %w", serviceNetwork.To, err)
+			}
+			if parsed := net.ParseIP(ip); parsed != nil && from.Contains(parsed) {
+				return utils.TranslateIP(ip, from, to, nil)
+			}
+		}
+		return ip, nil
+	}
+
+	if svc.Spec.ClusterIP == "" || strings.EqualFold(svc.Spec.ClusterIP, corev1.ClusterIPNone) {
+		return nil
+	}
+
+	var err error
+	if svc.Spec.ClusterIP, err = translate(svc.Spec.ClusterIP); err != nil {
+		return fmt.Errorf("failed to translate cluster ip of service %s/%s: %w", svc.Namespace, svc.Name, err)
+	}
+	for i := range svc.Spec.ClusterIPs {
+		if svc.Spec.ClusterIPs[i], err = translate(svc.Spec.ClusterIPs[i]); err != nil {
+			return fmt.Errorf("failed to translate cluster ips of service %s/%s: %w", svc.Namespace, svc.Name, err)
+		}
+	}
+	return nil
+}
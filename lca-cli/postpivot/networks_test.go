@@ -0,0 +1,241 @@
+package postpivot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+var (
+	testSeedClusterInfo = &seedclusterinfo.SeedClusterInfo{
+		MachineNetworks: []string{"192.168.127.0/24"},
+		ClusterNetworks: []clusterconfig_api.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 23}},
+		ServiceNetworks: []string{"172.30.0.0/16"},
+	}
+	testNetworksReconfiguration = &clusterconfig_api.SeedReconfiguration{
+		MachineNetworks: []string{"192.168.121.0/24"},
+		ClusterNetworks: []clusterconfig_api.ClusterNetworkEntry{{CIDR: "10.132.0.0/14", HostPrefix: 24}},
+		ServiceNetworks: []string{"172.31.0.0/16"},
+	}
+)
+
+func TestNewNetworkReplacements(t *testing.T) {
+	testcases := []struct {
+		name            string
+		seedClusterInfo *seedclusterinfo.SeedClusterInfo
+		reconfiguration *clusterconfig_api.SeedReconfiguration
+		expectedEmpty   bool
+		expectedError   bool
+	}{
+		{
+			name:            "Networks changed",
+			seedClusterInfo: testSeedClusterInfo,
+			reconfiguration: testNetworksReconfiguration,
+		},
+		{
+			name:            "Networks not provided",
+			seedClusterInfo: testSeedClusterInfo,
+			reconfiguration: &clusterconfig_api.SeedReconfiguration{},
+			expectedEmpty:   true,
+		},
+		{
+			name:            "Seed without networks",
+			seedClusterInfo: &seedclusterinfo.SeedClusterInfo{},
+			reconfiguration: testNetworksReconfiguration,
+			expectedEmpty:   true,
+		},
+		{
+			name:            "IP family changed",
+			seedClusterInfo: testSeedClusterInfo,
+			reconfiguration: &clusterconfig_api.SeedReconfiguration{ServiceNetworks: []string{"fd02::/112"}},
+			expectedError:   true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			replacements, err := newNetworkReplacements(tc.reconfiguration, tc.seedClusterInfo)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			if err == nil {
+				assert.Equal(t, tc.expectedEmpty, replacements.empty())
+			}
+		})
+	}
+}
+
+func TestNetworkFileReplacements(t *testing.T) {
+	replacements, err := newNetworkReplacements(testNetworksReconfiguration, testSeedClusterInfo)
+	assert.NoError(t, err)
+
+	fileReplacements, err := replacements.fileReplacements()
+	assert.NoError(t, err)
+	assert.Equal(t, []utils.IPReplacement{
+		{From: "10.128.0.0/14/23", To: "10.132.0.0/14/24"},
+		{From: "10.128.0.0/14", To: "10.132.0.0/14"},
+		{From: "172.30.0.0/16", To: "172.31.0.0/16"},
+		{From: "172.30.0.1", To: "172.31.0.1"},
+		{From: "172.30.0.10", To: "172.31.0.10"},
+		{From: "192.168.127.0/24", To: "192.168.121.0/24"},
+	}, fileReplacements)
+
+	rewritten, err := rewriteIPsInContent(formatYAML,
+		"args:\n- --service-cluster-ip-range=172.30.0.0/16\n- --cluster-cidr=10.128.0.0/14\nclusterDNS:\n- 172.30.0.10\n",
+		fileReplacements)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"args:\n- --service-cluster-ip-range=172.31.0.0/16\n- --cluster-cidr=10.132.0.0/14\nclusterDNS:\n- 172.31.0.10\n",
+		rewritten)
+}
+
+func TestUpdateNetworkConfig(t *testing.T) {
+	replacements, err := newNetworkReplacements(testNetworksReconfiguration, testSeedClusterInfo)
+	assert.NoError(t, err)
+
+	update := updateJSON(func(obj map[string]any) error {
+		return updateNetworkConfig(obj, testNetworksReconfiguration, replacements)
+	})
+	updated, err := update([]byte(`{"kind":"Network","spec":{"clusterNetwork":[{"cidr":"10.128.0.0/14","hostPrefix":23}],
+		"serviceNetwork":["172.30.0.0/16"],"networkType":"OVNKubernetes"},"status":{"networkType":"OVNKubernetes"}}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"kind":"Network",
+		"spec":{"clusterNetwork":[{"cidr":"10.132.0.0/14","hostPrefix":24}],"serviceNetwork":["172.31.0.0/16"],"networkType":"OVNKubernetes"},
+		"status":{"clusterNetwork":[{"cidr":"10.132.0.0/14","hostPrefix":24}],"serviceNetwork":["172.31.0.0/16"],"networkType":"OVNKubernetes"}}`,
+		string(updated))
+
+	update = updateJSON(func(obj map[string]any) error {
+		return updateNetworkOperatorConfig(obj, testNetworksReconfiguration, replacements)
+	})
+	updated, err = update([]byte(`{"kind":"Network","spec":{"defaultNetwork":{"type":"OVNKubernetes"}}}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"kind":"Network","spec":{"defaultNetwork":{"type":"OVNKubernetes"},
+		"clusterNetwork":[{"cidr":"10.132.0.0/14","hostPrefix":24}],"serviceNetwork":["172.31.0.0/16"]}}`,
+		string(updated))
+}
+
+func TestUpdateConfigMaps(t *testing.T) {
+	replacements, err := newNetworkReplacements(testNetworksReconfiguration, testSeedClusterInfo)
+	assert.NoError(t, err)
+
+	encode := func(obj runtime.Object) []byte {
+		serializer := protobuf.NewSerializer(clientgoscheme.Scheme, clientgoscheme.Scheme)
+		obj.GetObjectKind().SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+		data, err := runtime.Encode(serializer, obj)
+		assert.NoError(t, err)
+		return data
+	}
+	decode := func(data []byte) *corev1.ConfigMap {
+		serializer := protobuf.NewSerializer(clientgoscheme.Scheme, clientgoscheme.Scheme)
+		cm := &corev1.ConfigMap{}
+		_, _, err := serializer.Decode(data, nil, cm)
+		assert.NoError(t, err)
+		return cm
+	}
+
+	installConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-config-v1", Namespace: "kube-system"},
+		Data: map[string]string{"install-config": `baseDomain: redhat.com
+networking:
+  clusterNetwork:
+  - cidr: 10.128.0.0/14
+    hostPrefix: 23
+  machineNetwork:
+  - cidr: 192.168.127.0/24
+  networkType: OVNKubernetes
+  serviceNetwork:
+  - 172.30.0.0/16
+`},
+	}
+	updated, err := updateConfigMap(func(cm *corev1.ConfigMap) error {
+		return updateInstallConfig(cm, testNetworksReconfiguration, replacements)
+	})(encode(installConfig))
+	assert.NoError(t, err)
+	cm := decode(updated)
+	assert.Equal(t, "cluster-config-v1", cm.Name)
+
+	var networking struct {
+		Networking struct {
+			ClusterNetwork []struct {
+				CIDR       string `json:"cidr"`
+				HostPrefix int    `json:"hostPrefix"`
+			} `json:"clusterNetwork"`
+			MachineNetwork []struct {
+				CIDR string `json:"cidr"`
+			} `json:"machineNetwork"`
+			NetworkType    string   `json:"networkType"`
+			ServiceNetwork []string `json:"serviceNetwork"`
+		} `json:"networking"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte(cm.Data["install-config"]), &networking))
+	assert.Equal(t, "10.132.0.0/14", networking.Networking.ClusterNetwork[0].CIDR)
+	assert.Equal(t, 24, networking.Networking.ClusterNetwork[0].HostPrefix)
+	assert.Equal(t, "192.168.121.0/24", networking.Networking.MachineNetwork[0].CIDR)
+	assert.Equal(t, "OVNKubernetes", networking.Networking.NetworkType)
+	assert.Equal(t, []string{"172.31.0.0/16"}, networking.Networking.ServiceNetwork)
+
+	ovnKubeConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ovnkube-config", Namespace: "openshift-ovn-kubernetes"},
+		Data: map[string]string{"ovnkube.conf": `[default]
+cluster-subnets = "10.128.0.0/14/23"
+
+[kubernetes]
+service-cidrs = "172.30.0.0/16"
+`},
+	}
+	updated, err = updateConfigMap(func(cm *corev1.ConfigMap) error {
+		return updateOVNKubeConfig(cm, replacements)
+	})(encode(ovnKubeConfig))
+	assert.NoError(t, err)
+	assert.Equal(t, `[default]
+cluster-subnets = "10.132.0.0/14/24"
+
+[kubernetes]
+service-cidrs = "172.31.0.0/16"
+`, decode(updated).Data["ovnkube.conf"])
+}
+
+func TestTranslateServiceIPs(t *testing.T) {
+	serviceNetworks := []utils.IPReplacement{{From: "172.30.0.0/16", To: "172.31.0.0/16"}}
+
+	testcases := []struct {
+		name     string
+		spec     corev1.ServiceSpec
+		expected corev1.ServiceSpec
+	}{
+		{
+			name:     "Cluster ips translated",
+			spec:     corev1.ServiceSpec{ClusterIP: "172.30.12.34", ClusterIPs: []string{"172.30.12.34", "fd02::1"}},
+			expected: corev1.ServiceSpec{ClusterIP: "172.31.12.34", ClusterIPs: []string{"172.31.12.34", "fd02::1"}},
+		},
+		{
+			name:     "Headless service",
+			spec:     corev1.ServiceSpec{ClusterIP: "None", ClusterIPs: []string{"None"}},
+			expected: corev1.ServiceSpec{ClusterIP: "None", ClusterIPs: []string{"None"}},
+		},
+		{
+			name:     "External name service",
+			spec:     corev1.ServiceSpec{ExternalName: "example.com"},
+			expected: corev1.ServiceSpec{ExternalName: "example.com"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &corev1.Service{Spec: tc.spec}
+			assert.NoError(t, translateServiceIPs(svc, serviceNetworks))
+			assert.Equal(t, tc.expected, svc.Spec)
+		})
+	}
+
+	svc := &corev1.Service{Spec: corev1.ServiceSpec{ClusterIP: "172.30.12.34"}}
+	assert.Error(t, translateServiceIPs(svc, []utils.IPReplacement{{From: "172.30.0.0/16", To: "172.31.0.0/24"}}))
+}
@@ -3,8 +3,11 @@ package postpivot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -13,6 +16,7 @@ import (
 
 	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/lvm"
 	"github.com/openshift-kni/lifecycle-agent/internal/recert"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
@@ -23,6 +27,7 @@ import (
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/google/uuid"
 	cp "github.com/otiai10/copy"
 	"github.com/sirupsen/logrus"
 	etcdClient "go.etcd.io/etcd/client/v3"
@@ -40,24 +45,51 @@ type PostPivot struct {
 	authFile   string
 	workingDir string
 	kubeconfig string
+	progress   *progressTracker
+	// stepTimeouts are the timeouts of the post pivot steps, by step name
+	stepTimeouts map[string]time.Duration
 }
 
 func NewPostPivot(scheme *runtime.Scheme, log *logrus.Logger, ops ops.Ops, authFile, workingDir, kubeconfig string) *PostPivot {
+	stepTimeouts := make(map[string]time.Duration, len(defaultStepTimeouts))
+	for name, timeout := range defaultStepTimeouts {
+		stepTimeouts[name] = timeout
+	}
 	return &PostPivot{
-		scheme:     scheme,
-		log:        log,
-		ops:        ops,
-		authFile:   authFile,
-		workingDir: workingDir,
-		kubeconfig: kubeconfig,
+		scheme:       scheme,
+		log:          log,
+		ops:          ops,
+		authFile:     authFile,
+		workingDir:   workingDir,
+		kubeconfig:   kubeconfig,
+		stepTimeouts: stepTimeouts,
 	}
 }
 
+// defaultStepTimeouts are the timeouts of the post pivot steps that are bounded unless given another timeout
+var defaultStepTimeouts = map[string]time.Duration{
+	common.PostPivotStepPullRecertImage: 10 * time.Minute,
+}
+
+// SetStepTimeouts bounds the duration of the post pivot steps, given in seconds by step name, on top of the default
+// ones. A step that times out fails the post pivot configuration.
+func (p *PostPivot) SetStepTimeouts(timeoutsSeconds map[string]int) error {
+	if err := common.ValidatePostPivotStepTimeouts(timeoutsSeconds); err != nil {
+		return fmt.Errorf("invalid post pivot step timeouts, err: %w", err)
+	}
+	for name, seconds := range timeoutsSeconds {
+		p.stepTimeouts[name] = time.Duration(seconds) * time.Second
+	}
+	return nil
+}
+
 var (
 	dnsmasqOverrides   = "/etc/default/sno_dnsmasq_configuration_overrides"
 	hostnameFile       = "/etc/hostname"
+	kubernetesDir      = "/etc/kubernetes"
 	nmConnectionFolder = common.NMConnectionFolder
 	nodeIpFile         = "/run/nodeip-configuration/primary-ip"
+	lvmRecordFile      = common.LvmRecordFile
 )
 
 const (
@@ -78,8 +110,12 @@ const (
 )
 
 func (p *PostPivot) PostPivotConfiguration(ctx context.Context) error {
+	p.progress = newProgressTracker(p.log, progressFile)
+	p.progress.timeouts = p.stepTimeouts
 
-	if err := p.waifForConfiguration(ctx, filepath.Join(common.OptOpenshift, common.ClusterConfigDir), blockDeviceMountFolder); err != nil {
+	if err := p.progress.track(ctx, common.PostPivotStepWaitForConfiguration, func(ctx context.Context) error {
+		return p.waitForConfiguration(ctx, filepath.Join(common.OptOpenshift, common.ClusterConfigDir), blockDeviceMountFolder)
+	}); err != nil {
 		return err
 	}
 
@@ -96,67 +132,125 @@ func (p *PostPivot) PostPivotConfiguration(ctx context.Context) error {
 		return fmt.Errorf("failed to get cluster info from %s, err: %w", "", err)
 	}
 
-	if err := p.networkConfiguration(ctx, seedReconfiguration); err != nil {
-		return fmt.Errorf("failed to configure networking, err: %w", err)
+	if err := p.progress.track(ctx, common.PostPivotStepNetworkConfiguration, func(ctx context.Context) error {
+		if err := p.networkConfiguration(ctx, seedReconfiguration); err != nil {
+			return fmt.Errorf("failed to configure networking, err: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	if err := utils.RunOnce("setSSHKey", p.workingDir, p.log, p.setSSHKey,
-		seedReconfiguration, sshKeyEarlyAccessFile); err != nil {
+	manifestsDir := path.Join(p.workingDir, common.ClusterConfigDir, common.ManifestsDir)
+	if err := p.runOnce(ctx, common.PostPivotStepTimeConfiguration, func(ctx context.Context) error {
+		return p.setTimeConfiguration(ctx, seedReconfiguration, manifestsDir)
+	}); err != nil {
 		return err
 	}
 
-	if err := utils.RunOnce("pull-secret", p.workingDir, p.log, p.createPullSecretFileAndManifest,
-		seedReconfiguration.PullSecret, common.ImageRegistryAuthFile, path.Join(p.workingDir, common.ClusterConfigDir,
-			common.ManifestsDir, pullSecretFileName)); err != nil {
+	if err := p.runOnce(ctx, common.PostPivotStepSSHKey, func(ctx context.Context) error {
+		return p.setSSHKey(ctx, seedReconfiguration, sshKeyEarlyAccessFile)
+	}); err != nil {
 		return err
 	}
 
-	if seedReconfiguration.APIVersion != clusterconfig_api.SeedReconfigurationVersion {
-		return fmt.Errorf("unsupported seed reconfiguration version %d", seedReconfiguration.APIVersion)
+	if err := p.runOnce(ctx, common.PostPivotStepPullSecret, func(ctx context.Context) error {
+		return p.createPullSecretFileAndManifest(ctx, seedReconfiguration.PullSecret, common.ImageRegistryAuthFile,
+			path.Join(manifestsDir, pullSecretFileName))
+	}); err != nil {
+		return err
 	}
 
-	if err := utils.RunOnce("recert", p.workingDir, p.log, p.recert, ctx, seedReconfiguration, seedClusterInfo); err != nil {
+	if err := p.runOnce(ctx, common.PostPivotStepProxyAndTrustBundle, func(ctx context.Context) error {
+		return p.setProxyAndTrustBundle(ctx, seedReconfiguration, manifestsDir)
+	}); err != nil {
 		return err
 	}
 
-	if err := p.copyClusterConfigFiles(); err != nil {
+	if err := p.runOnce(ctx, common.PostPivotStepMirrorRegistries, func(ctx context.Context) error {
+		return p.setMirrorRegistries(ctx, seedReconfiguration, manifestsDir)
+	}); err != nil {
 		return err
 	}
 
-	client, err := utils.CreateKubeClient(p.scheme, p.kubeconfig)
-	if err != nil {
-		return fmt.Errorf("failed to create k8s client, err: %w", err)
+	if err := p.runOnce(ctx, common.PostPivotStepServingCertificates, func(ctx context.Context) error {
+		return p.setServingCertificates(ctx, seedReconfiguration, manifestsDir)
+	}); err != nil {
+		return err
 	}
 
-	if _, err := p.ops.SystemctlAction("enable", "kubelet", "--now"); err != nil {
+	if err := p.runOnce(ctx, common.PostPivotStepMachineConfigs, func(ctx context.Context) error {
+		return p.setMachineConfigs(ctx, seedReconfiguration, manifestsDir)
+	}); err != nil {
 		return err
 	}
-	p.waitForApi(ctx, client)
 
-	if err := p.deleteAllOldMirrorResources(ctx, client); err != nil {
+	if err := p.runOnce(ctx, common.PostPivotStepPullRecertImage, func(ctx context.Context) error {
+		return p.pullRecertImage(ctx, seedClusterInfo.RecertImagePullSpec)
+	}); err != nil {
 		return err
 	}
 
-	// We move back seed pull secret that we saved aside (if it exists), right before applying new PS secret
-	// in order for MCO not to be degraded and apply new rendered master machine config
-	if err := utils.MoveFileIfExists(common.ImageRegistryAuthFile+seedPullSecretSuffix,
-		common.ImageRegistryAuthFile); err != nil {
+	if err := p.runOnce(ctx, common.PostPivotStepRecert, func(ctx context.Context) error {
+		return p.recert(ctx, seedReconfiguration, seedClusterInfo)
+	}); err != nil {
 		return err
 	}
-	if err := p.applyManifests(); err != nil {
+
+	if err := p.copyClusterConfigFiles(); err != nil {
+		return err
+	}
+
+	client, err := utils.CreateKubeClient(p.scheme, p.kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client, err: %w", err)
+	}
+
+	if err := p.progress.track(ctx, common.PostPivotStepStartCluster, func(ctx context.Context) error {
+		if _, err := p.ops.SystemctlAction("enable", "kubelet", "--now"); err != nil {
+			return err
+		}
+		return p.waitForApi(ctx, client)
+	}); err != nil {
+		return err
+	}
+
+	if err := p.progress.track(ctx, common.PostPivotStepApplyManifests, func(ctx context.Context) error {
+		if err := p.deleteAllOldMirrorResources(ctx, client); err != nil {
+			return err
+		}
+
+		// We move back seed pull secret that we saved aside (if it exists), right before applying new PS secret
+		// in order for MCO not to be degraded and apply new rendered master machine config
+		if err := utils.MoveFileIfExists(common.ImageRegistryAuthFile+seedPullSecretSuffix,
+			common.ImageRegistryAuthFile); err != nil {
+			return err
+		}
+		if err := p.applyManifests(); err != nil {
+			return err
+		}
+
+		return p.changeRegistryInCSVDeployment(ctx, client, seedReconfiguration, seedClusterInfo)
+	}); err != nil {
 		return err
 	}
 
-	if err := p.changeRegistryInCSVDeployment(ctx, client, seedReconfiguration, seedClusterInfo); err != nil {
+	if err := p.runOnce(ctx, common.PostPivotStepSetClusterID, func(ctx context.Context) error {
+		return p.setNewClusterID(ctx, client, seedReconfiguration)
+	}); err != nil {
 		return err
 	}
 
-	if err := utils.RunOnce("set_cluster_id", p.workingDir, p.log, p.setNewClusterID, ctx, client, seedReconfiguration); err != nil {
+	if err := p.runOnce(ctx, common.PostPivotStepRecordIdentity, func(ctx context.Context) error {
+		return p.recordIdentity(ctx, client, seedReconfiguration, seedClusterInfo)
+	}); err != nil {
 		return err
 	}
 
 	// Restore lvm devices
-	if err := utils.RunOnce("recover_lvm_devices", p.workingDir, p.log, p.recoverLvmDevices); err != nil {
+	if err := p.runOnce(ctx, common.PostPivotStepRecoverLvmDevices, func(ctx context.Context) error {
+		return p.recoverLvmDevices(ctx)
+	}); err != nil {
 		return err
 	}
 
@@ -178,31 +272,117 @@ func (p *PostPivot) recert(ctx context.Context, seedReconfiguration *clusterconf
 	if err := utils.SeedReconfigurationKubeconfigRetentionToCryptoDir(kubeconfigCryptoDir, &seedReconfiguration.KubeconfigCryptoRetention); err != nil {
 		return fmt.Errorf("failed to populate crypto dir from seed reconfiguration: %w", err)
 	}
+	servingCertificates, err := utils.SeedReconfigurationServingCertificatesToCryptoDir(kubeconfigCryptoDir, seedReconfiguration)
+	if err != nil {
+		return fmt.Errorf("failed to populate crypto dir with serving certificates: %w", err)
+	}
 
-	if err := recert.CreateRecertConfigFile(seedReconfiguration, seedClusterInfo, kubeconfigCryptoDir,
+	if err := recert.CreateRecertConfigFile(seedReconfiguration, seedClusterInfo, servingCertificates, kubeconfigCryptoDir,
 		p.workingDir); err != nil {
 		return err
 	}
 
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Minute)
-	defer cancel()
-	_ = wait.PollUntilContextCancel(ctxWithTimeout, time.Second, true, func(ctx context.Context) (bool, error) {
+	// Recert runs in a container, which is stopped once the step context is done. Recert is not started at all if the
+	// context got done while the etcd server was starting.
+	stop := p.stopContainerOnDone(ctx, common.RecertContainerName)
+	err = p.ops.RecertFullFlow(seedClusterInfo.RecertImagePullSpec, p.authFile,
+		path.Join(p.workingDir, recert.RecertConfigFile),
+		func() error { return ctx.Err() },
+		func() error { return p.postRecertCommands(ctx, seedReconfiguration, seedClusterInfo) },
+		"-v", fmt.Sprintf("%s:%s", p.workingDir, p.workingDir))
+	stop()
+	if err != nil {
+		return err
+	}
+
+	p.recordRecertSummary(seedClusterInfo)
+	return nil
+}
+
+// pullRecertImage pulls the recert image, retrying until the context is done. Each pull is bounded by the deadline of
+// the context, if any. The pull is best-effort when the image is already in the local container storage, as it is
+// once pulled for the recert dry-run at Prep, so that a registry outage doesn't fail the upgrade.
+func (p *PostPivot) pullRecertImage(ctx context.Context, image string) error {
+	var pullErr error
+	err := wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
 		p.log.Info("pulling recert image")
-		if _, err := p.ops.RunInHostNamespace("podman", "pull", seedClusterInfo.RecertImagePullSpec); err != nil {
-			p.log.Warnf("failed to pull recert image, will retry, err: %s", err.Error())
+		if _, pullErr = p.runInHostNamespace(ctx, "podman", "pull", image); pullErr != nil {
+			p.log.Warnf("failed to pull recert image, will retry, err: %s", pullErr.Error())
 			return false, nil
 		}
 		return true, nil
 	})
-
-	err := p.ops.RecertFullFlow(seedClusterInfo.RecertImagePullSpec, p.authFile,
-		path.Join(p.workingDir, recert.RecertConfigFile),
-		nil,
-		func() error { return p.postRecertCommands(ctx, seedReconfiguration, seedClusterInfo) },
-		"-v", fmt.Sprintf("%s:%s", p.workingDir, p.workingDir))
+	if err == nil {
+		return nil
+	}
+	if exists, existsErr := p.ops.ImageExists(image); existsErr == nil && exists {
+		p.log.Warnf("failed to pull recert image %s, using the local one: %v", image, errors.Join(err, pullErr))
+		return nil
+	}
+	if pullErr != nil {
+		return fmt.Errorf("failed to pull recert image %s, err: %w", image, pullErr)
+	}
 	return err
 }
 
+// hostCommand returns the command and args bounded by the deadline of the context, if any, using the timeout command
+// so that the host command is killed once the deadline is reached
+func hostCommand(ctx context.Context, command string, args ...string) (string, []string) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return "timeout", append([]string{fmt.Sprintf("%.0f", math.Ceil(time.Until(deadline).Seconds())), command},
+			args...)
+	}
+	return command, args
+}
+
+// runInHostNamespace runs the command in the host namespace, bounded by the deadline of the context, if any. The
+// command is not run at all if the context is already done.
+func (p *PostPivot) runInHostNamespace(ctx context.Context, command string, args ...string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	command, args = hostCommand(ctx, command, args...)
+	return p.ops.RunInHostNamespace(command, args...)
+}
+
+// systemctlAction runs the systemctl action, bounded by the deadline of the context, if any. The action is not run at
+// all if the context is already done.
+func (p *PostPivot) systemctlAction(ctx context.Context, action string, args ...string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return p.ops.SystemctlAction(action, args...)
+	}
+	output, err := p.runInHostNamespace(ctx, "systemctl", append([]string{action}, args...)...)
+	if err != nil {
+		err = fmt.Errorf("failed executing systemctl %s %s: %w", action, args, err)
+	}
+	return output, err
+}
+
+// stopContainerOnDone stops the named container once the context is done, until the returned func is called. The
+// returned func only returns once the container is stopped, if it had to be.
+func (p *PostPivot) stopContainerOnDone(ctx context.Context, name string) func() {
+	released := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			p.log.Warnf("Stopping the %s container: %v", name, ctx.Err())
+			if _, err := p.ops.RunInHostNamespace("podman", "stop", name); err != nil {
+				p.log.Warnf("failed to stop the %s container, err: %s", name, err.Error())
+			}
+		case <-released:
+		}
+	}()
+	return func() {
+		close(released)
+		<-stopped
+	}
+}
+
 func (p *PostPivot) etcdPostPivotOperations(ctx context.Context, reconfigurationInfo *clusterconfig_api.SeedReconfiguration) error {
 	p.log.Info("Start running etcd post pivot operations")
 	cli, err := etcdClient.New(etcdClient.Config{
@@ -214,16 +394,13 @@ func (p *PostPivot) etcdPostPivotOperations(ctx context.Context, reconfiguration
 	}
 	defer cli.Close()
 
-	newEtcdIp := reconfigurationInfo.NodeIP
-	if utils.IsIpv6(newEtcdIp) {
-		newEtcdIp = fmt.Sprintf("[%s]", newEtcdIp)
-	}
-
 	members, err := cli.MemberList(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get etcd members list")
 	}
-	_, err = cli.MemberUpdate(ctx, members.Members[0].ID, []string{fmt.Sprintf("http://%s:2380", newEtcdIp)})
+	peerURLs := etcdPeerURLs(members.Members[0].PeerURLs,
+		utils.NodeIPsOrPrimary(reconfigurationInfo.NodeIP, reconfigurationInfo.NodeIPs))
+	_, err = cli.MemberUpdate(ctx, members.Members[0].ID, peerURLs)
 	if err != nil {
 		return fmt.Errorf("failed to change etcd peer url, err: %w", err)
 	}
@@ -231,25 +408,75 @@ func (p *PostPivot) etcdPostPivotOperations(ctx context.Context, reconfiguration
 	return nil
 }
 
+// etcdPeerURLs returns the etcd peer urls of the new node ips, one per current peer url with the ip of the same
+// family, falling back to the primary node ip only
+func etcdPeerURLs(currentPeerURLs, nodeIPs []string) []string {
+	peerURL := func(ip string) string {
+		if utils.IsIpv6(ip) {
+			ip = fmt.Sprintf("[%s]", ip)
+		}
+		return fmt.Sprintf("http://%s:2380", ip)
+	}
+
+	var peerURLs []string
+	for _, currentPeerURL := range currentPeerURLs {
+		u, err := url.Parse(currentPeerURL)
+		if err != nil || net.ParseIP(u.Hostname()) == nil {
+			continue
+		}
+		for _, ip := range nodeIPs {
+			if utils.IsIpv6(ip) == utils.IsIpv6(u.Hostname()) {
+				peerURLs = append(peerURLs, peerURL(ip))
+				break
+			}
+		}
+	}
+	if len(peerURLs) == 0 && len(nodeIPs) > 0 {
+		peerURLs = []string{peerURL(nodeIPs[0])}
+	}
+	return peerURLs
+}
+
 func (p *PostPivot) postRecertCommands(ctx context.Context, clusterInfo *clusterconfig_api.SeedReconfiguration, seedClusterInfo *seedclusterinfo.SeedClusterInfo) error {
 	// TODO: remove after https://issues.redhat.com/browse/ETCD-503
 	if err := p.etcdPostPivotOperations(ctx, clusterInfo); err != nil {
 		return fmt.Errorf("failed to run post pivot etcd operations, err: %w", err)
 	}
 
-	// changing seed ip to new ip in all static pod files
-	_, err := p.ops.RunBashInHostNamespace(fmt.Sprintf("find /etc/kubernetes/ -type f -print0 | xargs -0 sed -i \"s/%s/%s/g\"",
-		seedClusterInfo.NodeIP, clusterInfo.NodeIP))
+	ipReplacements, err := utils.IPReplacementsByFamily(
+		utils.NodeIPsOrPrimary(seedClusterInfo.NodeIP, seedClusterInfo.NodeIPs),
+		utils.NodeIPsOrPrimary(clusterInfo.NodeIP, clusterInfo.NodeIPs))
+	if err != nil {
+		return fmt.Errorf("failed to match seed node ips with the desired ones: %w", err)
+	}
+
+	networkReplacements, err := newNetworkReplacements(clusterInfo, seedClusterInfo)
 	if err != nil {
-		return fmt.Errorf("failed to change seed ip to new ip in /etc/kubernetes, err: %w", err)
+		return err
+	}
+	if !networkReplacements.empty() {
+		if err := p.etcdNetworkOperations(ctx, clusterInfo, networkReplacements); err != nil {
+			return fmt.Errorf("failed to apply the desired networks in etcd, err: %w", err)
+		}
+		networkFileReplacements, err := networkReplacements.fileReplacements()
+		if err != nil {
+			return err
+		}
+		ipReplacements = append(ipReplacements, networkFileReplacements...)
+	}
+
+	// changing seed ips and networks to new ones in all static pod files, in a single pass so that an ip replaced by
+	// one of them is never replaced again by another
+	if err := p.rewriteIPs(kubernetesDir, ipReplacements); err != nil {
+		return fmt.Errorf("failed to change seed ip to new ip in %s, err: %w", kubernetesDir, err)
 	}
 
 	return nil
 }
 
-func (p *PostPivot) waitForApi(ctx context.Context, client runtimeclient.Client) {
+func (p *PostPivot) waitForApi(ctx context.Context, client runtimeclient.Client) error {
 	p.log.Info("Start waiting for api")
-	_ = wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (done bool, err error) {
+	return wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (done bool, err error) {
 		p.log.Info("waiting for api")
 		nodes := &v1.NodeList{}
 		if err = client.List(ctx, nodes); err == nil {
@@ -287,7 +514,7 @@ func (p *PostPivot) applyManifests() error {
 	return nil
 }
 
-func (p *PostPivot) recoverLvmDevices() error {
+func (p *PostPivot) recoverLvmDevices(ctx context.Context) error {
 	lvmConfigPath := path.Join(p.workingDir, common.LvmConfigDir)
 	lvmDevicesPath := path.Join(lvmConfigPath, path.Base(common.LvmDevicesPath))
 
@@ -299,11 +526,42 @@ func (p *PostPivot) recoverLvmDevices() error {
 	}
 
 	// Update the online record of PVs and activate all lvm devices in the VGs
-	_, err := p.ops.RunInHostNamespace("pvscan", "--cache", "--activate", "ay")
+	_, err := p.runInHostNamespace(ctx, "pvscan", "--cache", "--activate", "ay")
 	if err != nil {
 		return fmt.Errorf("failed to scan and active lvm devices, err: %w", err)
 	}
 
+	return p.verifyLvmLayout(path.Join(lvmConfigPath, lvm.RecordFileName), lvmRecordFile)
+}
+
+// verifyLvmLayout checks that the volume groups and logical volumes recorded before the pivot are all back and active,
+// and keeps the record for the LVMS objects to be verified once restored
+func (p *PostPivot) verifyLvmLayout(recordFile, savedRecordFile string) error {
+	record := &lvm.Record{}
+	if err := utils.ReadYamlOrJSONFile(recordFile, record); err != nil {
+		if os.IsNotExist(err) {
+			p.log.Infof("No lvm record found in %s, skipping lvm layout verification", recordFile)
+			return nil
+		}
+		return fmt.Errorf("failed to read lvm record %s, err: %w", recordFile, err)
+	}
+
+	current, err := lvm.ReadLayout(p.ops)
+	if err != nil {
+		return err
+	}
+	if err := lvm.VerifyLayout(&record.Layout, current); err != nil {
+		return fmt.Errorf("lvm layout does not match the one before the upgrade, err: %w", err)
+	}
+	p.log.Infof("Verified %d volume groups and %d logical volumes", len(record.Layout.VolumeGroups),
+		len(record.Layout.LogicalVolumes))
+
+	if err := os.MkdirAll(path.Dir(savedRecordFile), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s, err: %w", path.Dir(savedRecordFile), err)
+	}
+	if err := cp.Copy(recordFile, savedRecordFile); err != nil {
+		return fmt.Errorf("failed to save lvm record to %s, err: %w", savedRecordFile, err)
+	}
 	return nil
 }
 
@@ -385,7 +643,7 @@ func (p *PostPivot) changeRegistryInCSVDeployment(ctx context.Context, client ru
 	patch := []byte(fmt.Sprintf(`{"spec":{"template":{"spec": {"containers": [{"name": "%s", "image":"%s", "args": %s}]}}}}`,
 		csvD.Spec.Template.Spec.Containers[0].Name, newImage, newArgsStr))
 
-	p.log.Infof("Applying csv deploymet patch %s", string(patch))
+	p.log.Infof("Applying csv deployment patch %s", string(patch))
 	err = client.Patch(ctx, csvD, runtimeclient.RawPatch(types.StrategicMergePatchType, patch))
 	if err != nil {
 		return fmt.Errorf("failed to patch csv deployment, err: %w", err)
@@ -406,14 +664,15 @@ func (p *PostPivot) setNewClusterID(ctx context.Context, client runtimeclient.Cl
 		return err
 	}
 
-	if seedReconfiguration.ClusterID == "" {
-		// TODO: Generate a fresh cluster ID in case it is empty
-		return fmt.Errorf("cluster id is empty, automatic generation is not yet implemented")
+	clusterID := seedReconfiguration.ClusterID
+	if clusterID == "" {
+		clusterID = uuid.New().String()
+		p.log.Infof("Cluster id is not provided, generated %s", clusterID)
 	}
 
-	patch := []byte(fmt.Sprintf(`{"spec":{"clusterID":"%s"}}`, seedReconfiguration.ClusterID))
+	patch := []byte(fmt.Sprintf(`{"spec":{"clusterID":"%s"}}`, clusterID))
 
-	p.log.Infof("Applying csv deploymet patch %s", string(patch))
+	p.log.Infof("Applying cluster version patch %s", string(patch))
 	err := client.Patch(ctx, clusterVersion, runtimeclient.RawPatch(types.MergePatchType, patch))
 	if err != nil {
 		return fmt.Errorf("failed to patch cluster id in clusterversion, err: %w", err)
@@ -441,15 +700,24 @@ func (p *PostPivot) setDnsMasqConfiguration(seedReconfiguration *clusterconfig_a
 }
 
 // setNodeIPIfNotProvided will run nodeip configuration service on demand in case seedReconfiguration node ip is empty
-// nodeip-configuration service in on charge of setting kubelet and crio ip, this ip we will take as NodeIP
+// nodeip-configuration service in on charge of setting kubelet and crio ip, this ip we will take as NodeIP.
+// On a dual-stack cluster, unless the node ips are provided as well, the node ips of the other ip families are taken
+// from the host addresses within the machine networks.
 func (p *PostPivot) setNodeIPIfNotProvided(ctx context.Context,
 	seedReconfiguration *clusterconfig_api.SeedReconfiguration, ipFile string) error {
 	if seedReconfiguration.NodeIP != "" {
+		if len(seedReconfiguration.NodeIPs) == 0 {
+			nodeIPs, err := p.nodeIPsInMachineNetworks(seedReconfiguration.NodeIP, seedReconfiguration.MachineNetworks)
+			if err != nil {
+				return err
+			}
+			seedReconfiguration.NodeIPs = nodeIPs
+		}
 		return nil
 	}
 
 	if _, err := os.Stat(ipFile); err != nil {
-		_, err := p.ops.SystemctlAction("start", "nodeip-configuration")
+		_, err := p.systemctlAction(ctx, "start", "nodeip-configuration")
 		if err != nil {
 			return fmt.Errorf("failed to start nodeip-configuration service, err %w", err)
 		}
@@ -473,40 +741,82 @@ func (p *PostPivot) setNodeIPIfNotProvided(ctx context.Context,
 	}
 
 	seedReconfiguration.NodeIP = ip.String()
+	nodeIPs, err := p.nodeIPsInMachineNetworks(seedReconfiguration.NodeIP, seedReconfiguration.MachineNetworks)
+	if err != nil {
+		return err
+	}
+	seedReconfiguration.NodeIPs = nodeIPs
 	return nil
 }
 
-// setSSHKey  sets ssh public key provided by user in 2 operations:
+// interfaceAddrs returns the host addresses, it is a variable in order to be replaced in tests
+var interfaceAddrs = net.InterfaceAddrs
+
+// nodeIPsInMachineNetworks returns the primary node ip followed by the first host address of every other ip family
+// within the machine networks
+func (p *PostPivot) nodeIPsInMachineNetworks(primary string, machineNetworks []string) ([]string, error) {
+	nodeIPs := []string{primary}
+	if len(machineNetworks) == 0 {
+		return nodeIPs, nil
+	}
+
+	addrs, err := interfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list host addresses, err: %w", err)
+	}
+
+	for _, machineNetwork := range machineNetworks {
+		_, ipNet, err := net.ParseCIDR(machineNetwork)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse machine network %s, err: %w", machineNetwork, err)
+		}
+		if utils.IsIpv6(ipNet.IP.String()) == utils.IsIpv6(primary) {
+			continue
+		}
+		for _, addr := range addrs {
+			addrNet, ok := addr.(*net.IPNet)
+			if ok && ipNet.Contains(addrNet.IP) {
+				p.log.Infof("Using %s from machine network %s as node ip", addrNet.IP, machineNetwork)
+				nodeIPs = append(nodeIPs, addrNet.IP.String())
+				break
+			}
+		}
+	}
+	return nodeIPs, nil
+}
+
+// setSSHKey  sets the ssh public keys provided by user in 2 operations:
 // 1. as file in order to give early access to the node
 // 2. creates 2 machine configs in manifests dir that will be applied when cluster is up
-func (p *PostPivot) setSSHKey(seedReconfiguration *clusterconfig_api.SeedReconfiguration, sshKeyFile string) error {
-	if seedReconfiguration.SSHKey == "" {
+func (p *PostPivot) setSSHKey(ctx context.Context, seedReconfiguration *clusterconfig_api.SeedReconfiguration, sshKeyFile string) error {
+	sshKeys := seedReconfiguration.AuthorizedKeys()
+	if len(sshKeys) == 0 {
 		p.log.Infof("No ssh public key was provided, skipping")
 		return nil
 	}
 
-	p.log.Infof("Creating file %s with ssh keys for early connection", sshKeyFile)
-	if err := os.WriteFile(sshKeyFile, []byte(seedReconfiguration.SSHKey), 0o600); err != nil {
+	p.log.Infof("Creating file %s with %d ssh keys for early connection", sshKeyFile, len(sshKeys))
+	if err := os.WriteFile(sshKeyFile, []byte(strings.Join(sshKeys, "\n")+"\n"), 0o600); err != nil {
 		return fmt.Errorf("failed to write ssh key to file, err %w", err)
 	}
 
 	p.log.Infof("Setting %s user ownership on %s", userCore, sshKeyFile)
-	if _, err := p.ops.RunInHostNamespace("chown", userCore, sshKeyFile); err != nil {
+	if _, err := p.runInHostNamespace(ctx, "chown", userCore, sshKeyFile); err != nil {
 		return fmt.Errorf("failed to set %s user ownership on %s, err :%w", userCore, sshKeyFile, err)
 	}
 
-	return p.createSSHKeyMachineConfigs(seedReconfiguration.SSHKey)
+	return p.createSSHKeyMachineConfigs(sshKeys)
 }
 
-func (p *PostPivot) createSSHKeyMachineConfigs(sshKey string) error {
-	p.log.Info("Creating worker and master machine configs with provided ssh key, in order to override seed's")
+func (p *PostPivot) createSSHKeyMachineConfigs(sshKeys []string) error {
+	p.log.Info("Creating worker and master machine configs with provided ssh keys, in order to override seed's")
 	ignConfig := map[string]any{
 		"ignition": map[string]string{"version": "3.2.0"},
 		"passwd": map[string]any{
 			"users": []any{
 				map[string]any{
 					"name":              userCore,
-					"sshAuthorizedKeys": []string{sshKey},
+					"sshAuthorizedKeys": sshKeys,
 				}},
 		},
 	}
@@ -531,8 +841,9 @@ func (p *PostPivot) createSSHKeyMachineConfigs(sshKey string) error {
 				Config: rawExt,
 			},
 		}
+		// oc apply only picks the files of the manifests dir with a json or yaml extension
 		if err := utils.MarshalToFile(mc, path.Join(p.workingDir, common.ClusterConfigDir,
-			common.ManifestsDir, fmt.Sprintf(sshMachineConfig, role))); err != nil {
+			common.ManifestsDir, fmt.Sprintf(sshMachineConfig, role)+".json")); err != nil {
 			return fmt.Errorf("failed to marshal ssh key into file for role %s, err: %w", role, err)
 		}
 	}
@@ -541,7 +852,7 @@ func (p *PostPivot) createSSHKeyMachineConfigs(sshKey string) error {
 
 // applyNMStateConfiguration is applying nmstate yaml provided as string in seedReconfiguration.
 // It uses nmstatectl apply <file> command that will return error in case configuration is not successful
-func (p *PostPivot) applyNMStateConfiguration(seedReconfiguration *clusterconfig_api.SeedReconfiguration) error {
+func (p *PostPivot) applyNMStateConfiguration(ctx context.Context, seedReconfiguration *clusterconfig_api.SeedReconfiguration) error {
 	if seedReconfiguration.RawNMStateConfig == "" {
 		p.log.Infof("NMState config is empty, skipping")
 		return nil
@@ -551,7 +862,7 @@ func (p *PostPivot) applyNMStateConfiguration(seedReconfiguration *clusterconfig
 	if err := os.WriteFile(nmFile, []byte(seedReconfiguration.RawNMStateConfig), 0o600); err != nil {
 		return fmt.Errorf("failed to write nmstate config to %s, err %w", nmFile, err)
 	}
-	if _, err := p.ops.RunInHostNamespace("nmstatectl", "apply", nmFile); err != nil {
+	if _, err := p.runInHostNamespace(ctx, "nmstatectl", "apply", nmFile); err != nil {
 		return fmt.Errorf("failed to apply nmstate config %s, err: %w", seedReconfiguration.RawNMStateConfig, err)
 	}
 
@@ -560,12 +871,15 @@ func (p *PostPivot) applyNMStateConfiguration(seedReconfiguration *clusterconfig
 
 // createPullSecretFile creates auth file on filesystem in order to be able to pull images
 // and runs createPullSecretManifest to write secret in manifests folder
-func (p *PostPivot) createPullSecretFileAndManifest(pullSecret, pullSecretFile, pullSecretManifest string) error {
+func (p *PostPivot) createPullSecretFileAndManifest(ctx context.Context, pullSecret, pullSecretFile, pullSecretManifest string) error {
 	// TODO: Should return error in the future as cluster will not be operational without it
 	if pullSecret == "" {
 		p.log.Infof("Pull secret was not provided")
 		return nil
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	p.log.Infof("Move seed PS file aside")
 	if err := utils.MoveFileIfExists(pullSecretFile, pullSecretFile+seedPullSecretSuffix); err != nil {
 		return err
@@ -602,9 +916,9 @@ func (p *PostPivot) createPullSecretManifest(pullSecret, pullSecretManifest stri
 	return nil
 }
 
-// waifForConfiguration waits till configuration folder exists or till device with label "relocation-config" is added.
+// waitForConfiguration waits till configuration folder exists or till device with label "relocation-config" is added.
 // in case device was provided we are mounting it and copying files to configFolder
-func (p *PostPivot) waifForConfiguration(ctx context.Context, configFolder, blockDeviceMountFolder string) error {
+func (p *PostPivot) waitForConfiguration(ctx context.Context, configFolder, blockDeviceMountFolder string) error {
 	err := wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
 		p.log.Infof("waiting for block device with label %s or for configuration folder %s", blockDeviceLabel, configFolder)
 		if _, err := os.Stat(configFolder); err == nil {
@@ -684,7 +998,7 @@ func (p *PostPivot) networkConfiguration(ctx context.Context, seedReconfiguratio
 		return err
 	}
 
-	if err := p.applyNMStateConfiguration(seedReconfiguration); err != nil {
+	if err := p.applyNMStateConfiguration(ctx, seedReconfiguration); err != nil {
 		return err
 	}
 
@@ -700,11 +1014,11 @@ func (p *PostPivot) networkConfiguration(ctx context.Context, seedReconfiguratio
 		return err
 	}
 
-	if _, err := p.ops.SystemctlAction("restart", nmService); err != nil {
+	if _, err := p.systemctlAction(ctx, "restart", nmService); err != nil {
 		return fmt.Errorf("failed to restart network manager service, err %w", err)
 	}
 
-	if _, err := p.ops.SystemctlAction("restart", dnsmasqService); err != nil {
+	if _, err := p.systemctlAction(ctx, "restart", dnsmasqService); err != nil {
 		return fmt.Errorf("failed to restart dnsmasq service, err %w", err)
 	}
 
@@ -2,21 +2,31 @@ package postpivot
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+	v1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
 
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	"github.com/openshift-kni/lifecycle-agent/utils"
 )
@@ -40,7 +50,13 @@ func TestSetNodeIPIfNotProvided(t *testing.T) {
 
 	defer func() {
 		mockController.Finish()
+		interfaceAddrs = net.InterfaceAddrs
 	}()
+	interfaceAddrs = func() ([]net.Addr, error) {
+		ip, ipNet, _ := net.ParseCIDR("2620:52:0:199::10/64")
+		ipNet.IP = ip
+		return []net.Addr{ipNet}, nil
+	}
 
 	testcases := []struct {
 		name             string
@@ -48,6 +64,8 @@ func TestSetNodeIPIfNotProvided(t *testing.T) {
 		nodeipFileExists bool
 		ipProvided       bool
 		ipToSet          string
+		machineNetworks  []string
+		expectedNodeIPs  []string
 	}{
 		{
 			name:             "Ip provided nothing to do",
@@ -55,6 +73,16 @@ func TestSetNodeIPIfNotProvided(t *testing.T) {
 			nodeipFileExists: true,
 			ipProvided:       true,
 			ipToSet:          "192.167.1.2",
+			expectedNodeIPs:  []string{"192.167.1.2"},
+		},
+		{
+			name:             "Ip provided on a dual-stack cluster, secondary ip taken from the machine networks",
+			expectedError:    false,
+			nodeipFileExists: true,
+			ipProvided:       true,
+			ipToSet:          "192.167.1.2",
+			machineNetworks:  []string{"192.167.1.0/24", "2620:52:0:199::/64"},
+			expectedNodeIPs:  []string{"192.167.1.2", "2620:52:0:199::10"},
 		},
 		{
 			name:             "Ip is not provided, bad ip in file",
@@ -69,6 +97,7 @@ func TestSetNodeIPIfNotProvided(t *testing.T) {
 			nodeipFileExists: true,
 			ipProvided:       false,
 			ipToSet:          "192.167.1.2",
+			expectedNodeIPs:  []string{"192.167.1.2"},
 		},
 	}
 
@@ -77,7 +106,7 @@ func TestSetNodeIPIfNotProvided(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			log := &logrus.Logger{}
 			pp := NewPostPivot(nil, log, mockOps, "", "", "")
-			seedReconfig := &clusterconfig_api.SeedReconfiguration{}
+			seedReconfig := &clusterconfig_api.SeedReconfiguration{MachineNetworks: tc.machineNetworks}
 			if tc.ipProvided {
 				seedReconfig.NodeIP = tc.ipToSet
 			}
@@ -96,6 +125,103 @@ func TestSetNodeIPIfNotProvided(t *testing.T) {
 			} else {
 				assert.Equal(t, err != nil, tc.expectedError)
 			}
+			if !tc.expectedError {
+				assert.Equal(t, tc.expectedNodeIPs, seedReconfig.NodeIPs)
+			}
+		})
+	}
+}
+
+func TestNodeIPsInMachineNetworks(t *testing.T) {
+	defer func() { interfaceAddrs = net.InterfaceAddrs }()
+	interfaceAddrs = func() ([]net.Addr, error) {
+		var addrs []net.Addr
+		for _, cidr := range []string{"127.0.0.1/8", "192.168.121.10/24", "fe80::1/64", "2620:52:0:199::10/64"} {
+			ip, ipNet, _ := net.ParseCIDR(cidr)
+			ipNet.IP = ip
+			addrs = append(addrs, ipNet)
+		}
+		return addrs, nil
+	}
+
+	testcases := []struct {
+		name            string
+		primary         string
+		machineNetworks []string
+		expected        []string
+		expectedError   bool
+	}{
+		{
+			name:     "No machine networks",
+			primary:  "192.168.121.10",
+			expected: []string{"192.168.121.10"},
+		},
+		{
+			name:            "Dual stack, ipv4 primary",
+			primary:         "192.168.121.10",
+			machineNetworks: []string{"192.168.121.0/24", "2620:52:0:199::/64"},
+			expected:        []string{"192.168.121.10", "2620:52:0:199::10"},
+		},
+		{
+			name:            "Dual stack, ipv6 primary",
+			primary:         "2620:52:0:199::10",
+			machineNetworks: []string{"2620:52:0:199::/64", "192.168.121.0/24"},
+			expected:        []string{"2620:52:0:199::10", "192.168.121.10"},
+		},
+		{
+			name:            "No host address in the machine network",
+			primary:         "192.168.121.10",
+			machineNetworks: []string{"192.168.121.0/24", "2620:52:0:200::/64"},
+			expected:        []string{"192.168.121.10"},
+		},
+		{
+			name:            "Bad machine network",
+			primary:         "192.168.121.10",
+			machineNetworks: []string{"bad"},
+			expectedError:   true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			pp := NewPostPivot(nil, &logrus.Logger{}, nil, "", "", "")
+			nodeIPs, err := pp.nodeIPsInMachineNetworks(tc.primary, tc.machineNetworks)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			assert.Equal(t, tc.expected, nodeIPs)
+		})
+	}
+}
+
+func TestEtcdPeerURLs(t *testing.T) {
+	testcases := []struct {
+		name            string
+		currentPeerURLs []string
+		nodeIPs         []string
+		expected        []string
+	}{
+		{
+			name:            "Single stack",
+			currentPeerURLs: []string{"https://192.168.127.10:2380"},
+			nodeIPs:         []string{"192.168.121.10"},
+			expected:        []string{"http://192.168.121.10:2380"},
+		},
+		{
+			name:            "Dual stack",
+			currentPeerURLs: []string{"https://[2620:52:0:198::10]:2380", "https://192.168.127.10:2380"},
+			nodeIPs:         []string{"192.168.121.10", "2620:52:0:199::10"},
+			expected:        []string{"http://[2620:52:0:199::10]:2380", "http://192.168.121.10:2380"},
+		},
+		{
+			name:            "No usable peer url falls back to the primary ip",
+			currentPeerURLs: []string{"http://localhost:2380"},
+			nodeIPs:         []string{"2620:52:0:199::10", "192.168.121.10"},
+			expected:        []string{"http://[2620:52:0:199::10]:2380"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, etcdPeerURLs(tc.currentPeerURLs, tc.nodeIPs))
 		})
 	}
 }
@@ -222,7 +348,7 @@ interfaces:
 				mockOps.EXPECT().RunInHostNamespace("nmstatectl", "apply", nmstateFile).Return("", nil)
 			}
 
-			err := pp.applyNMStateConfiguration(tc.seedReconfiguration)
+			err := pp.applyNMStateConfiguration(context.TODO(), tc.seedReconfiguration)
 			if !tc.expectedError {
 				if err != nil {
 					t.Errorf("unexpected error: %v", err)
@@ -272,7 +398,7 @@ func TestCreatePullSecretFile(t *testing.T) {
 			pullSecretManifestFile := path.Join(tmpDir, pullSecretFileName)
 			clientgoscheme.AddToScheme(scheme)
 			pp := NewPostPivot(scheme, log, mockOps, "", tmpDir, "")
-			err := pp.createPullSecretFileAndManifest(tc.pullSecret, pullSecretFile, pullSecretManifestFile)
+			err := pp.createPullSecretFileAndManifest(context.TODO(), tc.pullSecret, pullSecretFile, pullSecretManifestFile)
 			if err != nil {
 				t.Errorf("unexpected error: %v", err)
 			}
@@ -301,6 +427,70 @@ func TestCreatePullSecretFile(t *testing.T) {
 	}
 }
 
+func TestSetSSHKey(t *testing.T) {
+	testcases := []struct {
+		name     string
+		sshKey   string
+		sshKeys  []string
+		expected []string
+	}{
+		{
+			name: "No ssh key was set",
+		},
+		{
+			name:     "Ssh key and additional keys",
+			sshKey:   "ssh-ed25519 AAAA team-a\n",
+			sshKeys:  []string{"ssh-ed25519 AAAA team-a", "ssh-rsa BBBB team-b"},
+			expected: []string{"ssh-ed25519 AAAA team-a", "ssh-rsa BBBB team-b"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			manifestsDir := path.Join(tmpDir, common.ClusterConfigDir, common.ManifestsDir)
+			assert.NoError(t, os.MkdirAll(manifestsDir, 0o700))
+			sshKeyFile := path.Join(tmpDir, "ib-early-access")
+			mockOps := ops.NewMockOps(gomock.NewController(t))
+			if len(tc.expected) > 0 {
+				mockOps.EXPECT().RunInHostNamespace("chown", userCore, sshKeyFile).Return("", nil)
+			}
+
+			pp := NewPostPivot(nil, logrus.New(), mockOps, "", tmpDir, "")
+			assert.NoError(t, pp.setSSHKey(context.TODO(), &clusterconfig_api.SeedReconfiguration{
+				SSHKey:  tc.sshKey,
+				SSHKeys: tc.sshKeys,
+			}, sshKeyFile))
+
+			if len(tc.expected) == 0 {
+				_, err := os.Stat(sshKeyFile)
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+			content, err := os.ReadFile(sshKeyFile)
+			assert.NoError(t, err)
+			assert.Equal(t, strings.Join(tc.expected, "\n")+"\n", string(content))
+
+			for _, role := range []string{"master", "worker"} {
+				mc := &mcfgv1.MachineConfig{}
+				assert.NoError(t, utils.ReadYamlOrJSONFile(
+					path.Join(manifestsDir, fmt.Sprintf(sshMachineConfig, role)+".json"), mc))
+				assert.Equal(t, fmt.Sprintf(sshMachineConfig, role), mc.Name)
+				config := struct {
+					Passwd struct {
+						Users []struct {
+							Name              string   `json:"name"`
+							SSHAuthorizedKeys []string `json:"sshAuthorizedKeys"`
+						} `json:"users"`
+					} `json:"passwd"`
+				}{}
+				assert.NoError(t, json.Unmarshal(mc.Spec.Config.Raw, &config))
+				assert.Equal(t, tc.expected, config.Passwd.Users[0].SSHAuthorizedKeys)
+			}
+		})
+	}
+}
+
 func TestSetHostname(t *testing.T) {
 	var (
 		mockController = gomock.NewController(t)
@@ -399,12 +589,117 @@ func TestWaifForConfiguration(t *testing.T) {
 				mockOps.EXPECT().ListBlockDevices().Return(nil, fmt.Errorf("dummy")).Do(cancel).Times(1)
 			}
 
-			err := pp.waifForConfiguration(ctx, configFolder, configFolder)
+			err := pp.waitForConfiguration(ctx, configFolder, configFolder)
 			assert.Equal(t, tc.expectedError, err != nil)
 		})
 	}
 }
 
+func TestPullRecertImage(t *testing.T) {
+	const image = "quay.io/edge-infrastructure/recert:latest"
+
+	t.Run("Pulled after a retry, bounded by the deadline", func(t *testing.T) {
+		mockOps := ops.NewMockOps(gomock.NewController(t))
+		pp := NewPostPivot(nil, &logrus.Logger{}, mockOps, "", "", "")
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Minute)
+		defer cancel()
+
+		gomock.InOrder(
+			mockOps.EXPECT().RunInHostNamespace("timeout", "60", "podman", "pull", image).Return("", fmt.Errorf("dummy")),
+			mockOps.EXPECT().RunInHostNamespace("timeout", gomock.Any(), "podman", "pull", image).Return("", nil),
+		)
+		assert.NoError(t, pp.pullRecertImage(ctx, image))
+	})
+
+	t.Run("Pull error returned once the context is done", func(t *testing.T) {
+		mockOps := ops.NewMockOps(gomock.NewController(t))
+		pp := NewPostPivot(nil, &logrus.Logger{}, mockOps, "", "", "")
+		ctx, cancel := context.WithCancel(context.TODO())
+
+		mockOps.EXPECT().RunInHostNamespace("podman", "pull", image).Return("", fmt.Errorf("unauthorized")).
+			Do(func(string, ...string) { cancel() })
+		mockOps.EXPECT().ImageExists(image).Return(false, nil)
+		assert.EqualError(t, pp.pullRecertImage(ctx, image),
+			"failed to pull recert image quay.io/edge-infrastructure/recert:latest, err: unauthorized")
+	})
+
+	t.Run("Local image used once the context is done", func(t *testing.T) {
+		mockOps := ops.NewMockOps(gomock.NewController(t))
+		pp := NewPostPivot(nil, &logrus.Logger{}, mockOps, "", "", "")
+		ctx, cancel := context.WithCancel(context.TODO())
+
+		mockOps.EXPECT().RunInHostNamespace("podman", "pull", image).Return("", fmt.Errorf("connection refused")).
+			Do(func(string, ...string) { cancel() })
+		mockOps.EXPECT().ImageExists(image).Return(true, nil)
+		assert.NoError(t, pp.pullRecertImage(ctx, image))
+	})
+}
+
+func TestHostCommands(t *testing.T) {
+	t.Run("Run as is without a deadline", func(t *testing.T) {
+		mockOps := ops.NewMockOps(gomock.NewController(t))
+		pp := NewPostPivot(nil, &logrus.Logger{}, mockOps, "", "", "")
+
+		mockOps.EXPECT().RunInHostNamespace("pvscan", "--cache").Return("", nil)
+		mockOps.EXPECT().SystemctlAction("restart", nmService).Return("", nil)
+		_, err := pp.runInHostNamespace(context.TODO(), "pvscan", "--cache")
+		assert.NoError(t, err)
+		_, err = pp.systemctlAction(context.TODO(), "restart", nmService)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Bounded by the deadline", func(t *testing.T) {
+		mockOps := ops.NewMockOps(gomock.NewController(t))
+		pp := NewPostPivot(nil, &logrus.Logger{}, mockOps, "", "", "")
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Minute)
+		defer cancel()
+
+		mockOps.EXPECT().RunInHostNamespace("timeout", "60", "pvscan", "--cache").Return("", nil)
+		mockOps.EXPECT().RunInHostNamespace("timeout", gomock.Any(), "systemctl", "restart", nmService).
+			Return("", fmt.Errorf("exit status 124"))
+		_, err := pp.runInHostNamespace(ctx, "pvscan", "--cache")
+		assert.NoError(t, err)
+		_, err = pp.systemctlAction(ctx, "restart", nmService)
+		assert.EqualError(t, err, "failed executing systemctl restart [NetworkManager.service]: exit status 124")
+	})
+
+	t.Run("Not run once the context is done", func(t *testing.T) {
+		pp := NewPostPivot(nil, &logrus.Logger{}, ops.NewMockOps(gomock.NewController(t)), "", "", "")
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+
+		_, err := pp.runInHostNamespace(ctx, "pvscan", "--cache")
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = pp.systemctlAction(ctx, "restart", nmService)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, pp.setMachineConfigs(ctx, &clusterconfig_api.SeedReconfiguration{
+			MachineConfigs: []string{"dummy"},
+		}, t.TempDir()), context.Canceled)
+	})
+}
+
+func TestStopContainerOnDone(t *testing.T) {
+	mockOps := ops.NewMockOps(gomock.NewController(t))
+	pp := NewPostPivot(nil, &logrus.Logger{}, mockOps, "", "", "")
+
+	// Released before the context is done, the container is left alone
+	stop := pp.stopContainerOnDone(context.TODO(), common.RecertContainerName)
+	stop()
+
+	// The container of a running flow is stopped once the context is done, which ends the flow
+	ctx, cancel := context.WithCancel(context.TODO())
+	stop = pp.stopContainerOnDone(ctx, common.RecertContainerName)
+	flowEnded := make(chan struct{})
+	mockOps.EXPECT().RunInHostNamespace("podman", "stop", common.RecertContainerName).
+		DoAndReturn(func(string, ...string) (string, error) {
+			close(flowEnded)
+			return "", nil
+		})
+	cancel()
+	<-flowEnded
+	stop()
+}
+
 func TestNetworkConfiguration(t *testing.T) {
 	seedReconfiguration := &clusterconfig_api.SeedReconfiguration{
 		BaseDomain:  "new.com",
@@ -464,3 +759,95 @@ func TestNetworkConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyLvmLayout(t *testing.T) {
+	record := `{"layout":{"volumeGroups":[{"vg_name":"vg1","vg_uuid":"vg-uuid","vg_size":"1024"}],` +
+		`"logicalVolumes":[{"vg_name":"vg1","lv_name":"lv1","lv_uuid":"lv-uuid","lv_size":"512","lv_active":"active"}]}}`
+	vgsReport := `{"report":[{"vg":[{"vg_name":"vg1","vg_uuid":"vg-uuid","vg_size":"1024"}]}]}`
+
+	testcases := []struct {
+		name          string
+		record        string
+		lvsReport     string
+		expectedError string
+	}{
+		{
+			name: "No record",
+		},
+		{
+			name:      "Layout matches",
+			record:    record,
+			lvsReport: `{"report":[{"lv":[{"vg_name":"vg1","lv_name":"lv1","lv_uuid":"lv-uuid","lv_size":"512","lv_active":"active"}]}]}`,
+		},
+		{
+			name:          "Logical volume not active",
+			record:        record,
+			lvsReport:     `{"report":[{"lv":[{"vg_name":"vg1","lv_name":"lv1","lv_uuid":"lv-uuid","lv_size":"512","lv_active":""}]}]}`,
+			expectedError: "logical volume vg1/lv1 is not active",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			recordFile := path.Join(tmpDir, "lvm-record.json")
+			savedRecordFile := path.Join(tmpDir, "lca", "lvm_record.json")
+			mockOps := ops.NewMockOps(gomock.NewController(t))
+			if tc.record != "" {
+				assert.NoError(t, os.WriteFile(recordFile, []byte(tc.record), 0o600))
+				mockOps.EXPECT().RunInHostNamespace("vgs", gomock.Any()).Return(vgsReport, nil)
+				mockOps.EXPECT().RunInHostNamespace("lvs", gomock.Any()).Return(tc.lvsReport, nil)
+			}
+
+			pp := NewPostPivot(nil, logrus.New(), mockOps, "", tmpDir, "")
+			err := pp.verifyLvmLayout(recordFile, savedRecordFile)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			_, err = os.Stat(savedRecordFile)
+			assert.Equal(t, tc.record != "", err == nil)
+		})
+	}
+}
+
+func TestSetNewClusterID(t *testing.T) {
+	testcases := []struct {
+		name      string
+		clusterID string
+	}{
+		{
+			name:      "Desired cluster id",
+			clusterID: "5d4a5b1e-7f0c-4d4e-9d8b-2a3c1e6f9b10",
+		},
+		{
+			name: "Generated cluster id",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			assert.NoError(t, v1.AddToScheme(scheme))
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&v1.ClusterVersion{
+				ObjectMeta: metav1.ObjectMeta{Name: "version"},
+				Spec:       v1.ClusterVersionSpec{ClusterID: "f0a3c9b2-3c0e-4b5a-8c1d-6f3a9e2b7d41"},
+			}).Build()
+
+			pp := NewPostPivot(scheme, logrus.New(), nil, "", t.TempDir(), "")
+			assert.NoError(t, pp.setNewClusterID(context.TODO(), client,
+				&clusterconfig_api.SeedReconfiguration{ClusterID: tc.clusterID}))
+
+			clusterVersion := &v1.ClusterVersion{}
+			assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{Name: "version"}, clusterVersion))
+			clusterID := string(clusterVersion.Spec.ClusterID)
+			assert.NotEqual(t, "f0a3c9b2-3c0e-4b5a-8c1d-6f3a9e2b7d41", clusterID)
+			if tc.clusterID != "" {
+				assert.Equal(t, tc.clusterID, clusterID)
+			}
+			_, err := uuid.Parse(clusterID)
+			assert.NoError(t, err)
+		})
+	}
+}
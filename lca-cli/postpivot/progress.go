@@ -0,0 +1,123 @@
+package postpivot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// progressFile is where the post-pivot progress is recorded, to be merged into the IBU status by the controller
+var progressFile = common.PostPivotProgressFile
+
+// stepTimeoutReasons tell what didn't happen in time when the step of the same name times out
+var stepTimeoutReasons = map[string]string{
+	common.PostPivotStepWaitForConfiguration: "the configuration was not found",
+	common.PostPivotStepNetworkConfiguration: "the network was not configured",
+	common.PostPivotStepPullRecertImage:      "the recert image was not pulled",
+	common.PostPivotStepRecert:               "recert did not complete",
+	common.PostPivotStepStartCluster:         "the cluster API did not come up",
+	common.PostPivotStepApplyManifests:       "the manifests were not applied",
+}
+
+// progressTracker records the post-pivot steps, with their timing and outcome, in a progress file. Post-pivot runs
+// before the cluster API is available, so this is the only way to report its progress to the cluster. The steps
+// recorded by previous runs (e.g. before the service was restarted on failure) are kept.
+type progressTracker struct {
+	log   *logrus.Logger
+	file  string
+	steps []lcav1alpha1.PostPivotStep
+	// timeouts are the timeouts of the steps, by step name, steps without one are not bounded
+	timeouts map[string]time.Duration
+}
+
+func newProgressTracker(log *logrus.Logger, file string) *progressTracker {
+	t := &progressTracker{log: log, file: file}
+	if err := utils.ReadYamlOrJSONFile(file, &t.steps); err != nil && !os.IsNotExist(err) {
+		log.Warnf("Failed to read post pivot progress from %s, starting over: %v", file, err)
+		t.steps = nil
+	}
+	return t
+}
+
+// track runs the named step, recording when it started and completed and its error, if any
+func (t *progressTracker) track(ctx context.Context, name string, f func(ctx context.Context) error) error {
+	step := t.step(name)
+	step.StartedAt = metav1.NewTime(time.Now())
+	step.CompletedAt = metav1.Time{}
+	t.save()
+
+	err := t.run(ctx, name, f)
+
+	step = t.step(name)
+	step.CompletedAt = metav1.NewTime(time.Now())
+	step.Error = ""
+	if err != nil {
+		step.Error = err.Error()
+	}
+	t.save()
+	return err
+}
+
+// run runs the named step with a context bounded by the step timeout, if any. It returns once the step has stopped,
+// the step being expected to stop on its context being done. A step that fails past its timeout fails with a timeout
+// error.
+func (t *progressTracker) run(ctx context.Context, name string, f func(ctx context.Context) error) error {
+	timeout := t.timeouts[name]
+	if timeout <= 0 {
+		return f(ctx)
+	}
+
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := f(stepCtx)
+	if err == nil || ctx.Err() != nil || !errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	if reason, ok := stepTimeoutReasons[name]; ok {
+		return fmt.Errorf("%s in %s, post pivot step %s timed out: %w", reason, timeout, name, err)
+	}
+	return fmt.Errorf("post pivot step %s did not complete in %s: %w", name, timeout, err)
+}
+
+// step returns the named step, adding it if it wasn't recorded yet
+func (t *progressTracker) step(name string) *lcav1alpha1.PostPivotStep {
+	for i := range t.steps {
+		if t.steps[i].Name == name {
+			return &t.steps[i]
+		}
+	}
+	t.steps = append(t.steps, lcav1alpha1.PostPivotStep{Name: name})
+	return &t.steps[len(t.steps)-1]
+}
+
+// save writes the progress file. Failing to do so is not fatal, as it only affects the reporting.
+func (t *progressTracker) save() {
+	if err := os.MkdirAll(path.Dir(t.file), 0o700); err != nil {
+		t.log.Warnf("Failed to create %s: %v", path.Dir(t.file), err)
+		return
+	}
+	if err := utils.MarshalToFile(t.steps, t.file); err != nil {
+		t.log.Warnf("Failed to write post pivot progress to %s: %v", t.file, err)
+	}
+}
+
+// runOnce runs the named step once, as utils.RunOnce does, and tracks its progress. Steps already done by a previous
+// run keep their recorded progress.
+func (p *PostPivot) runOnce(ctx context.Context, name string, f func(ctx context.Context) error) error {
+	if _, err := os.Stat(path.Join(p.workingDir, name+".done")); err == nil {
+		return utils.RunOnce(name, p.workingDir, p.log, f, ctx)
+	}
+	return p.progress.track(ctx, name, func(ctx context.Context) error {
+		return utils.RunOnce(name, p.workingDir, p.log, f, ctx)
+	})
+}
@@ -0,0 +1,154 @@
+package postpivot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+func TestProgressTracker(t *testing.T) {
+	log := &logrus.Logger{}
+	file := path.Join(t.TempDir(), "lca", "postpivot_progress.json")
+
+	readSteps := func() []lcav1alpha1.PostPivotStep {
+		var steps []lcav1alpha1.PostPivotStep
+		assert.NoError(t, utils.ReadYamlOrJSONFile(file, &steps))
+		return steps
+	}
+
+	tracker := newProgressTracker(log, file)
+	assert.NoError(t, tracker.track(context.TODO(), "first", func(context.Context) error {
+		// The step is recorded as started, but not completed, while running
+		steps := readSteps()
+		assert.Len(t, steps, 1)
+		assert.Equal(t, "first", steps[0].Name)
+		assert.False(t, steps[0].StartedAt.IsZero())
+		assert.True(t, steps[0].CompletedAt.IsZero())
+		return nil
+	}))
+	assert.EqualError(t, tracker.track(context.TODO(), "second", func(context.Context) error { return fmt.Errorf("boom") }), "boom")
+
+	steps := readSteps()
+	assert.Len(t, steps, 2)
+	assert.Equal(t, "first", steps[0].Name)
+	assert.False(t, steps[0].CompletedAt.IsZero())
+	assert.Empty(t, steps[0].Error)
+	assert.Equal(t, "second", steps[1].Name)
+	assert.Equal(t, "boom", steps[1].Error)
+
+	// A new run, e.g. after the service restarted on failure, keeps the recorded steps and clears the error of the
+	// failed step once it succeeds
+	tracker = newProgressTracker(log, file)
+	assert.NoError(t, tracker.track(context.TODO(), "second", func(context.Context) error { return nil }))
+
+	steps = readSteps()
+	assert.Len(t, steps, 2)
+	assert.Equal(t, "first", steps[0].Name)
+	assert.Equal(t, "second", steps[1].Name)
+	assert.Empty(t, steps[1].Error)
+}
+
+func TestProgressTrackerTimeouts(t *testing.T) {
+	tracker := newProgressTracker(&logrus.Logger{}, path.Join(t.TempDir(), "postpivot_progress.json"))
+	tracker.timeouts = map[string]time.Duration{
+		common.PostPivotStepStartCluster: 10 * time.Millisecond,
+		"custom":                         10 * time.Millisecond,
+		"late":                           10 * time.Millisecond,
+		"fast":                           time.Minute,
+	}
+	// blocked stops once its context is done, the tracker only returning once it has
+	stopped := false
+	blocked := func(ctx context.Context) error {
+		<-ctx.Done()
+		stopped = true
+		return ctx.Err()
+	}
+
+	assert.EqualError(t, tracker.track(context.TODO(), common.PostPivotStepStartCluster, blocked),
+		"the cluster API did not come up in 10ms, post pivot step start_cluster timed out: context deadline exceeded")
+	assert.True(t, stopped)
+	assert.EqualError(t, tracker.track(context.TODO(), "custom", blocked),
+		"post pivot step custom did not complete in 10ms: context deadline exceeded")
+	// A step completing past its timeout, without checking its context, still completes
+	assert.NoError(t, tracker.track(context.TODO(), "late", func(context.Context) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}))
+	assert.EqualError(t, tracker.track(context.TODO(), "fast", func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		return fmt.Errorf("boom")
+	}), "boom")
+	assert.NoError(t, tracker.track(context.TODO(), "unbounded", func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+		return nil
+	}))
+
+	// The step is not failed with a timeout when the parent context is done
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.EqualError(t, tracker.track(ctx, "custom", blocked), "context canceled")
+
+	assert.Len(t, tracker.steps, 5)
+	assert.Equal(t, "the cluster API did not come up in 10ms, post pivot step start_cluster timed out: context deadline exceeded",
+		tracker.steps[0].Error)
+	assert.False(t, tracker.steps[0].CompletedAt.IsZero())
+}
+
+func TestSetStepTimeouts(t *testing.T) {
+	pp := NewPostPivot(nil, &logrus.Logger{}, nil, "", t.TempDir(), "")
+	assert.Equal(t, 10*time.Minute, pp.stepTimeouts[common.PostPivotStepPullRecertImage])
+
+	assert.NoError(t, pp.SetStepTimeouts(map[string]int{
+		common.PostPivotStepStartCluster:    1200,
+		common.PostPivotStepPullRecertImage: 300,
+	}))
+	assert.Equal(t, map[string]time.Duration{
+		common.PostPivotStepStartCluster:    20 * time.Minute,
+		common.PostPivotStepPullRecertImage: 5 * time.Minute,
+	}, pp.stepTimeouts)
+
+	assert.ErrorContains(t, pp.SetStepTimeouts(map[string]int{"start-cluster": 1200}),
+		`invalid post pivot step timeouts, err: unknown post pivot step "start-cluster"`)
+	// Defaults are not shared between post pivot runners
+	assert.Equal(t, 10*time.Minute, defaultStepTimeouts[common.PostPivotStepPullRecertImage])
+}
+
+func TestRunOnceKeepsProgressOfDoneSteps(t *testing.T) {
+	log := &logrus.Logger{}
+	workingDir := t.TempDir()
+	file := path.Join(t.TempDir(), "postpivot_progress.json")
+
+	pp := NewPostPivot(nil, log, nil, "", workingDir, "")
+	pp.progress = newProgressTracker(log, file)
+
+	calls := 0
+	step := func(context.Context) error {
+		calls++
+		return nil
+	}
+
+	assert.NoError(t, pp.runOnce(context.TODO(), "step", step))
+	steps := pp.progress.steps
+	assert.Len(t, steps, 1)
+	completedAt := steps[0].CompletedAt
+
+	_, err := os.Stat(path.Join(workingDir, "step.done"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, pp.runOnce(context.TODO(), "step", step))
+	assert.Equal(t, 1, calls)
+	assert.Len(t, pp.progress.steps, 1)
+	assert.Equal(t, completedAt, pp.progress.steps[0].CompletedAt)
+}
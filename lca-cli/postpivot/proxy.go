@@ -0,0 +1,148 @@
+package postpivot
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path"
+
+	v1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+const (
+	// the manifests have the same names as the ones gathered from the original SNO during an IBU
+	proxyName            = "cluster"
+	proxyFileName        = "proxy.json"
+	userCABundleName     = "user-ca-bundle"
+	userCABundleFileName = userCABundleName + ".json"
+	userCABundleKey      = "ca-bundle.crt"
+)
+
+// userCABundleAnchorFile is where the additional trust bundle is added to the node trust store, as the
+// machine-config-operator does with the user-ca-bundle
+var userCABundleAnchorFile = "/etc/pki/ca-trust/source/anchors/openshift-config-user-ca-bundle.crt"
+
+// setProxyAndTrustBundle renders the proxy and the additional trust bundle provided by the user into the manifests
+// folder, to be applied when the cluster is up, and adds the trust bundle to the node trust store, so that images can
+// be pulled before that
+func (p *PostPivot) setProxyAndTrustBundle(ctx context.Context, seedReconfiguration *clusterconfig_api.SeedReconfiguration, manifestsDir string) error {
+	if seedReconfiguration.Proxy == nil && seedReconfiguration.AdditionalTrustBundle == "" {
+		p.log.Infof("No proxy or additional trust bundle were provided, skipping")
+		return nil
+	}
+
+	if seedReconfiguration.AdditionalTrustBundle != "" {
+		if err := validateTrustBundle(seedReconfiguration.AdditionalTrustBundle); err != nil {
+			return fmt.Errorf("invalid additional trust bundle: %w", err)
+		}
+		if err := p.createUserCABundleManifest(seedReconfiguration.AdditionalTrustBundle,
+			path.Join(manifestsDir, userCABundleFileName)); err != nil {
+			return err
+		}
+		if err := p.updateNodeTrustStore(ctx, seedReconfiguration.AdditionalTrustBundle); err != nil {
+			return err
+		}
+	}
+
+	return p.createProxyManifest(seedReconfiguration, path.Join(manifestsDir, proxyFileName))
+}
+
+// validateTrustBundle checks that the bundle is made of PEM-encoded X.509 certificates only
+func validateTrustBundle(bundle string) error {
+	rest := []byte(bundle)
+	certificates := 0
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected %s PEM block", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certificates++
+	}
+	if certificates == 0 {
+		return fmt.Errorf("no PEM-encoded certificate found")
+	}
+	return nil
+}
+
+// createUserCABundleManifest creates the user-ca-bundle configmap, referenced by the proxy trustedCA, in the manifests
+// folder
+func (p *PostPivot) createUserCABundleManifest(bundle, manifest string) error {
+	p.log.Infof("Creating user ca bundle manifest %s", manifest)
+	cm := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      userCABundleName,
+			Namespace: common.OpenshiftConfigNamespace,
+		},
+		Data: map[string]string{userCABundleKey: bundle},
+	}
+	typeMeta, err := utils.TypeMetaForObject(p.scheme, &cm)
+	if err != nil {
+		return fmt.Errorf("failed to create typeMeta for user ca bundle, err: %w", err)
+	}
+	cm.TypeMeta = *typeMeta
+
+	if err := utils.MarshalToFile(cm, manifest); err != nil {
+		return fmt.Errorf("failed to marshal user ca bundle into file, err: %w", err)
+	}
+	return nil
+}
+
+// createProxyManifest creates the cluster-wide proxy in the manifests folder. Only the provided settings are set, as
+// the manifest is applied on top of the seed cluster proxy.
+func (p *PostPivot) createProxyManifest(seedReconfiguration *clusterconfig_api.SeedReconfiguration, manifest string) error {
+	p.log.Infof("Creating proxy manifest %s", manifest)
+	proxy := v1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: proxyName,
+		},
+	}
+	if seedReconfiguration.Proxy != nil {
+		proxy.Spec.HTTPProxy = seedReconfiguration.Proxy.HTTPProxy
+		proxy.Spec.HTTPSProxy = seedReconfiguration.Proxy.HTTPSProxy
+		proxy.Spec.NoProxy = seedReconfiguration.Proxy.NoProxy
+	}
+	if seedReconfiguration.AdditionalTrustBundle != "" {
+		proxy.Spec.TrustedCA = v1.ConfigMapNameReference{Name: userCABundleName}
+	}
+	typeMeta, err := utils.TypeMetaForObject(p.scheme, &proxy)
+	if err != nil {
+		return fmt.Errorf("failed to create typeMeta for proxy, err: %w", err)
+	}
+	proxy.TypeMeta = *typeMeta
+
+	if err := utils.MarshalToFile(proxy, manifest); err != nil {
+		return fmt.Errorf("failed to marshal proxy into file, err: %w", err)
+	}
+	return nil
+}
+
+// updateNodeTrustStore adds the bundle as a trust anchor and regenerates the extracted trust store, common.CABundleFilePath
+func (p *PostPivot) updateNodeTrustStore(ctx context.Context, bundle string) error {
+	p.log.Infof("Adding additional trust bundle to %s", userCABundleAnchorFile)
+	if err := os.MkdirAll(path.Dir(userCABundleAnchorFile), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s, err: %w", path.Dir(userCABundleAnchorFile), err)
+	}
+	if err := os.WriteFile(userCABundleAnchorFile, []byte(bundle), 0o600); err != nil {
+		return fmt.Errorf("failed to write additional trust bundle to %s, err: %w", userCABundleAnchorFile, err)
+	}
+
+	if _, err := p.runInHostNamespace(ctx, "update-ca-trust", "extract"); err != nil {
+		return fmt.Errorf("failed to update %s, err: %w", common.CABundleFilePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package postpivot
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	v1 "github.com/openshift/api/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+func testCertificatePEM(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestValidateTrustBundle(t *testing.T) {
+	certificate := testCertificatePEM(t)
+	assert.NoError(t, validateTrustBundle(certificate))
+	assert.NoError(t, validateTrustBundle(certificate+certificate))
+	assert.Error(t, validateTrustBundle(""))
+	assert.Error(t, validateTrustBundle("not a certificate"))
+	assert.Error(t, validateTrustBundle(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")}))))
+	assert.Error(t, validateTrustBundle(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("bad")}))))
+}
+
+func TestSetProxyAndTrustBundle(t *testing.T) {
+	defer func(anchorFile string) { userCABundleAnchorFile = anchorFile }(userCABundleAnchorFile)
+	certificate := testCertificatePEM(t)
+
+	testcases := []struct {
+		name                  string
+		proxy                 *clusterconfig_api.Proxy
+		additionalTrustBundle string
+		updateCATrustError    error
+		expectedProxy         *v1.ProxySpec
+		expectedError         bool
+	}{
+		{
+			name:          "Nothing provided",
+			expectedProxy: nil,
+		},
+		{
+			name:          "Proxy only",
+			proxy:         &clusterconfig_api.Proxy{HTTPProxy: "http://proxy:3128", HTTPSProxy: "http://proxy:3129", NoProxy: ".example.com"},
+			expectedProxy: &v1.ProxySpec{HTTPProxy: "http://proxy:3128", HTTPSProxy: "http://proxy:3129", NoProxy: ".example.com"},
+		},
+		{
+			name:                  "Proxy and trust bundle",
+			proxy:                 &clusterconfig_api.Proxy{HTTPSProxy: "http://proxy:3129"},
+			additionalTrustBundle: certificate,
+			expectedProxy:         &v1.ProxySpec{HTTPSProxy: "http://proxy:3129", TrustedCA: v1.ConfigMapNameReference{Name: userCABundleName}},
+		},
+		{
+			name:                  "Trust bundle only",
+			additionalTrustBundle: certificate,
+			expectedProxy:         &v1.ProxySpec{TrustedCA: v1.ConfigMapNameReference{Name: userCABundleName}},
+		},
+		{
+			name:                  "Invalid trust bundle",
+			additionalTrustBundle: "bad",
+			expectedError:         true,
+		},
+		{
+			name:                  "Failed to update the trust store",
+			additionalTrustBundle: certificate,
+			updateCATrustError:    fmt.Errorf("failed"),
+			expectedError:         true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			mockOps := ops.NewMockOps(mockController)
+			defer mockController.Finish()
+
+			tmpDir := t.TempDir()
+			manifestsDir := path.Join(tmpDir, "manifests")
+			assert.NoError(t, os.MkdirAll(manifestsDir, 0o700))
+			userCABundleAnchorFile = path.Join(tmpDir, "anchors", "openshift-config-user-ca-bundle.crt")
+
+			scheme := runtime.NewScheme()
+			assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+			assert.NoError(t, v1.AddToScheme(scheme))
+			pp := NewPostPivot(scheme, &logrus.Logger{}, mockOps, "", tmpDir, "")
+
+			if tc.additionalTrustBundle == certificate {
+				mockOps.EXPECT().RunInHostNamespace("update-ca-trust", "extract").Return("", tc.updateCATrustError).Times(1)
+			}
+
+			err := pp.setProxyAndTrustBundle(context.TODO(), &clusterconfig_api.SeedReconfiguration{
+				Proxy:                 tc.proxy,
+				AdditionalTrustBundle: tc.additionalTrustBundle,
+			}, manifestsDir)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			if tc.expectedError {
+				return
+			}
+
+			proxy := &v1.Proxy{}
+			err = utils.ReadYamlOrJSONFile(path.Join(manifestsDir, proxyFileName), proxy)
+			if tc.expectedProxy == nil {
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, proxyName, proxy.Name)
+			assert.Equal(t, "Proxy", proxy.Kind)
+			assert.Equal(t, *tc.expectedProxy, proxy.Spec)
+
+			cm := &corev1.ConfigMap{}
+			err = utils.ReadYamlOrJSONFile(path.Join(manifestsDir, userCABundleFileName), cm)
+			anchor, anchorErr := os.ReadFile(userCABundleAnchorFile)
+			if tc.additionalTrustBundle == "" {
+				assert.True(t, os.IsNotExist(err))
+				assert.True(t, os.IsNotExist(anchorErr))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, userCABundleName, cm.Name)
+			assert.Equal(t, "openshift-config", cm.Namespace)
+			assert.Equal(t, certificate, cm.Data[userCABundleKey])
+			assert.NoError(t, anchorErr)
+			assert.Equal(t, certificate, string(anchor))
+		})
+	}
+}
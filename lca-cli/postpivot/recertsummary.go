@@ -0,0 +1,52 @@
+package postpivot
+
+import (
+	"os"
+	"path"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/recert"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+var (
+	// recertSummaryFile and recertSeedSummaryFile are the summaries written by recert during the post-pivot
+	// configuration and during the seed creation
+	recertSummaryFile     = recert.SummaryFile
+	recertSeedSummaryFile = "/etc/kubernetes/recert-seed-summary.yaml"
+
+	// recertSummariesDir is where the recert summaries are kept for later forensics, along with the digest merged into
+	// the IBU status by the controller
+	recertSummariesDir      = common.LCAConfigDir
+	recertSummaryDigestFile = common.RecertSummaryDigestFile
+)
+
+// recordRecertSummary keeps the recert summaries in the stateroot and writes a digest of the post-pivot one, to be
+// added to the IBU status by the controller. Failing to do so is not fatal, as it only affects the reporting.
+func (p *PostPivot) recordRecertSummary(seedClusterInfo *seedclusterinfo.SeedClusterInfo) {
+	for _, summaryFile := range []string{recertSummaryFile, recertSeedSummaryFile} {
+		if err := utils.CopyFileIfExists(summaryFile, path.Join(recertSummariesDir, path.Base(summaryFile))); err != nil {
+			p.log.Warnf("Failed to keep recert summary %s: %v", summaryFile, err)
+		}
+	}
+
+	summary, err := os.ReadFile(recertSummaryFile)
+	if err != nil {
+		p.log.Warnf("Failed to read recert summary %s: %v", recertSummaryFile, err)
+		return
+	}
+	digest, err := recert.DigestSummary(summary, seedClusterInfo)
+	if err != nil {
+		p.log.Warnf("Failed to digest recert summary %s: %v", recertSummaryFile, err)
+		return
+	}
+	p.log.Info(utils.RecertSummaryMessage(digest))
+	if err := os.MkdirAll(path.Dir(recertSummaryDigestFile), 0o700); err != nil {
+		p.log.Warnf("Failed to create %s: %v", path.Dir(recertSummaryDigestFile), err)
+		return
+	}
+	if err := utils.MarshalToFile(digest, recertSummaryDigestFile); err != nil {
+		p.log.Warnf("Failed to write recert summary digest to %s: %v", recertSummaryDigestFile, err)
+	}
+}
@@ -0,0 +1,80 @@
+package postpivot
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+var (
+	testRecertSeedClusterInfo = &seedclusterinfo.SeedClusterInfo{
+		SNOHostname: "seed",
+		ClusterName: "seed",
+		BaseDomain:  "redhat.com",
+		NodeIP:      "192.168.127.10",
+	}
+	// testRecertSummary follows the layout of the summary written by recert, trimmed to a few crypto objects
+	testRecertSummary = `cluster_crypto:
+  cert_key_pairs:
+  - distributed_private_key:
+      locations:
+      - k8s:Secret/openshift-kube-apiserver-operator/localhost-serving-signer:/data/tls.key
+    distributed_cert:
+      certificate_subject: CN=localhost-serving-signer,OU=openshift
+    signees: []
+  - distributed_private_key: null
+    distributed_cert:
+      certificate_subject: CN=admin-kubeconfig-signer,OU=openshift
+    signees: []
+  distributed_jwts: []
+config:
+  cluster_customizations:
+    hostname: sno
+run_times:
+  scan_run_time: 1s 212ms
+`
+)
+
+func TestRecordRecertSummary(t *testing.T) {
+	defer func(summaryFile, seedSummaryFile, summariesDir, digestFile string) {
+		recertSummaryFile = summaryFile
+		recertSeedSummaryFile = seedSummaryFile
+		recertSummariesDir = summariesDir
+		recertSummaryDigestFile = digestFile
+	}(recertSummaryFile, recertSeedSummaryFile, recertSummariesDir, recertSummaryDigestFile)
+
+	tmpDir := t.TempDir()
+	recertSummaryFile = path.Join(tmpDir, "tmp", "recert-summary.yaml")
+	recertSeedSummaryFile = path.Join(tmpDir, "kubernetes", "recert-seed-summary.yaml")
+	recertSummariesDir = path.Join(tmpDir, "lca")
+	recertSummaryDigestFile = path.Join(recertSummariesDir, "recert_summary_digest.json")
+	pp := NewPostPivot(nil, &logrus.Logger{}, nil, "", tmpDir, "")
+
+	// No summary, nothing recorded
+	pp.recordRecertSummary(testRecertSeedClusterInfo)
+	_, err := os.Stat(recertSummaryDigestFile)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, os.MkdirAll(path.Dir(recertSummaryFile), 0o700))
+	assert.NoError(t, os.WriteFile(recertSummaryFile, []byte(testRecertSummary), 0o600))
+	pp.recordRecertSummary(testRecertSeedClusterInfo)
+
+	kept, err := os.ReadFile(path.Join(recertSummariesDir, "recert-summary.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, testRecertSummary, string(kept))
+	_, err = os.Stat(path.Join(recertSummariesDir, "recert-seed-summary.yaml"))
+	assert.True(t, os.IsNotExist(err))
+
+	digest := &lcav1alpha1.RecertSummary{}
+	assert.NoError(t, utils.ReadYamlOrJSONFile(recertSummaryDigestFile, digest))
+	assert.Equal(t, 2, digest.Certificates)
+	assert.Equal(t, 1, digest.Keys)
+	assert.Equal(t, []string{"hostname: seed -> sno"}, digest.Renames)
+}
@@ -0,0 +1,158 @@
+package postpivot
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+const (
+	chronyService       = "chronyd.service"
+	timeMachineConfig   = "99-%s-time"
+	timezoneService     = "lca-timezone.service"
+	chronyWaitSyncTries = "6"
+)
+
+var (
+	chronyConfFile = "/etc/chrony.conf"
+	zoneinfoDir    = "/usr/share/zoneinfo"
+)
+
+// setTimeConfiguration configures the NTP sources and the timezone provided by the user. The node is configured right
+// away, so that the time is correct before etcd starts and the certificates validity is checked, and a MachineConfig
+// per role is rendered into the manifests folder so that the machine-config-operator keeps the configuration, in
+// place of the seed one.
+func (p *PostPivot) setTimeConfiguration(ctx context.Context, seedReconfiguration *clusterconfig_api.SeedReconfiguration, manifestsDir string) error {
+	if len(seedReconfiguration.NTPSources) == 0 && seedReconfiguration.Timezone == "" {
+		p.log.Infof("No NTP sources or timezone were provided, skipping")
+		return nil
+	}
+
+	var chronyConf string
+	if len(seedReconfiguration.NTPSources) > 0 {
+		chronyConf = renderChronyConf(seedReconfiguration.NTPSources)
+		p.log.Infof("Writing NTP sources to %s", chronyConfFile)
+		if err := os.WriteFile(chronyConfFile, []byte(chronyConf), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s, err: %w", chronyConfFile, err)
+		}
+		if _, err := p.systemctlAction(ctx, "restart", chronyService); err != nil {
+			return fmt.Errorf("failed to restart %s, err: %w", chronyService, err)
+		}
+		// not being able to reach the NTP sources yet shouldn't fail the configuration, chrony keeps trying
+		p.log.Info("Waiting for the clock to be synchronized")
+		if _, err := p.runInHostNamespace(ctx, "chronyc", "waitsync", chronyWaitSyncTries); err != nil {
+			p.log.Warnf("Clock is not synchronized yet, continuing: %v", err)
+		}
+	}
+
+	if seedReconfiguration.Timezone != "" {
+		if _, err := os.Stat(path.Join(zoneinfoDir, seedReconfiguration.Timezone)); err != nil {
+			return fmt.Errorf("unknown timezone %s, err: %w", seedReconfiguration.Timezone, err)
+		}
+		p.log.Infof("Setting timezone to %s", seedReconfiguration.Timezone)
+		if _, err := p.runInHostNamespace(ctx, "timedatectl", "set-timezone", seedReconfiguration.Timezone); err != nil {
+			return fmt.Errorf("failed to set timezone to %s, err: %w", seedReconfiguration.Timezone, err)
+		}
+	}
+
+	return p.createTimeMachineConfigs(chronyConf, seedReconfiguration.Timezone, manifestsDir)
+}
+
+// renderChronyConf renders the chrony configuration of the NTP sources, with the same settings as the default RHCOS one
+func renderChronyConf(ntpSources []string) string {
+	var b strings.Builder
+	b.WriteString("# Generated by the lifecycle agent from the seed reconfiguration\n")
+	for _, source := range ntpSources {
+		fmt.Fprintf(&b, "server %s iburst\n", source)
+	}
+	b.WriteString("driftfile /var/lib/chrony/drift\n")
+	b.WriteString("makestep 1.0 3\n")
+	b.WriteString("rtcsync\n")
+	b.WriteString("keyfile /etc/chrony.keys\n")
+	b.WriteString("leapsectz right/UTC\n")
+	b.WriteString("logdir /var/log/chrony\n")
+	return b.String()
+}
+
+// createTimeMachineConfigs creates the worker and master MachineConfigs of the chrony configuration, when set, and of
+// a unit setting the timezone, when set
+func (p *PostPivot) createTimeMachineConfigs(chronyConf, timezone, manifestsDir string) error {
+	p.log.Info("Creating worker and master machine configs with provided time configuration, in order to override seed's")
+	ignConfig := map[string]any{
+		"ignition": map[string]string{"version": "3.2.0"},
+	}
+	if chronyConf != "" {
+		ignConfig["storage"] = map[string]any{
+			"files": []any{
+				map[string]any{
+					"path":      chronyConfFile,
+					"mode":      0o644,
+					"overwrite": true,
+					"contents": map[string]string{
+						"source": "data:text/plain;charset=utf-8;base64," + base64.StdEncoding.EncodeToString([]byte(chronyConf)),
+					},
+				}},
+		}
+	}
+	if timezone != "" {
+		ignConfig["systemd"] = map[string]any{
+			"units": []any{
+				map[string]any{
+					"name":     timezoneService,
+					"enabled":  true,
+					"contents": renderTimezoneUnit(timezone),
+				}},
+		}
+	}
+	rawExt, err := utils.ConvertToRawExtension(ignConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range []string{"master", "worker"} {
+		mc := &mcfgv1.MachineConfig{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: mcfgv1.SchemeGroupVersion.String(),
+				Kind:       "MachineConfig",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf(timeMachineConfig, role),
+				Labels: map[string]string{
+					"machineconfiguration.openshift.io/role": role,
+				},
+			},
+			Spec: mcfgv1.MachineConfigSpec{
+				Config: rawExt,
+			},
+		}
+		if err := utils.MarshalToFile(mc, path.Join(manifestsDir, fmt.Sprintf(timeMachineConfig, role)+".json")); err != nil {
+			return fmt.Errorf("failed to marshal time configuration into file for role %s, err: %w", role, err)
+		}
+	}
+	return nil
+}
+
+// renderTimezoneUnit renders a unit setting the timezone on boot, as the machine-config-operator doesn't manage it
+func renderTimezoneUnit(timezone string) string {
+	return fmt.Sprintf(`[Unit]
+Description=Set the timezone to %[1]s
+Before=kubelet.service
+
+[Service]
+Type=oneshot
+ExecStart=/usr/bin/timedatectl set-timezone %[1]s
+RemainAfterExit=yes
+
+[Install]
+WantedBy=multi-user.target
+`, timezone)
+}
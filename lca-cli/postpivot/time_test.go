@@ -0,0 +1,125 @@
+package postpivot
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+func TestRenderChronyConf(t *testing.T) {
+	chronyConf := renderChronyConf([]string{"ntp1.example.com", "192.168.127.1"})
+	assert.True(t, strings.HasPrefix(chronyConf, `# Generated by the lifecycle agent from the seed reconfiguration
+server ntp1.example.com iburst
+server 192.168.127.1 iburst
+driftfile /var/lib/chrony/drift
+makestep 1.0 3
+`))
+}
+
+func TestSetTimeConfiguration(t *testing.T) {
+	defer func(conf, zoneinfo string) {
+		chronyConfFile = conf
+		zoneinfoDir = zoneinfo
+	}(chronyConfFile, zoneinfoDir)
+
+	testcases := []struct {
+		name          string
+		ntpSources    []string
+		timezone      string
+		waitSyncError error
+		expectedError bool
+	}{
+		{
+			name: "Nothing provided",
+		},
+		{
+			name:       "NTP sources",
+			ntpSources: []string{"ntp1.example.com"},
+		},
+		{
+			name:          "NTP sources not reachable yet",
+			ntpSources:    []string{"ntp1.example.com"},
+			waitSyncError: fmt.Errorf("exit status 1"),
+		},
+		{
+			name:       "NTP sources and timezone",
+			ntpSources: []string{"ntp1.example.com"},
+			timezone:   "Europe/Paris",
+		},
+		{
+			name:          "Unknown timezone",
+			timezone:      "Europe/Nowhere",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			mockOps := ops.NewMockOps(mockController)
+			defer mockController.Finish()
+
+			tmpDir := t.TempDir()
+			manifestsDir := path.Join(tmpDir, "manifests")
+			assert.NoError(t, os.MkdirAll(manifestsDir, 0o700))
+			chronyConfFile = path.Join(tmpDir, "chrony.conf")
+			assert.NoError(t, os.WriteFile(chronyConfFile, []byte("seed"), 0o600))
+			zoneinfoDir = path.Join(tmpDir, "zoneinfo")
+			assert.NoError(t, os.MkdirAll(path.Join(zoneinfoDir, "Europe"), 0o700))
+			assert.NoError(t, os.WriteFile(path.Join(zoneinfoDir, "Europe", "Paris"), []byte("TZif"), 0o600))
+
+			if len(tc.ntpSources) > 0 {
+				mockOps.EXPECT().SystemctlAction("restart", chronyService).Return("", nil)
+				mockOps.EXPECT().RunInHostNamespace("chronyc", "waitsync", chronyWaitSyncTries).Return("", tc.waitSyncError)
+			}
+			if tc.timezone != "" && !tc.expectedError {
+				mockOps.EXPECT().RunInHostNamespace("timedatectl", "set-timezone", tc.timezone).Return("", nil)
+			}
+
+			pp := NewPostPivot(nil, &logrus.Logger{}, mockOps, "", tmpDir, "")
+			err := pp.setTimeConfiguration(context.TODO(), &clusterconfig_api.SeedReconfiguration{
+				NTPSources: tc.ntpSources,
+				Timezone:   tc.timezone,
+			}, manifestsDir)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			if tc.expectedError {
+				return
+			}
+
+			chronyConf, err := os.ReadFile(chronyConfFile)
+			assert.NoError(t, err)
+			mc := &mcfgv1.MachineConfig{}
+			mcErr := utils.ReadYamlOrJSONFile(path.Join(manifestsDir, fmt.Sprintf(timeMachineConfig, "master")+".json"), mc)
+			if len(tc.ntpSources) == 0 && tc.timezone == "" {
+				assert.Equal(t, "seed", string(chronyConf))
+				assert.True(t, os.IsNotExist(mcErr))
+				return
+			}
+			assert.NoError(t, mcErr)
+			assert.Equal(t, "master", mc.Labels["machineconfiguration.openshift.io/role"])
+			assert.Contains(t, string(chronyConf), "server ntp1.example.com iburst")
+			assert.Contains(t, string(mc.Spec.Config.Raw), base64.StdEncoding.EncodeToString(chronyConf))
+			if tc.timezone != "" {
+				assert.Contains(t, string(mc.Spec.Config.Raw), timezoneService)
+				assert.Contains(t, string(mc.Spec.Config.Raw), "set-timezone "+tc.timezone)
+			} else {
+				assert.NotContains(t, string(mc.Spec.Config.Raw), timezoneService)
+			}
+			_, err = os.Stat(path.Join(manifestsDir, fmt.Sprintf(timeMachineConfig, "worker")+".json"))
+			assert.NoError(t, err)
+		})
+	}
+}
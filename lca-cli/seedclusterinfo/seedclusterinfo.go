@@ -1,6 +1,7 @@
 package seedclusterinfo
 
 import (
+	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
 	"github.com/openshift-kni/lifecycle-agent/utils"
 )
 
@@ -31,11 +32,36 @@ type SeedClusterInfo struct {
 	// See BaseDomain documentation above.
 	ClusterName string `json:"cluster_name,omitempty"`
 
+	// The cluster ID of the seed cluster. It is recorded post pivot in the
+	// identity record mapping the seed cluster to the new one. Seed images
+	// created before the identity record was introduced don't have it.
+	ClusterID string `json:"cluster_id,omitempty"`
+
 	// The IP of the seed cluster's SNO node. This is used when we sed the IP
 	// address of the seed to replace it with the desired IP address of the
 	// cluster.
 	NodeIP string `json:"node_ip,omitempty"`
 
+	// The IPs of the seed cluster's SNO node, one per IP family on a
+	// dual-stack seed, the primary one (NodeIP) first. Each of them is
+	// replaced with the desired IP of the same family. Seed images created
+	// before dual-stack support only have NodeIP.
+	NodeIPs []string `json:"node_ips,omitempty"`
+
+	// The machine network CIDRs of the seed cluster, one per IP family on a
+	// dual-stack seed.
+	MachineNetworks []string `json:"machine_networks,omitempty"`
+
+	// The cluster network of the seed cluster. When the desired cluster
+	// network differs, its CIDRs are replaced in the cluster configuration.
+	// Seed images created before network reconfiguration support don't have
+	// it, so their cluster network can't be changed.
+	ClusterNetworks []seedreconfig.ClusterNetworkEntry `json:"cluster_networks,omitempty"`
+
+	// The service network CIDRs of the seed cluster. See ClusterNetworks
+	// documentation above.
+	ServiceNetworks []string `json:"service_networks,omitempty"`
+
 	// The container registry used to host the release image of the seed cluster.
 	// TODO: Document what this is for
 	// TODO: Is this really necessary? Find a way to get rid of this
@@ -65,7 +91,12 @@ func NewFromClusterInfo(clusterInfo *utils.ClusterInfo, seedImagePullSpec string
 		SeedClusterOCPVersion:    clusterInfo.OCPVersion,
 		BaseDomain:               clusterInfo.BaseDomain,
 		ClusterName:              clusterInfo.ClusterName,
+		ClusterID:                clusterInfo.ClusterID,
 		NodeIP:                   clusterInfo.NodeIP,
+		NodeIPs:                  clusterInfo.NodeIPs,
+		MachineNetworks:          clusterInfo.MachineNetworks,
+		ClusterNetworks:          clusterInfo.ClusterNetworks,
+		ServiceNetworks:          clusterInfo.ServiceNetworks,
 		ReleaseRegistry:          clusterInfo.ReleaseRegistry,
 		SNOHostname:              clusterInfo.Hostname,
 		MirrorRegistryConfigured: clusterInfo.MirrorRegistryConfigured,
@@ -0,0 +1,492 @@
+package seedcreator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	cp "github.com/otiai10/copy"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	etcdClient "go.etcd.io/etcd/client/v3"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	ostree "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedsbom"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// ostreeAdminStatusDeploymentRegex matches a deployment line of ostree admin status, e.g.
+// "* rhcos ed4ab3244a76c6503a21441da650634b5abd25aba4255ca116782b2b3020519c.1 (rollback)"
+var ostreeAdminStatusDeploymentRegex = regexp.MustCompile(`^[* ] (\S+) ([0-9a-f]{64})\.(\d+)`)
+
+// OfflineSeedCreator creates a seed image out of the ostree sysroot of a seed SNO that is not running, e.g. a mounted
+// disk image or a VM snapshot. As the seed cluster API isn't available, the seed cluster information has to be
+// provided, the Node is removed directly from the etcd data and the steps that only make sense on a running cluster
+// (recert force expiration, stopping kubelet and CRI-O) are skipped. kubelet is still disabled in the deployment, as
+// post pivot only starts it after recert. The sysroot is modified in the process, so it should be a copy that is
+// discarded afterwards.
+type OfflineSeedCreator struct {
+	*SeedCreator
+	sysroot             string
+	seedClusterInfoFile string
+	containerStorage    string
+	servicesDir         string
+}
+
+// NewOfflineSeedCreator is a constructor function for OfflineSeedCreator
+func NewOfflineSeedCreator(log *logrus.Logger, ops ops.Ops, backupDir, sysroot, seedClusterInfoFile,
+	containerStorage, containerRegistry, authFile, recertContainerImage string,
+	additionalImages []SeedImageDestination, pushStatusFile string) *OfflineSeedCreator {
+
+	return &OfflineSeedCreator{
+		SeedCreator: NewSeedCreator(nil, log, ops, nil, backupDir, "", containerRegistry, authFile,
+			recertContainerImage, true, additionalImages, pushStatusFile, WaitTimeouts{}),
+		sysroot:             sysroot,
+		seedClusterInfoFile: seedClusterInfoFile,
+		containerStorage:    containerStorage,
+		servicesDir:         filepath.Join(common.InstallationConfigurationFilesDir, "services"),
+	}
+}
+
+// offlineDeployment is the ostree deployment of the sysroot the seed image is created from
+type offlineDeployment struct {
+	OSName   string
+	Checksum string
+	Serial   int32
+	Version  string
+}
+
+// name returns the deployment name, i.e. <checksum>.<serial>
+func (d *offlineDeployment) name() string {
+	return fmt.Sprintf("%s.%d", d.Checksum, d.Serial)
+}
+
+// parseOstreeAdminStatus returns the default deployment, i.e. the first one, listed in the output of ostree admin status
+func parseOstreeAdminStatus(output string) (*offlineDeployment, error) {
+	var deployment *offlineDeployment
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if matches := ostreeAdminStatusDeploymentRegex.FindStringSubmatch(line); matches != nil {
+			if deployment != nil {
+				// Reached the next deployment
+				break
+			}
+			serial, err := strconv.ParseInt(matches[3], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid deployment serial in %q: %w", line, err)
+			}
+			deployment = &offlineDeployment{OSName: matches[1], Checksum: matches[2], Serial: int32(serial)}
+			continue
+		}
+		if deployment == nil {
+			continue
+		}
+		if version, found := strings.CutPrefix(strings.TrimSpace(line), "Version:"); found {
+			deployment.Version = strings.TrimSpace(version)
+		}
+	}
+	if deployment == nil {
+		return nil, fmt.Errorf("failed to find an ostree deployment in ostree admin status output")
+	}
+	return deployment, nil
+}
+
+// offlineVarExcludePatterns returns the /var backup exclude patterns relative to the stateroot, matching the member
+// names of an archive created from it
+func offlineVarExcludePatterns() []string {
+	return lo.Map(varExcludePatterns, func(pattern string, _ int) string {
+		return strings.TrimPrefix(pattern, "/")
+	})
+}
+
+// parsePodmanImages parses the output of podman images --format json into a list of image references, preferring
+// tags over digests, as done for the images listed on a running seed cluster
+func parsePodmanImages(output string) ([]string, error) {
+	var podmanImages []struct {
+		Names       []string `json:"Names"`
+		RepoDigests []string `json:"RepoDigests"`
+	}
+	if err := json.Unmarshal([]byte(output), &podmanImages); err != nil {
+		return nil, fmt.Errorf("failed to parse podman images output: %w", err)
+	}
+
+	var images []string
+	for _, image := range podmanImages {
+		if len(image.Names) > 0 {
+			images = append(images, image.Names...)
+		} else {
+			images = append(images, image.RepoDigests...)
+		}
+	}
+	return lo.Uniq(images), nil
+}
+
+func (o *OfflineSeedCreator) staterootPath(deployment *offlineDeployment) string {
+	return filepath.Join(o.sysroot, "ostree", "deploy", deployment.OSName)
+}
+
+func (o *OfflineSeedCreator) deploymentDir(deployment *offlineDeployment) string {
+	return filepath.Join(o.staterootPath(deployment), "deploy", deployment.name())
+}
+
+func (o *OfflineSeedCreator) varPath(deployment *offlineDeployment, p string) string {
+	return filepath.Join(o.staterootPath(deployment), p)
+}
+
+// CreateSeedImage comprises the lca-cli workflow for creating a single OCI seed image out of an offline sysroot
+func (o *OfflineSeedCreator) CreateSeedImage() error {
+	o.log.Infof("Creating seed image from sysroot %s", o.sysroot)
+	ctx := context.TODO()
+
+	seedClusterInfo, err := seedclusterinfo.ReadSeedClusterInfoFromFile(o.seedClusterInfoFile)
+	if err != nil {
+		return fmt.Errorf("failed to read seed cluster info from %s: %w", o.seedClusterInfoFile, err)
+	}
+	if seedClusterInfo.SeedClusterOCPVersion == "" || seedClusterInfo.SNOHostname == "" {
+		return fmt.Errorf("seed cluster info %s must at least provide seed_cluster_ocp_version and sno_hostname",
+			o.seedClusterInfoFile)
+	}
+	seedClusterInfo.RecertImagePullSpec = o.recertContainerImage
+
+	deployment, err := o.defaultDeployment()
+	if err != nil {
+		return err
+	}
+	o.log.Infof("Using ostree deployment %s of stateroot %s", deployment.name(), deployment.OSName)
+
+	if err := os.MkdirAll(o.backupDir, 0o700); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(common.BackupChecksDir, 0o700); err != nil {
+		return err
+	}
+
+	if err := utils.RunOnce("offline_install_services", common.BackupChecksDir, o.log, o.installServices, deployment); err != nil {
+		return err
+	}
+
+	if err := utils.RunOnce("offline_save_cluster_info", common.BackupChecksDir, o.log, o.saveClusterInfo, seedClusterInfo, deployment); err != nil {
+		return err
+	}
+
+	if err := utils.RunOnce("offline_create_container_list", common.BackupChecksDir, o.log, o.createContainerList, deployment); err != nil {
+		return err
+	}
+
+	if err := utils.RunOnce("offline_delete_node", common.BackupChecksDir, o.log, o.deleteNode, ctx, seedClusterInfo.SNOHostname, deployment); err != nil {
+		return err
+	}
+
+	o.log.Infof("Removing ovn certs folders")
+	if err := utils.RemoveListOfFolders(o.log, []string{
+		o.varPath(deployment, common.OvnNodeCerts),
+		filepath.Join(o.deploymentDir(deployment), common.MultusCerts),
+	}); err != nil {
+		return err
+	}
+
+	if err := utils.RunOnce("offline_backup_var", common.BackupChecksDir, o.log, o.backupVar, deployment); err != nil {
+		return err
+	}
+
+	if err := utils.RunOnce("offline_backup_etc", common.BackupChecksDir, o.log, o.backupEtc, deployment); err != nil {
+		return err
+	}
+
+	if err := utils.RunOnce("offline_backup_ostree", common.BackupChecksDir, o.log, o.backupOstree); err != nil {
+		return err
+	}
+
+	if err := utils.RunOnce("offline_backup_rpmostree", common.BackupChecksDir, o.log, o.backupRPMOstree, deployment); err != nil {
+		return err
+	}
+
+	if err := utils.RunOnce("offline_backup_mco_config", common.BackupChecksDir, o.log, o.backupMCOConfig, deployment); err != nil {
+		return err
+	}
+
+	if err := utils.RunOnce("offline_create_seed_sbom", common.BackupChecksDir, o.log, o.createSeedSBOM,
+		"--dbpath", filepath.Join(o.deploymentDir(deployment), "usr", "share", "rpm")); err != nil {
+		return err
+	}
+
+	o.log.Info("Build and push OCI image to ", o.containerRegistry)
+	return o.buildAndPushSeedImage()
+}
+
+// defaultDeployment returns the default ostree deployment of the sysroot, i.e. the one it would boot
+func (o *OfflineSeedCreator) defaultDeployment() (*offlineDeployment, error) {
+	output, err := o.ops.RunInHostNamespace("ostree", "admin", "status", "--sysroot", o.sysroot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ostree status of sysroot %s: %w", o.sysroot, err)
+	}
+	return parseOstreeAdminStatus(output)
+}
+
+// installServices installs the seed installation services and lca-cli binary into the deployment and disables
+// kubelet, as done on a running seed cluster
+func (o *OfflineSeedCreator) installServices(deployment *offlineDeployment) error {
+	deploymentDir := o.deploymentDir(deployment)
+	if err := utils.HandleFilesWithCallback(o.servicesDir, func(path string) error {
+		serviceName := filepath.Base(path)
+
+		o.log.Infof("Creating service %s", serviceName)
+		if err := cp.Copy(path, filepath.Join(deploymentDir, "etc", "systemd", "system", serviceName)); err != nil {
+			return err
+		}
+
+		o.log.Infof("Enabling service %s", serviceName)
+		_, err := o.ops.RunInHostNamespace("systemctl", "--root", deploymentDir, "enable", serviceName)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to add configuration files: %w", err)
+	}
+
+	// Post pivot only starts kubelet once recert is done, so it mustn't start at boot against the seed state
+	o.log.Info("Disabling kubelet service")
+	if _, err := o.ops.RunInHostNamespace("systemctl", "--root", deploymentDir, "disable", "kubelet.service"); err != nil {
+		return fmt.Errorf("failed to disable kubelet service: %w", err)
+	}
+
+	// The offline seed creation doesn't necessarily run from the lifecycle-agent image, so copy the running binary
+	lcaCli, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get lca-cli binary path: %w", err)
+	}
+	o.log.Info("Copy lca-cli binary")
+	return cp.Copy(lcaCli, o.varPath(deployment, "/var/usrlocal/bin/lca-cli"), cp.Options{AddPermission: os.FileMode(0o777)})
+}
+
+// saveClusterInfo stores the provided seed cluster info in the seed data dir of the stateroot and the backup dir,
+// and starts the seed SBOM with the seed cluster identity
+func (o *OfflineSeedCreator) saveClusterInfo(seedClusterInfo *seedclusterinfo.SeedClusterInfo, deployment *offlineDeployment) error {
+	o.log.Info("Saving seed cluster configuration")
+	seedDataDir := o.varPath(deployment, common.SeedDataDir)
+	if err := os.MkdirAll(seedDataDir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating %s: %w", seedDataDir, err)
+	}
+
+	o.log.Infof("Creating seed information file in %s", common.SeedClusterInfoFileName)
+	if err := utils.MarshalToFile(seedClusterInfo, path.Join(seedDataDir, common.SeedClusterInfoFileName)); err != nil {
+		return err
+	}
+
+	// in order to allow lca to verify version we need to provide file not as part of var archive too
+	if err := utils.MarshalToFile(seedClusterInfo, path.Join(o.backupDir, common.SeedClusterInfoFileName)); err != nil {
+		return err
+	}
+
+	sbom := &seedsbom.SeedSBOM{
+		SourceCluster: seedsbom.SourceCluster{
+			ClusterName:     seedClusterInfo.ClusterName,
+			BaseDomain:      seedClusterInfo.BaseDomain,
+			OCPVersion:      seedClusterInfo.SeedClusterOCPVersion,
+			ReleaseRegistry: seedClusterInfo.ReleaseRegistry,
+		},
+	}
+	return utils.MarshalToFile(sbom, path.Join(o.backupDir, common.SeedSBOMFileName))
+}
+
+// createContainerList lists the images of the seed container storage. Only the default catalog images can be
+// filtered out, as the catalog sources can't be listed offline.
+func (o *OfflineSeedCreator) createContainerList(deployment *offlineDeployment) error {
+	containerStorage := o.containerStorage
+	if containerStorage == "" {
+		containerStorage = o.varPath(deployment, "/var/lib/containers/storage")
+	}
+
+	o.log.Infof("Save list of images in %s", containerStorage)
+	output, err := o.ops.RunInHostNamespace("podman", "--root", containerStorage, "images", "--format", "json")
+	if err != nil {
+		return fmt.Errorf("failed to list images in %s: %w", containerStorage, err)
+	}
+	images, err := parsePodmanImages(output)
+	if err != nil {
+		return err
+	}
+	images = lo.Filter(images, func(image string, _ int) bool {
+		return !defaultCatalogsRegex.MatchString(image)
+	})
+	o.log.Infof("Adding recert %s image to image list", o.recertContainerImage)
+	images = append(images, o.recertContainerImage)
+
+	containersListFileName := path.Join(o.backupDir, "containers.list")
+	o.log.Infof("Creating %s file", containersListFileName)
+	if err := os.WriteFile(containersListFileName, []byte(strings.Join(images, "\n")), 0o600); err != nil {
+		return fmt.Errorf("failed to write container list file %s, err %w", containersListFileName, err)
+	}
+	return nil
+}
+
+// deleteNode removes the seed Node and its lease directly from the etcd data of the stateroot, using an
+// unauthenticated etcd server
+func (o *OfflineSeedCreator) deleteNode(ctx context.Context, nodeName string, deployment *offlineDeployment) error {
+	etcdPodFile := filepath.Join(o.deploymentDir(deployment), common.EtcdStaticPodFile)
+	if err := o.ops.RunUnauthenticatedEtcdServer(o.authFile, common.EtcdContainerName, etcdPodFile,
+		o.varPath(deployment, "/var/lib/etcd")); err != nil {
+		return fmt.Errorf("failed to run etcd server: %w", err)
+	}
+	defer func() {
+		if _, err := o.ops.RunInHostNamespace("podman", "stop", common.EtcdContainerName); err != nil {
+			o.log.Errorf("Failed to stop etcd server: %v", err)
+		}
+	}()
+
+	cli, err := etcdClient.New(etcdClient.Config{
+		Endpoints:   []string{common.EtcdDefaultEndpoint},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create etcd client: %w", err)
+	}
+	defer cli.Close()
+
+	for _, key := range []string{
+		"/kubernetes.io/minions/" + nodeName,
+		"/kubernetes.io/leases/kube-node-lease/" + nodeName,
+	} {
+		o.log.Infof("Deleting %s from etcd", key)
+		if _, err := cli.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete %s from etcd: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (o *OfflineSeedCreator) backupVar(deployment *offlineDeployment) error {
+	varTarFile := path.Join(o.backupDir, "var.tgz")
+
+	tarArgs := []string{"czf", varTarFile}
+	for _, pattern := range offlineVarExcludePatterns() {
+		// We're handling the excluded patterns in bash, we need to single quote them to prevent expansion
+		tarArgs = append(tarArgs, "--exclude", fmt.Sprintf("'%s'", pattern))
+	}
+	tarArgs = append(tarArgs, "--selinux", "-C", o.staterootPath(deployment), "var")
+
+	if _, err := o.ops.RunBashInHostNamespace("tar", tarArgs...); err != nil {
+		return err
+	}
+
+	o.log.Infof("Backup of %s created successfully.", o.varPath(deployment, common.VarFolder))
+	return nil
+}
+
+func (o *OfflineSeedCreator) backupEtc(deployment *offlineDeployment) error {
+	o.log.Info("Backing up /etc")
+	configDiff := []string{"admin", "config-diff", "--sysroot", o.sysroot, "--os", deployment.OSName}
+
+	args := append(configDiff, "|", "awk", `'$1 == "D" {print "/etc/" $2}'`, ">",
+		path.Join(o.backupDir, "/etc.deletions"))
+	if _, err := o.ops.RunBashInHostNamespace("ostree", args...); err != nil {
+		return err
+	}
+
+	// The archive members are relative to the deployment, matching the ones of an archive of the booted /etc
+	args = append(configDiff, "|", "grep", "-v", "'cni/multus'",
+		"|", "awk", `'$1 != "D" {print "etc/" $2}'`, "|",
+		"tar", "czf", path.Join(o.backupDir, "etc.tgz"),
+		"--exclude", "'etc/NetworkManager/system-connections'",
+		"--selinux", "-C", o.deploymentDir(deployment), "-T", "-")
+	if _, err := o.ops.RunBashInHostNamespace("ostree", args...); err != nil {
+		return err
+	}
+	o.log.Info("Backup of /etc created successfully.")
+
+	return nil
+}
+
+func (o *OfflineSeedCreator) backupOstree() error {
+	o.log.Info("Backing up ostree")
+	_, err := o.ops.RunBashInHostNamespace(
+		"tar", "czf", path.Join(o.backupDir, "ostree.tgz"), "--selinux", "-C", filepath.Join(o.sysroot, "ostree", "repo"), ".")
+	return err
+}
+
+// backupRPMOstree writes the rpm-ostree.json and .origin files, describing the deployment as if it was booted
+func (o *OfflineSeedCreator) backupRPMOstree(deployment *offlineDeployment) error {
+	originFile := o.deploymentDir(deployment) + ".origin"
+	if _, err := o.ops.RunInHostNamespace(
+		"cp", originFile, path.Join(o.backupDir, fmt.Sprintf("ostree-%s.origin", deployment.name()))); err != nil {
+		return err
+	}
+	o.log.Info("Backup of .origin created successfully.")
+
+	origin, err := os.ReadFile(originFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", originFile, err)
+	}
+	status := &ostree.Status{
+		Deployments: []ostree.Deployment{{
+			ID:                      fmt.Sprintf("%s-%s", deployment.OSName, deployment.name()),
+			OSName:                  deployment.OSName,
+			Serial:                  deployment.Serial,
+			Checksum:                deployment.Checksum,
+			Version:                 deployment.Version,
+			Booted:                  true,
+			ContainerImageReference: originContainerImageReference(string(origin)),
+		}},
+	}
+	if err := utils.MarshalToFile(status, path.Join(o.backupDir, "rpm-ostree.json")); err != nil {
+		return err
+	}
+	o.log.Info("Backup of rpm-ostree.json created successfully.")
+	return nil
+}
+
+// originContainerImageReference returns the container-image-reference set in an ostree .origin file, if any
+func originContainerImageReference(origin string) string {
+	for _, line := range strings.Split(origin, "\n") {
+		if reference, found := strings.CutPrefix(strings.TrimSpace(line), "container-image-reference="); found {
+			return reference
+		}
+	}
+	return ""
+}
+
+func (o *OfflineSeedCreator) backupMCOConfig(deployment *offlineDeployment) error {
+	_, err := o.ops.RunInHostNamespace("cp",
+		filepath.Join(o.deploymentDir(deployment), "etc", "machine-config-daemon", "currentconfig"),
+		path.Join(o.backupDir, "mco-currentconfig.json"))
+	if err != nil {
+		return err
+	}
+	o.log.Info("Backup of mco-currentconfig created successfully.")
+	return nil
+}
+
+// CleanupOfflineSeedCreation removes the seed image and the backup folders. The sysroot itself is left as is.
+func (o *OfflineSeedCreator) CleanupOfflineSeedCreation() error {
+	o.log.Info("Cleaning up offline seed creation")
+	var errors []error
+
+	rmiArgs := append([]string{"rmi"}, lo.Map(o.seedImageDestinations(), func(d SeedImageDestination, _ int) string {
+		return d.Image
+	})...)
+	if _, err := o.ops.RunInHostNamespace("podman", rmiArgs...); err != nil {
+		o.log.Errorf("failed to remove seed image: %v", err)
+		errors = append(errors, err)
+	}
+
+	if err := utils.RemoveListOfFolders(o.log, []string{o.backupDir, common.BackupChecksDir}); err != nil {
+		o.log.Errorf("failed to remove backup folders: %v", err)
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d errors during cleanup", len(errors))
+	}
+	return nil
+}
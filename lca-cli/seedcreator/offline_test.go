@@ -0,0 +1,162 @@
+package seedcreator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+func TestParseOstreeAdminStatus(t *testing.T) {
+	testcases := []struct {
+		name          string
+		output        string
+		expected      *offlineDeployment
+		expectedError bool
+	}{
+		{
+			name: "Default deployment is the first listed",
+			output: `  rhcos 7bd4a4d0c51f8d1e5ac3c1e5e07eb3b1e4d2fd3a4ee77deb94d57e6a2d1f38a6.1
+    Version: 415.92.202403061641-0
+    origin: <unknown origin type>
+  rhcos ed4ab3244a76c6503a21441da650634b5abd25aba4255ca116782b2b3020519c.0 (rollback)
+    Version: 415.92.202402201450-0
+    origin: <unknown origin type>
+`,
+			expected: &offlineDeployment{
+				OSName:   "rhcos",
+				Checksum: "7bd4a4d0c51f8d1e5ac3c1e5e07eb3b1e4d2fd3a4ee77deb94d57e6a2d1f38a6",
+				Serial:   1,
+				Version:  "415.92.202403061641-0",
+			},
+		},
+		{
+			name: "Booted deployment without version",
+			output: `* rhcos ed4ab3244a76c6503a21441da650634b5abd25aba4255ca116782b2b3020519c.0
+  rhcos 7bd4a4d0c51f8d1e5ac3c1e5e07eb3b1e4d2fd3a4ee77deb94d57e6a2d1f38a6.1 (rollback)
+    Version: 415.92.202403061641-0
+`,
+			expected: &offlineDeployment{
+				OSName:   "rhcos",
+				Checksum: "ed4ab3244a76c6503a21441da650634b5abd25aba4255ca116782b2b3020519c",
+				Serial:   0,
+			},
+		},
+		{
+			name:          "No deployment",
+			output:        "No deployments.\n",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment, err := parseOstreeAdminStatus(tc.output)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, deployment)
+		})
+	}
+}
+
+func TestOfflineVarExcludePatterns(t *testing.T) {
+	patterns := offlineVarExcludePatterns()
+	assert.Contains(t, patterns, "*/.bash_history")
+	assert.Contains(t, patterns, "var/lib/containers/*")
+	assert.Contains(t, patterns, "var/lib/ovn-ic/etc/ovnkube-node-certs/*")
+	for _, pattern := range patterns {
+		assert.NotEqual(t, '/', rune(pattern[0]), pattern)
+	}
+}
+
+func TestParsePodmanImages(t *testing.T) {
+	output := `[
+		{"Id": "1", "Names": ["quay.io/openshift-release-dev/ocp-release:4.15.0-x86_64"], "RepoDigests": ["quay.io/openshift-release-dev/ocp-release@sha256:1"]},
+		{"Id": "2", "RepoDigests": ["quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:2"]},
+		{"Id": "3", "Names": ["quay.io/openshift-release-dev/ocp-release:4.15.0-x86_64"]}
+	]`
+
+	images, err := parsePodmanImages(output)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"quay.io/openshift-release-dev/ocp-release:4.15.0-x86_64",
+		"quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:2",
+	}, images)
+
+	_, err = parsePodmanImages("not json")
+	assert.Error(t, err)
+}
+
+func TestOriginContainerImageReference(t *testing.T) {
+	origin := `[origin]
+container-image-reference=ostree-unverified-registry:quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:abc
+
+[rpmostree]
+custom-origin-url=pivot://quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:abc
+`
+	assert.Equal(t, "ostree-unverified-registry:quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:abc",
+		originContainerImageReference(origin))
+	assert.Equal(t, "", originContainerImageReference("[origin]\nrefspec=rhcos\n"))
+}
+
+func TestInstallServices(t *testing.T) {
+	testcases := []struct {
+		name              string
+		disableKubeletErr error
+		expectedError     string
+	}{
+		{
+			name: "Services enabled and kubelet disabled",
+		},
+		{
+			name:              "Failing to disable kubelet fails",
+			disableKubeletErr: fmt.Errorf("no such unit"),
+			expectedError:     "failed to disable kubelet service: no such unit",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mockController = gomock.NewController(t)
+				mockOps        = ops.NewMockOps(mockController)
+			)
+			defer mockController.Finish()
+
+			tmpDir := t.TempDir()
+			servicesDir := filepath.Join(tmpDir, "services")
+			assert.NoError(t, os.MkdirAll(servicesDir, 0o700))
+			assert.NoError(t, os.WriteFile(filepath.Join(servicesDir, "installation-configuration.service"), nil, 0o600))
+
+			o := NewOfflineSeedCreator(logrus.New(), mockOps, "", filepath.Join(tmpDir, "sysroot"), "", "", "", "", "", nil, "")
+			o.servicesDir = servicesDir
+			deployment := &offlineDeployment{OSName: "rhcos", Checksum: "abc", Serial: 0}
+			deploymentDir := o.deploymentDir(deployment)
+
+			gomock.InOrder(
+				mockOps.EXPECT().RunInHostNamespace("systemctl", "--root", deploymentDir, "enable",
+					"installation-configuration.service").Return("", nil),
+				mockOps.EXPECT().RunInHostNamespace("systemctl", "--root", deploymentDir, "disable",
+					"kubelet.service").Return("", tc.disableKubeletErr),
+			)
+
+			err := o.installServices(deployment)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.FileExists(t, filepath.Join(deploymentDir, "etc", "systemd", "system", "installation-configuration.service"))
+			assert.FileExists(t, o.varPath(deployment, "/var/usrlocal/bin/lca-cli"))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package seedcreator
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	runtime "sigs.k8s.io/controller-runtime/pkg/client"
@@ -25,6 +28,7 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	ostree "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedsbom"
 	"github.com/openshift-kni/lifecycle-agent/utils"
 )
 
@@ -34,6 +38,62 @@ FROM scratch
 COPY . /
 `
 
+// SeedImageDestination is an additional destination the seed image is pushed to
+type SeedImageDestination struct {
+	Image    string
+	AuthFile string
+}
+
+// SeedImagePushResult holds the outcome of pushing the seed image to a single destination
+type SeedImagePushResult struct {
+	Image   string `json:"image"`
+	Pushed  bool   `json:"pushed"`
+	Message string `json:"message,omitempty"`
+}
+
+// ParseSeedImageDestination parses an IMAGE[,AUTHFILE] destination string, using defaultAuthFile when no
+// auth file is specified
+func ParseSeedImageDestination(destination, defaultAuthFile string) (SeedImageDestination, error) {
+	image, authFile, _ := strings.Cut(destination, ",")
+	image = strings.TrimSpace(image)
+	authFile = strings.TrimSpace(authFile)
+	if image == "" {
+		return SeedImageDestination{}, fmt.Errorf("invalid seed image destination %q: missing image", destination)
+	}
+	if authFile == "" {
+		authFile = defaultAuthFile
+	}
+	return SeedImageDestination{Image: image, AuthFile: authFile}, nil
+}
+
+// Default bounds for the waits done while shutting down the seed cluster
+const (
+	DefaultOvnKubeNodeShutdownTimeout = 5 * time.Minute
+	DefaultContainersStopTimeout      = 5 * time.Minute
+)
+
+// WaitTimeouts bounds the waits done while shutting down the seed cluster
+type WaitTimeouts struct {
+	OvnKubeNodeShutdown time.Duration
+	ContainersStop      time.Duration
+}
+
+// BlockedError is returned when the seed creation times out waiting for a component to stop. It reports what
+// was still running, so the blocking component can be surfaced to the user.
+type BlockedError struct {
+	Component    string   `json:"component"`
+	Timeout      string   `json:"timeout"`
+	StillRunning []string `json:"stillRunning,omitempty"`
+}
+
+func (e *BlockedError) Error() string {
+	msg := fmt.Sprintf("timed out after %s waiting for %s to stop", e.Timeout, e.Component)
+	if len(e.StillRunning) > 0 {
+		msg += fmt.Sprintf(", still running: %s", strings.Join(e.StillRunning, ", "))
+	}
+	return msg
+}
+
 // SeedCreator TODO: move params to Options
 type SeedCreator struct {
 	client               runtime.Client
@@ -46,11 +106,15 @@ type SeedCreator struct {
 	authFile             string
 	recertContainerImage string
 	recertSkipValidation bool
+	additionalImages     []SeedImageDestination
+	pushStatusFile       string
+	waitTimeouts         WaitTimeouts
 }
 
 // NewSeedCreator is a constructor function for SeedCreator
 func NewSeedCreator(client runtime.Client, log *logrus.Logger, ops ops.Ops, ostreeClient *ostree.Client, backupDir,
-	kubeconfig, containerRegistry, authFile, recertContainerImage string, recertSkipValidation bool) *SeedCreator {
+	kubeconfig, containerRegistry, authFile, recertContainerImage string, recertSkipValidation bool,
+	additionalImages []SeedImageDestination, pushStatusFile string, waitTimeouts WaitTimeouts) *SeedCreator {
 
 	return &SeedCreator{
 		client:               client,
@@ -63,6 +127,9 @@ func NewSeedCreator(client runtime.Client, log *logrus.Logger, ops ops.Ops, ostr
 		authFile:             authFile,
 		recertContainerImage: recertContainerImage,
 		recertSkipValidation: recertSkipValidation,
+		additionalImages:     additionalImages,
+		pushStatusFile:       pushStatusFile,
+		waitTimeouts:         waitTimeouts,
 	}
 }
 
@@ -98,6 +165,10 @@ func (s *SeedCreator) CreateSeedImage() error {
 		return err
 	}
 
+	if err := utils.RunOnce("gather_seed_sbom_cluster_data", common.BackupChecksDir, s.log, s.gatherSeedSBOMClusterData, ctx); err != nil {
+		return err
+	}
+
 	if s.recertSkipValidation {
 		s.log.Info("Skipping seed certificates backing up.")
 	} else {
@@ -108,13 +179,19 @@ func (s *SeedCreator) CreateSeedImage() error {
 		s.log.Info("Seed cluster certificates backed up successfully for recert tool")
 	}
 
+	if err := utils.RunOnce("delete_identity_record", common.BackupChecksDir, s.log, s.deleteIdentityRecord, ctx); err != nil {
+		return err
+	}
+
 	if err := utils.RunOnce("delete_node", common.BackupChecksDir, s.log, s.deleteNode, ctx); err != nil {
 		return err
 	}
 
-	_ = utils.RunOnce("wait_for_ovn_to_go_down", common.BackupChecksDir, s.log, s.waitTillOvnKubeNodeIsDown, ctx)
+	if err := utils.RunOnce("wait_for_ovn_to_go_down", common.BackupChecksDir, s.log, s.waitTillOvnKubeNodeIsDown, ctx); err != nil {
+		return err
+	}
 
-	if err := s.stopServices(); err != nil {
+	if err := s.stopServices(ctx); err != nil {
 		return err
 	}
 
@@ -149,6 +226,10 @@ func (s *SeedCreator) CreateSeedImage() error {
 		return err
 	}
 
+	if err := utils.RunOnce("create_seed_sbom", common.BackupChecksDir, s.log, s.createSeedSBOM); err != nil {
+		return err
+	}
+
 	if err := s.createAndPushSeedImage(); err != nil {
 		return err
 	}
@@ -204,6 +285,69 @@ func (s *SeedCreator) gatherClusterInfo(ctx context.Context) error {
 	return nil
 }
 
+// gatherSeedSBOMClusterData starts the seed SBOM with the data that can only be collected while the cluster is up:
+// the seed cluster identity and its installed operators
+func (s *SeedCreator) gatherSeedSBOMClusterData(ctx context.Context) error {
+	s.log.Info("Gathering seed cluster data for the seed SBOM")
+	clusterInfo, err := utils.GetClusterInfo(ctx, s.client)
+	if err != nil {
+		return err
+	}
+
+	sbom := seedsbom.NewFromClusterInfo(clusterInfo)
+
+	csvs := &operatorsv1alpha1.ClusterServiceVersionList{}
+	if err := s.client.List(ctx, csvs, &runtime.ListOptions{Namespace: metav1.NamespaceAll}); err != nil {
+		return fmt.Errorf("failed to list all ClusterServiceVersions: %w", err)
+	}
+	sbom.SetOperators(csvs.Items)
+
+	return utils.MarshalToFile(sbom, path.Join(s.backupDir, common.SeedSBOMFileName))
+}
+
+// createSeedSBOM completes the seed SBOM with the RPMs of the booted ostree deployment and the list of container images.
+// The rpmArgs are passed on to the rpm query, e.g. to point it to the rpm database of an offline deployment.
+func (s *SeedCreator) createSeedSBOM(rpmArgs ...string) error {
+	s.log.Info("Creating seed SBOM")
+	sbomFile := path.Join(s.backupDir, common.SeedSBOMFileName)
+	sbom, err := seedsbom.ReadSeedSBOMFromFile(sbomFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", sbomFile, err)
+	}
+
+	status := &ostree.Status{}
+	if err := utils.ReadYamlOrJSONFile(path.Join(s.backupDir, "rpm-ostree.json"), status); err != nil {
+		return fmt.Errorf("failed to read rpm-ostree.json: %w", err)
+	}
+	if err := sbom.SetOSTreeDeployment(status); err != nil {
+		return err
+	}
+
+	output, err := s.ops.RunInHostNamespace("rpm", append(rpmArgs, "-qa", "--queryformat", seedsbom.RPMQueryFormat)...)
+	if err != nil {
+		return fmt.Errorf("failed to list installed rpms: %w", err)
+	}
+	if sbom.Packages, err = seedsbom.ParseRPMList(output); err != nil {
+		return err
+	}
+
+	containersList, err := os.ReadFile(path.Join(s.backupDir, "containers.list"))
+	if err != nil {
+		return fmt.Errorf("failed to read containers.list: %w", err)
+	}
+	sbom.SetContainerImages(string(containersList))
+
+	sbom.CreationTime = time.Now().UTC()
+
+	if err := utils.MarshalToFile(sbom, sbomFile); err != nil {
+		return fmt.Errorf("failed to write %s: %w", sbomFile, err)
+	}
+
+	s.log.Infof("Seed SBOM created successfully with %d packages, %d container images and %d operators",
+		len(sbom.Packages), len(sbom.ContainerImages), len(sbom.Operators))
+	return nil
+}
+
 func (s *SeedCreator) createContainerList(ctx context.Context) error {
 	s.log.Info("Saving list of running containers and catalogsources.")
 	containersListFileName := s.backupDir + "/containers.list"
@@ -241,7 +385,7 @@ func (s *SeedCreator) createContainerList(ctx context.Context) error {
 	return nil
 }
 
-func (s *SeedCreator) stopServices() error {
+func (s *SeedCreator) stopServices(ctx context.Context) error {
 	s.log.Info("Stop kubelet service")
 	_, err := s.ops.SystemctlAction("stop", "kubelet.service")
 	if err != nil {
@@ -263,16 +407,10 @@ func (s *SeedCreator) stopServices() error {
 	}
 	s.log.Info("crio status is ", crioSystemdStatus)
 	if crioSystemdStatus == "active" {
-		// CRI-O is active, so stop running containers with retry
-		_ = wait.PollUntilContextCancel(context.TODO(), time.Second, true, func(ctx context.Context) (done bool, err error) {
-			s.log.Info("Stop running containers")
-			args := []string{"ps", "-q", "|", "xargs", "--no-run-if-empty", "--max-args", "1", "--max-procs", "10", "crictl", "stop", "--timeout", "5"}
-			_, err = s.ops.RunBashInHostNamespace("crictl", args...)
-			if err != nil {
-				return false, err
-			}
-			return true, nil
-		})
+		// CRI-O is active, so stop running containers with retry, until none is left running
+		if err := s.stopContainers(ctx); err != nil {
+			return err
+		}
 
 		// Execute a D-Bus call to stop the CRI-O runtime
 		s.log.Debug("Stopping CRI-O engine")
@@ -288,25 +426,89 @@ func (s *SeedCreator) stopServices() error {
 	return nil
 }
 
-func (s *SeedCreator) backupVar() error {
-	varTarFile := path.Join(s.backupDir, "var.tgz")
+// stopContainers stops all running containers, retrying until none is left or the containers stop timeout expires
+func (s *SeedCreator) stopContainers(ctx context.Context) error {
+	var stillRunning []string
+	err := wait.PollUntilContextTimeout(ctx, time.Second, s.waitTimeouts.ContainersStop, true, func(ctx context.Context) (done bool, err error) {
+		s.log.Info("Stop running containers")
+		args := []string{"ps", "-q", "|", "xargs", "--no-run-if-empty", "--max-args", "1", "--max-procs", "10", "crictl", "stop", "--timeout", "5"}
+		if _, err := s.ops.RunBashInHostNamespace("crictl", args...); err != nil {
+			s.log.Warnf("Failed to stop running containers, will retry: %v", err)
+		}
 
-	// Define the 'exclude' patterns
-	excludePatterns := []string{
-		"*/.bash_history",
-		"/var/tmp/*",
-		"/var/log/*",
-		"/var/lib/lca",
-		"/var/lib/log/*",
-		"/var/lib/cni/bin/*",
-		"/var/lib/containers/*",
-		"/var/lib/kubelet/pods/*",
-		common.OvnNodeCerts + "/*",
+		if stillRunning, err = s.runningContainers(); err != nil {
+			s.log.Warnf("Failed to list running containers, will retry: %v", err)
+			return false, nil
+		}
+		if len(stillRunning) > 0 {
+			s.log.Infof("Containers still running: %s", strings.Join(stillRunning, ", "))
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return &BlockedError{Component: "containers", Timeout: s.waitTimeouts.ContainersStop.String(), StillRunning: stillRunning}
+	}
+	return nil
+}
+
+// runningContainers returns the running containers, as namespace/pod/container
+func (s *SeedCreator) runningContainers() ([]string, error) {
+	output, err := s.ops.RunInHostNamespace("crictl", "ps", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list running containers: %w", err)
+	}
+	return parseCrictlContainers(output)
+}
+
+// parseCrictlContainers parses the output of crictl ps -o json into a list of namespace/pod/container
+func parseCrictlContainers(output string) ([]string, error) {
+	var ps struct {
+		Containers []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Labels map[string]string `json:"labels"`
+		} `json:"containers"`
+	}
+	if err := json.Unmarshal([]byte(output), &ps); err != nil {
+		return nil, fmt.Errorf("failed to parse crictl ps output: %w", err)
+	}
+
+	var containers []string
+	for _, container := range ps.Containers {
+		containers = append(containers, fmt.Sprintf("%s/%s/%s", container.Labels["io.kubernetes.pod.namespace"],
+			container.Labels["io.kubernetes.pod.name"], container.Metadata.Name))
 	}
+	return containers, nil
+}
+
+// varExcludePatterns are the paths left out of the /var backup
+var varExcludePatterns = []string{
+	"*/.bash_history",
+	"/var/tmp/*",
+	"/var/log/*",
+	"/var/lib/lca",
+	"/var/lib/log/*",
+	"/var/lib/cni/bin/*",
+	"/var/lib/containers/*",
+	"/var/lib/kubelet/pods/*",
+	common.OvnNodeCerts + "/*",
+}
+
+// defaultCatalogsRegex matches the list of known catalog images, there are 4 of them at least in 4.15
+// registry.redhat.io/redhat/community-operator-index:v4.15
+// registry.redhat.io/redhat/redhat-operator-index:v4.15
+// registry.redhat.io/redhat/certified-operator-index:v4.15
+// registry.redhat.io/redhat/redhat-marketplace-index:v4.15
+var defaultCatalogsRegex = regexp.MustCompile(`^registry\.redhat\.io/redhat/.+-index:.+`)
+
+func (s *SeedCreator) backupVar() error {
+	varTarFile := path.Join(s.backupDir, "var.tgz")
 
 	// Build the tar command
 	tarArgs := []string{"czf", varTarFile}
-	for _, pattern := range excludePatterns {
+	for _, pattern := range varExcludePatterns {
 		// We're handling the excluded patterns in bash, we need to single quote them to prevent expansion
 		tarArgs = append(tarArgs, "--exclude", fmt.Sprintf("'%s'", pattern))
 	}
@@ -397,6 +599,11 @@ func (s *SeedCreator) createAndPushSeedImage() error {
 		return err
 	}
 
+	return s.buildAndPushSeedImage()
+}
+
+// buildAndPushSeedImage builds the OCI image out of the backup dir and pushes it to all destinations
+func (s *SeedCreator) buildAndPushSeedImage() error {
 	// Create a temporary file for the Dockerfile content
 	tmpfile, err := os.CreateTemp("/var/tmp", "dockerfile-")
 	if err != nil {
@@ -411,25 +618,69 @@ func (s *SeedCreator) createAndPushSeedImage() error {
 	}
 	_ = tmpfile.Close() // Close the temporary file
 
+	sbom, err := seedsbom.ReadSeedSBOMFromFile(path.Join(s.backupDir, common.SeedSBOMFileName))
+	if err != nil {
+		return fmt.Errorf("failed to read seed SBOM: %w", err)
+	}
+
+	destinations := s.seedImageDestinations()
+
 	// Build the single OCI image (note: We could include --squash-all option, as well)
 	podmanBuildArgs := []string{
 		"build",
 		"--file", tmpfile.Name(),
-		"--tag", s.containerRegistry,
-		"--label", fmt.Sprintf("%s=%d", common.SeedFormatOCILabel, common.SeedFormatVersion),
-		s.backupDir,
 	}
+	for _, destination := range destinations {
+		podmanBuildArgs = append(podmanBuildArgs, "--tag", destination.Image)
+	}
+	podmanBuildArgs = append(podmanBuildArgs, "--label", fmt.Sprintf("%s=%d", common.SeedFormatOCILabel, common.SeedFormatVersion))
+	labels := sbom.OCILabels()
+	keys := lo.Keys(labels)
+	sort.Strings(keys)
+	for _, key := range keys {
+		podmanBuildArgs = append(podmanBuildArgs, "--label", fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+	podmanBuildArgs = append(podmanBuildArgs, s.backupDir)
 	_, err = s.ops.RunInHostNamespace(
 		"podman", podmanBuildArgs...)
 	if err != nil {
 		return fmt.Errorf("failed to build seed image: %w", err)
 	}
 
-	// Push the created OCI image to user's repository
-	_, err = s.ops.RunInHostNamespace(
-		"podman", []string{"push", "--authfile", s.authFile, s.containerRegistry}...)
-	if err != nil {
-		return fmt.Errorf("failed to push seed image: %w", err)
+	return s.pushSeedImage(destinations)
+}
+
+// seedImageDestinations returns the list of all destinations for the seed image, starting with the primary one
+func (s *SeedCreator) seedImageDestinations() []SeedImageDestination {
+	return append([]SeedImageDestination{{Image: s.containerRegistry, AuthFile: s.authFile}}, s.additionalImages...)
+}
+
+// pushSeedImage pushes the built OCI image to each of the destinations, attempting all of them even if some fail,
+// and records the per-destination results in the push status file, if requested
+func (s *SeedCreator) pushSeedImage(destinations []SeedImageDestination) error {
+	var failed []string
+	results := make([]SeedImagePushResult, 0, len(destinations))
+	for _, destination := range destinations {
+		s.log.Infof("Pushing seed image to %s", destination.Image)
+		result := SeedImagePushResult{Image: destination.Image, Pushed: true}
+		if _, err := s.ops.RunInHostNamespace(
+			"podman", []string{"push", "--authfile", destination.AuthFile, destination.Image}...); err != nil {
+			s.log.Errorf("Failed to push seed image to %s: %v", destination.Image, err)
+			result.Pushed = false
+			result.Message = err.Error()
+			failed = append(failed, destination.Image)
+		}
+		results = append(results, result)
+	}
+
+	if s.pushStatusFile != "" {
+		if err := utils.MarshalToFile(results, s.pushStatusFile); err != nil {
+			return fmt.Errorf("failed to write seed image push status to %s: %w", s.pushStatusFile, err)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to push seed image to: %s", strings.Join(failed, ", "))
 	}
 
 	return nil
@@ -475,27 +726,48 @@ func (s *SeedCreator) deleteNode(ctx context.Context) error {
 	return nil
 }
 
-func (s *SeedCreator) waitTillOvnKubeNodeIsDown(ctx context.Context) {
+// deleteIdentityRecord deletes the identity record of the seed cluster and the key it is signed with, so that the
+// clusters reconfigured from the seed image neither inherit the seed record nor share its signing key
+func (s *SeedCreator) deleteIdentityRecord(ctx context.Context) error {
+	for _, obj := range []runtime.Object{
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name: common.IdentityRecordConfigMapName, Namespace: common.LcaNamespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name: common.IdentityRecordKeySecretName, Namespace: common.LcaNamespace}},
+	} {
+		s.log.Infof("Deleting %s/%s", obj.GetNamespace(), obj.GetName())
+		if err := s.client.Delete(ctx, obj); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+func (s *SeedCreator) waitTillOvnKubeNodeIsDown(ctx context.Context) error {
 	ovnKubeNode := "ovnkube-node"
 	s.log.Infof("Waiting for %s to stop in order to give ovn to cleanup network", ovnKubeNode)
-	// we will wait for 5 minutes and exit, we don't want to fail as it is not critical
-	deadlineCtx, deadlineCancel := context.WithTimeout(ctx, 5*time.Minute)
-	_ = wait.PollUntilContextCancel(deadlineCtx, 10*time.Second, true, func(ctx context.Context) (done bool, err error) {
+	var stillRunning []string
+	err := wait.PollUntilContextTimeout(ctx, 10*time.Second, s.waitTimeouts.OvnKubeNodeShutdown, true, func(ctx context.Context) (done bool, err error) {
 		s.log.Infof("waiting for %s to stop", ovnKubeNode)
 		pods := &corev1.PodList{}
 		err = s.client.List(ctx, pods, &runtime.ListOptions{Namespace: "openshift-ovn-kubernetes"})
 		if err != nil {
+			s.log.Warnf("Failed to list %s pods, will retry: %v", ovnKubeNode, err)
 			return false, nil
 		}
+		stillRunning = nil
 		for _, pod := range pods.Items {
-			if strings.HasPrefix(pod.Name, "ovnkube-node") {
-				return false, nil
+			if strings.HasPrefix(pod.Name, ovnKubeNode) {
+				stillRunning = append(stillRunning, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
 			}
 		}
 
-		return true, nil
+		return len(stillRunning) == 0, nil
 	})
-	defer deadlineCancel()
+	if err != nil {
+		return &BlockedError{Component: ovnKubeNode, Timeout: s.waitTimeouts.OvnKubeNodeShutdown.String(), StillRunning: stillRunning}
+	}
+	return nil
 }
 
 // filterCatalogImages filters catalog source images as catalog sources have pull always policy
@@ -504,12 +776,6 @@ func (s *SeedCreator) waitTillOvnKubeNodeIsDown(ctx context.Context) {
 // Filtering known images will allow us to fix the case where those images were pulled in seed
 // and after it their catalog sources were removed but images are still part of podman images output as we create seed
 func (s *SeedCreator) filterCatalogImages(ctx context.Context, images []string) ([]string, error) {
-	// Regex matches list of known catalog images, there are 4 of them at least in 4.15
-	// registry.redhat.io/redhat/community-operator-index:v4.15
-	// registry.redhat.io/redhat/redhat-operator-index:v4.15
-	// registry.redhat.io/redhat/certified-operator-index:v4.15
-	// registry.redhat.io/redhat/redhat-marketplace-index:v4.15
-	defaultCatalogsRegex := regexp.MustCompile(`^registry\.redhat\.io/redhat/.+-index:.+`)
 	s.log.Info("Searching for catalog sources")
 	var catalogImages []string
 
@@ -0,0 +1,229 @@
+package seedcreator
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+func TestParseSeedImageDestination(t *testing.T) {
+	testcases := []struct {
+		name          string
+		destination   string
+		expected      SeedImageDestination
+		expectedError bool
+	}{
+		{
+			name:        "Image only uses default auth file",
+			destination: "quay.io/test/seed:latest",
+			expected:    SeedImageDestination{Image: "quay.io/test/seed:latest", AuthFile: "/default/auth.json"},
+		},
+		{
+			name:        "Image with auth file",
+			destination: "mirror.example.com:5000/seed:v1,/tmp/mirror-auth.json",
+			expected:    SeedImageDestination{Image: "mirror.example.com:5000/seed:v1", AuthFile: "/tmp/mirror-auth.json"},
+		},
+		{
+			name:        "Image with empty auth file uses default auth file",
+			destination: "quay.io/test/seed:latest,",
+			expected:    SeedImageDestination{Image: "quay.io/test/seed:latest", AuthFile: "/default/auth.json"},
+		},
+		{
+			name:          "Missing image",
+			destination:   ",/tmp/mirror-auth.json",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination, err := ParseSeedImageDestination(tc.destination, "/default/auth.json")
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, destination)
+		})
+	}
+}
+
+func TestPushSeedImage(t *testing.T) {
+	var (
+		mockController = gomock.NewController(t)
+		mockOps        = ops.NewMockOps(mockController)
+	)
+
+	defer func() {
+		mockController.Finish()
+	}()
+
+	destinations := []SeedImageDestination{
+		{Image: "quay.io/test/seed:v1", AuthFile: "/auth.json"},
+		{Image: "quay.io/test/seed:latest", AuthFile: "/auth.json"},
+		{Image: "mirror.example.com:5000/seed:v1", AuthFile: "/mirror-auth.json"},
+	}
+
+	testcases := []struct {
+		name          string
+		failedImages  map[string]bool
+		expectedError bool
+	}{
+		{
+			name:          "All pushes succeed",
+			failedImages:  map[string]bool{},
+			expectedError: false,
+		},
+		{
+			name:          "One push fails, remaining destinations are still pushed",
+			failedImages:  map[string]bool{"quay.io/test/seed:latest": true},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			pushStatusFile := filepath.Join(t.TempDir(), "push-status.json")
+			s := &SeedCreator{log: &logrus.Logger{}, ops: mockOps, pushStatusFile: pushStatusFile}
+
+			for _, destination := range destinations {
+				var err error
+				if tc.failedImages[destination.Image] {
+					err = fmt.Errorf("push failed")
+				}
+				mockOps.EXPECT().RunInHostNamespace("podman", "push", "--authfile", destination.AuthFile, destination.Image).
+					Return("", err).Times(1)
+			}
+
+			err := s.pushSeedImage(destinations)
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var results []SeedImagePushResult
+			assert.NoError(t, utils.ReadYamlOrJSONFile(pushStatusFile, &results))
+			assert.Equal(t, len(destinations), len(results))
+			for i, result := range results {
+				assert.Equal(t, destinations[i].Image, result.Image)
+				assert.Equal(t, !tc.failedImages[result.Image], result.Pushed)
+			}
+		})
+	}
+}
+
+func TestParseCrictlContainers(t *testing.T) {
+	output := `{"containers": [
+		{"id": "1", "metadata": {"name": "etcd"}, "labels": {"io.kubernetes.pod.namespace": "openshift-etcd", "io.kubernetes.pod.name": "etcd-sno"}},
+		{"id": "2", "metadata": {"name": "ovnkube-controller"}, "labels": {"io.kubernetes.pod.namespace": "openshift-ovn-kubernetes", "io.kubernetes.pod.name": "ovnkube-node-abcde"}}
+	]}`
+
+	containers, err := parseCrictlContainers(output)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"openshift-etcd/etcd-sno/etcd",
+		"openshift-ovn-kubernetes/ovnkube-node-abcde/ovnkube-controller",
+	}, containers)
+
+	containers, err = parseCrictlContainers(`{"containers": []}`)
+	assert.NoError(t, err)
+	assert.Empty(t, containers)
+
+	_, err = parseCrictlContainers("not json")
+	assert.Error(t, err)
+}
+
+func TestStopContainers(t *testing.T) {
+	stillRunning := `{"containers": [{"metadata": {"name": "etcd"}, "labels": {"io.kubernetes.pod.namespace": "openshift-etcd", "io.kubernetes.pod.name": "etcd-sno"}}]}`
+
+	testcases := []struct {
+		name          string
+		psOutput      string
+		expectedError error
+	}{
+		{
+			name:          "All containers stopped",
+			psOutput:      `{"containers": []}`,
+			expectedError: nil,
+		},
+		{
+			name:     "Containers still running after timeout",
+			psOutput: stillRunning,
+			expectedError: &BlockedError{
+				Component:    "containers",
+				Timeout:      "1ms",
+				StillRunning: []string{"openshift-etcd/etcd-sno/etcd"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			mockOps := ops.NewMockOps(mockController)
+			defer mockController.Finish()
+
+			s := &SeedCreator{log: &logrus.Logger{}, ops: mockOps, waitTimeouts: WaitTimeouts{ContainersStop: time.Millisecond}}
+
+			mockOps.EXPECT().RunBashInHostNamespace("crictl", gomock.Any()).Return("", nil).AnyTimes()
+			mockOps.EXPECT().RunInHostNamespace("crictl", "ps", "-o", "json").Return(tc.psOutput, nil).MinTimes(1)
+
+			err := s.stopContainers(context.Background())
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tc.expectedError, err)
+				assert.EqualError(t, err, "timed out after 1ms waiting for containers to stop, still running: openshift-etcd/etcd-sno/etcd")
+			}
+		})
+	}
+}
+
+func TestDeleteIdentityRecord(t *testing.T) {
+	identityRecord := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name: common.IdentityRecordConfigMapName, Namespace: common.LcaNamespace}}
+	signingKey := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name: common.IdentityRecordKeySecretName, Namespace: common.LcaNamespace}}
+
+	testcases := []struct {
+		name    string
+		objects []runtimeclient.Object
+	}{
+		{
+			name:    "Identity record and signing key",
+			objects: []runtimeclient.Object{identityRecord, signingKey},
+		},
+		{
+			name: "No identity record",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(tc.objects...).Build()
+			s := &SeedCreator{log: &logrus.Logger{}, client: c}
+
+			assert.NoError(t, s.deleteIdentityRecord(context.Background()))
+			assert.True(t, k8serrors.IsNotFound(c.Get(context.Background(),
+				runtimeclient.ObjectKeyFromObject(identityRecord), &corev1.ConfigMap{})))
+			assert.True(t, k8serrors.IsNotFound(c.Get(context.Background(),
+				runtimeclient.ObjectKeyFromObject(signingKey), &corev1.Secret{})))
+		})
+	}
+}
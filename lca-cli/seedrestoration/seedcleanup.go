@@ -21,6 +21,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	"github.com/sirupsen/logrus"
@@ -44,10 +45,18 @@ type SeedRestoration struct {
 	authFile             string
 	recertContainerImage string
 	recertSkipValidation bool
+	additionalImages     []string
+
+	// The host paths the seed creation leaves behind
+	checksDir        string
+	servicesDir      string
+	foldersToRemove  []string
+	seedgenWorkspace string
 }
 
 func NewSeedRestoration(log *logrus.Logger, ops ops.Ops, backupDir,
-	containerRegistry, authFile, recertContainerImage string, recertSkipValidation bool) *SeedRestoration {
+	containerRegistry, authFile, recertContainerImage string, recertSkipValidation bool,
+	additionalImages []string) *SeedRestoration {
 
 	return &SeedRestoration{
 		log:                  log,
@@ -57,6 +66,11 @@ func NewSeedRestoration(log *logrus.Logger, ops ops.Ops, backupDir,
 		authFile:             authFile,
 		recertContainerImage: recertContainerImage,
 		recertSkipValidation: recertSkipValidation,
+		additionalImages:     additionalImages,
+		checksDir:            common.BackupChecksDir,
+		servicesDir:          filepath.Join(common.InstallationConfigurationFilesDir, "services"),
+		foldersToRemove:      foldersToRemove,
+		seedgenWorkspace:     utils.SeedgenWorkspacePath,
 	}
 }
 
@@ -69,9 +83,15 @@ func (s *SeedRestoration) CleanupSeedCluster() error {
 	// but still cleanup as much as possible.
 	var errors []error
 
-	if _, err := s.ops.RunInHostNamespace("podman", []string{"rmi", s.containerRegistry}...); err != nil {
-		s.log.Errorf("failed to remove seed image: %v", err)
-		errors = append(errors, err)
+	if s.containerRegistry == "" {
+		s.log.Info("No seed image specified, skipping seed image removal")
+	} else {
+		// Remove every tag of the seed image, so the image itself is removed as well
+		rmiArgs := append([]string{"rmi", s.containerRegistry}, s.additionalImages...)
+		if _, err := s.ops.RunInHostNamespace("podman", rmiArgs...); err != nil {
+			s.log.Errorf("failed to remove seed image: %v", err)
+			errors = append(errors, err)
+		}
 	}
 
 	if err := s.cleanupServiceUnits(); err != nil {
@@ -82,7 +102,7 @@ func (s *SeedRestoration) CleanupSeedCluster() error {
 	if s.recertSkipValidation {
 		s.log.Info("Skipping restoring crypto via recert tool")
 	} else {
-		recertFilePath := filepath.Join(common.BackupChecksDir, "recert.done")
+		recertFilePath := filepath.Join(s.checksDir, "recert.done")
 		if _, err := os.Stat(recertFilePath); err == nil && !os.IsNotExist(err) {
 			if err := s.ops.RestoreOriginalSeedCrypto(s.recertContainerImage, s.authFile); err != nil {
 				s.log.Errorf("Error restoring certificates: %v", err)
@@ -91,7 +111,7 @@ func (s *SeedRestoration) CleanupSeedCluster() error {
 		}
 	}
 
-	for _, folder := range foldersToRemove {
+	for _, folder := range s.foldersToRemove {
 		s.log.Infof("Removing %s folder", folder)
 		if err := os.RemoveAll(folder); err != nil {
 			s.log.Errorf("Error removing %s: %v", folder, err)
@@ -113,8 +133,7 @@ func (s *SeedRestoration) CleanupSeedCluster() error {
 }
 
 func (s *SeedRestoration) cleanupServiceUnits() error {
-	dir := filepath.Join(common.InstallationConfigurationFilesDir, "services")
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(s.servicesDir, func(path string, info os.FileInfo, err error) error {
 		if info.IsDir() {
 			return nil
 		}
@@ -0,0 +1,166 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package seedrestoration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+const doneMarkerExt = ".done"
+
+// RecoveryAction describes a single action taken while recovering the seed cluster
+type RecoveryAction struct {
+	Description string
+	Err         error
+}
+
+// RecoverSeedCluster recovers a seed cluster from a seed image generation that didn't complete. It inspects the
+// markers in the BackupChecksDir to work out how far the run got, reverses the host level changes, waits for the
+// cluster to come back and restores the Node, the original pull-secret and the seedgen CRs, as needed.
+// All steps are attempted, the returned actions describe what was done and what failed.
+func (s *SeedRestoration) RecoverSeedCluster(ctx context.Context, newClient func() (client.Client, error),
+	timeout time.Duration) []RecoveryAction {
+	var actions []RecoveryAction
+	record := func(description string, err error) {
+		if err != nil {
+			s.log.Errorf("%s: %v", description, err)
+		} else {
+			s.log.Info(description)
+		}
+		actions = append(actions, RecoveryAction{Description: description, Err: err})
+	}
+
+	completedSteps, err := completedSeedCreationSteps(s.checksDir)
+	if len(completedSteps) == 0 {
+		record("Found no completed seed creation steps", err)
+	} else {
+		record(fmt.Sprintf("Found completed seed creation steps: %s", strings.Join(completedSteps, ", ")), err)
+	}
+
+	// Host level changes are reverted the same way as at the end of a seed image generation. The checks dir is
+	// removed as part of it, so the completed steps must be inspected beforehand.
+	var hostActions []string
+	if s.containerRegistry != "" {
+		hostActions = append(hostActions, "removed the seed image")
+	}
+	hostActions = append(hostActions, "cleaned up the installation service units")
+	if lo.Contains(completedSteps, "recert") && !s.recertSkipValidation {
+		hostActions = append(hostActions, "restored the original seed crypto via recert")
+	}
+	hostActions = append(hostActions, "removed the seed backup folders", "re-enabled kubelet")
+	record(fmt.Sprintf("Restored the seed host: %s", strings.Join(hostActions, ", ")), s.CleanupSeedCluster())
+
+	c, err := newClient()
+	if err != nil {
+		record("Failed to create client, skipping cluster resources recovery", err)
+		return actions
+	}
+
+	// The Node is recreated by kubelet when it registers again, so wait for it to show up. This also ensures the
+	// API is available before restoring the other resources.
+	var nodeName string
+	err = wait.PollUntilContextTimeout(ctx, 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		node, err := lcautils.GetSNOMasterNode(ctx, c)
+		if err != nil {
+			s.log.Infof("Waiting for the node to be registered: %v", err)
+			return false, nil
+		}
+		nodeName = node.Name
+		return true, nil
+	})
+	if err != nil {
+		record("Timed out waiting for the node to be registered, skipping cluster resources recovery", err)
+		return actions
+	}
+	if lo.Contains(completedSteps, "delete_node") {
+		record(fmt.Sprintf("Node %s was recreated", nodeName), nil)
+	} else {
+		record(fmt.Sprintf("Node %s is registered", nodeName), nil)
+	}
+
+	storedPullSecret := filepath.Join(s.seedgenWorkspace, utils.StoredPullSecretFileName)
+	if fileExists(filepath.Join(s.seedgenWorkspace, utils.SeedGenStoredCRFileName)) ||
+		fileExists(filepath.Join(s.seedgenWorkspace, utils.SeedGenStoredSecretCRFileName)) {
+		log := zap.New(zap.WriteTo(s.log.Out))
+		record("Restored the seedgen Secret, the SeedGenerator CR and the original pull-secret",
+			lcautils.RestoreSeedGen(ctx, c, &log, s.seedgenWorkspace))
+	} else if fileExists(storedPullSecret) {
+		dockerConfigJSON, err := os.ReadFile(common.PathOutsideChroot(storedPullSecret))
+		if err == nil {
+			_, err = lcautils.UpdatePullSecretFromDockerConfig(ctx, c, dockerConfigJSON)
+		}
+		record("Restored the original pull-secret", err)
+	} else {
+		record("Found no stored seedgen CRs or pull-secret, nothing to restore", nil)
+	}
+
+	return actions
+}
+
+// completedSeedCreationSteps returns the names of the seed creation steps with a done marker in the given
+// directory, in the order they were completed
+func completedSeedCreationSteps(checksDir string) ([]string, error) {
+	entries, err := os.ReadDir(checksDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", checksDir, err)
+	}
+
+	type marker struct {
+		step    string
+		modTime time.Time
+	}
+	var markers []marker
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != doneMarkerExt {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", entry.Name(), err)
+		}
+		markers = append(markers, marker{step: strings.TrimSuffix(entry.Name(), doneMarkerExt), modTime: info.ModTime()})
+	}
+
+	sort.SliceStable(markers, func(i, j int) bool {
+		return markers[i].modTime.Before(markers[j].modTime)
+	})
+
+	return lo.Map(markers, func(m marker, _ int) string { return m.step }), nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(common.PathOutsideChroot(path))
+	return err == nil
+}
@@ -0,0 +1,204 @@
+package seedrestoration
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	seedgenv1alpha1 "github.com/openshift-kni/lifecycle-agent/api/seedgenerator/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+func TestCompletedSeedCreationSteps(t *testing.T) {
+	testcases := []struct {
+		name     string
+		markers  []string
+		others   []string
+		expected []string
+	}{
+		{
+			name:     "no markers",
+			expected: []string{},
+		},
+		{
+			name:     "markers are returned in completion order",
+			markers:  []string{"create_container_list", "gather_cluster_info", "delete_node", "recert"},
+			expected: []string{"create_container_list", "gather_cluster_info", "delete_node", "recert"},
+		},
+		{
+			name:     "other files are ignored",
+			markers:  []string{"create_container_list"},
+			others:   []string{"recert_config.json"},
+			expected: []string{"create_container_list"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			checksDir := t.TempDir()
+			start := time.Now().Add(-time.Hour)
+			for i, step := range tc.markers {
+				marker := filepath.Join(checksDir, step+doneMarkerExt)
+				assert.NoError(t, os.WriteFile(marker, nil, 0o600))
+				modTime := start.Add(time.Duration(i) * time.Minute)
+				assert.NoError(t, os.Chtimes(marker, modTime, modTime))
+			}
+			for _, other := range tc.others {
+				assert.NoError(t, os.WriteFile(filepath.Join(checksDir, other), nil, 0o600))
+			}
+
+			steps, err := completedSeedCreationSteps(checksDir)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, steps)
+		})
+	}
+
+	t.Run("missing checks dir", func(t *testing.T) {
+		steps, err := completedSeedCreationSteps(filepath.Join(t.TempDir(), "missing"))
+		assert.NoError(t, err)
+		assert.Empty(t, steps)
+	})
+}
+
+func TestRecoverSeedCluster(t *testing.T) {
+	writeJSON := func(t *testing.T, file string, obj any) {
+		data, err := json.Marshal(obj)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(file, data, 0o600))
+	}
+	masterNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "sno",
+		Labels: map[string]string{"node-role.kubernetes.io/master": ""},
+	}}
+
+	testcases := []struct {
+		name             string
+		markers          []string
+		storedSeedGen    bool
+		storedPullSecret bool
+		nodeRegistered   bool
+		expectedFailed   []string
+		validate         func(t *testing.T, client runtimeclient.Client)
+	}{
+		{
+			name:             "recert completed and seedgen CRs stored",
+			markers:          []string{"create_container_list", "recert"},
+			storedSeedGen:    true,
+			storedPullSecret: true,
+			nodeRegistered:   true,
+			validate: func(t *testing.T, client runtimeclient.Client) {
+				seedgen := &seedgenv1alpha1.SeedGenerator{}
+				assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{Name: utils.SeedGenName}, seedgen))
+				secret := &corev1.Secret{}
+				assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{
+					Name: utils.SeedGenSecretName, Namespace: common.LcaNamespace}, secret))
+				assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{
+					Name: common.PullSecretName, Namespace: common.OpenshiftConfigNamespace}, secret))
+				assert.Equal(t, `{"auths":{}}`, string(secret.Data[".dockerconfigjson"]))
+			},
+		},
+		{
+			name:             "only the pull-secret stored",
+			markers:          []string{"create_container_list"},
+			storedPullSecret: true,
+			nodeRegistered:   true,
+			validate: func(t *testing.T, client runtimeclient.Client) {
+				secret := &corev1.Secret{}
+				assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{
+					Name: common.PullSecretName, Namespace: common.OpenshiftConfigNamespace}, secret))
+				assert.Equal(t, `{"auths":{}}`, string(secret.Data[".dockerconfigjson"]))
+			},
+		},
+		{
+			name:           "node not registered",
+			storedSeedGen:  true,
+			expectedFailed: []string{"Timed out waiting for the node to be registered, skipping cluster resources recovery"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mockController = gomock.NewController(t)
+				mockOps        = ops.NewMockOps(mockController)
+			)
+			defer mockController.Finish()
+
+			tmpDir := t.TempDir()
+			checksDir := filepath.Join(tmpDir, "checks")
+			seedgenWorkspace := filepath.Join(tmpDir, "seedgen")
+			assert.NoError(t, os.MkdirAll(checksDir, 0o700))
+			assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "services"), 0o700))
+			assert.NoError(t, os.MkdirAll(seedgenWorkspace, 0o700))
+			for _, step := range tc.markers {
+				assert.NoError(t, os.WriteFile(filepath.Join(checksDir, step+doneMarkerExt), nil, 0o600))
+			}
+
+			if tc.storedSeedGen {
+				writeJSON(t, filepath.Join(seedgenWorkspace, utils.SeedGenStoredCRFileName), &seedgenv1alpha1.SeedGenerator{
+					TypeMeta:   metav1.TypeMeta{Kind: "SeedGenerator", APIVersion: seedgenv1alpha1.GroupVersion.String()},
+					ObjectMeta: metav1.ObjectMeta{Name: utils.SeedGenName},
+				})
+				writeJSON(t, filepath.Join(seedgenWorkspace, utils.SeedGenStoredSecretCRFileName), &corev1.Secret{
+					TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+					ObjectMeta: metav1.ObjectMeta{Name: utils.SeedGenSecretName, Namespace: common.LcaNamespace},
+				})
+			}
+			if tc.storedPullSecret {
+				assert.NoError(t, os.WriteFile(filepath.Join(seedgenWorkspace, utils.StoredPullSecretFileName),
+					[]byte(`{"auths":{}}`), 0o600))
+			}
+
+			scheme := runtime.NewScheme()
+			assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+			assert.NoError(t, seedgenv1alpha1.AddToScheme(scheme))
+			objs := []runtimeclient.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: common.PullSecretName, Namespace: common.OpenshiftConfigNamespace}}}
+			if tc.nodeRegistered {
+				objs = append(objs, masterNode)
+			}
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+				WithStatusSubresource(&seedgenv1alpha1.SeedGenerator{}).Build()
+
+			if lo.Contains(tc.markers, "recert") {
+				mockOps.EXPECT().RestoreOriginalSeedCrypto("recert:latest", "/auth.json").Return(nil)
+			}
+			mockOps.EXPECT().SystemctlAction("enable", "kubelet.service", "--now").Return("", nil)
+
+			s := NewSeedRestoration(logrus.New(), mockOps, "", "", "/auth.json", "recert:latest", false, nil)
+			s.checksDir = checksDir
+			s.servicesDir = filepath.Join(tmpDir, "services")
+			s.foldersToRemove = []string{checksDir}
+			s.seedgenWorkspace = seedgenWorkspace
+			actions := s.RecoverSeedCluster(context.TODO(), func() (runtimeclient.Client, error) { return client, nil },
+				time.Millisecond)
+
+			failed := lo.FilterMap(actions, func(a RecoveryAction, _ int) (string, bool) {
+				return a.Description, a.Err != nil
+			})
+			assert.ElementsMatch(t, tc.expectedFailed, failed)
+			_, err := os.Stat(checksDir)
+			assert.True(t, os.IsNotExist(err))
+			if tc.validate != nil {
+				tc.validate(t, client)
+			}
+		})
+	}
+}
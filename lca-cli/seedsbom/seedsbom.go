@@ -0,0 +1,199 @@
+package seedsbom
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// OCI labels attached to the seed image, exposing its provenance without having to pull it
+const (
+	SeedClusterNameOCILabel       = "com.openshift.lifecycle-agent.seed_cluster_name"
+	SeedClusterIDOCILabel         = "com.openshift.lifecycle-agent.seed_cluster_id"
+	SeedClusterOCPVersionOCILabel = "com.openshift.lifecycle-agent.seed_cluster_ocp_version"
+	SeedCreationTimeOCILabel      = "com.openshift.lifecycle-agent.seed_creation_time"
+	SeedSBOMOCILabel              = "com.openshift.lifecycle-agent.seed_sbom"
+	OCIImageCreatedLabel          = "org.opencontainers.image.created"
+)
+
+// RPMQueryFormat is the rpm --queryformat used to produce output parsable by ParseRPMList
+const RPMQueryFormat = `%{NAME}\t%{EPOCH}\t%{VERSION}\t%{RELEASE}\t%{ARCH}\n`
+
+// SeedSBOM is a software bill of materials describing the content of a seed image and where it came from.
+// It is stored in the seed image next to the seed cluster info and copied into the new stateroot during prep,
+// so it's available for later audit.
+type SeedSBOM struct {
+	// The time at which the seed image was created
+	CreationTime time.Time `json:"creation_time"`
+
+	// The identity of the cluster the seed image was created from
+	SourceCluster SourceCluster `json:"source_cluster"`
+
+	// The booted ostree deployment of the seed cluster, as reported in rpm-ostree.json
+	OSTreeDeployment OSTreeDeployment `json:"ostree_deployment"`
+
+	// The RPMs installed in the booted ostree deployment
+	Packages []Package `json:"packages,omitempty"`
+
+	// The container images present on the seed cluster, as listed in containers.list
+	ContainerImages []string `json:"container_images,omitempty"`
+
+	// The operators installed on the seed cluster
+	Operators []Operator `json:"operators,omitempty"`
+}
+
+// SourceCluster identifies the seed cluster
+type SourceCluster struct {
+	ClusterName     string `json:"cluster_name,omitempty"`
+	BaseDomain      string `json:"base_domain,omitempty"`
+	ClusterID       string `json:"cluster_id,omitempty"`
+	OCPVersion      string `json:"ocp_version,omitempty"`
+	ReleaseRegistry string `json:"release_registry,omitempty"`
+}
+
+// OSTreeDeployment describes the booted ostree deployment of the seed cluster
+type OSTreeDeployment struct {
+	OSName                  string   `json:"osname,omitempty"`
+	Checksum                string   `json:"checksum,omitempty"`
+	Version                 string   `json:"version,omitempty"`
+	ContainerImageReference string   `json:"container_image_reference,omitempty"`
+	RequestedPackages       []string `json:"requested_packages,omitempty"`
+}
+
+// Package is an RPM installed on the seed cluster
+type Package struct {
+	Name    string `json:"name"`
+	Epoch   string `json:"epoch,omitempty"`
+	Version string `json:"version"`
+	Release string `json:"release"`
+	Arch    string `json:"arch"`
+}
+
+// Operator is an operator installed on the seed cluster, as described by its ClusterServiceVersion
+type Operator struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   string `json:"version,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+}
+
+// NewFromClusterInfo creates a SeedSBOM holding the identity of the given seed cluster
+func NewFromClusterInfo(clusterInfo *utils.ClusterInfo) *SeedSBOM {
+	return &SeedSBOM{
+		SourceCluster: SourceCluster{
+			ClusterName:     clusterInfo.ClusterName,
+			BaseDomain:      clusterInfo.BaseDomain,
+			ClusterID:       clusterInfo.ClusterID,
+			OCPVersion:      clusterInfo.OCPVersion,
+			ReleaseRegistry: clusterInfo.ReleaseRegistry,
+		},
+	}
+}
+
+// SetOperators records the operators described by the given ClusterServiceVersions. CSVs copied into other
+// namespaces by OLM are skipped, so each operator is only listed once.
+func (s *SeedSBOM) SetOperators(csvs []operatorsv1alpha1.ClusterServiceVersion) {
+	s.Operators = nil
+	for _, csv := range csvs {
+		if csv.IsCopied() {
+			continue
+		}
+		s.Operators = append(s.Operators, Operator{
+			Name:      csv.Name,
+			Namespace: csv.Namespace,
+			Version:   csv.Spec.Version.String(),
+			Provider:  csv.Spec.Provider.Name,
+		})
+	}
+	sort.Slice(s.Operators, func(i, j int) bool {
+		if s.Operators[i].Namespace != s.Operators[j].Namespace {
+			return s.Operators[i].Namespace < s.Operators[j].Namespace
+		}
+		return s.Operators[i].Name < s.Operators[j].Name
+	})
+}
+
+// SetContainerImages records the container images listed in the given containers.list content
+func (s *SeedSBOM) SetContainerImages(containersList string) {
+	s.ContainerImages = nil
+	for _, image := range strings.Split(containersList, "\n") {
+		if image = strings.TrimSpace(image); image != "" {
+			s.ContainerImages = append(s.ContainerImages, image)
+		}
+	}
+}
+
+// SetOSTreeDeployment records the booted deployment from the given rpm-ostree status
+func (s *SeedSBOM) SetOSTreeDeployment(status *rpmostreeclient.Status) error {
+	for _, deployment := range status.Deployments {
+		if deployment.Booted {
+			s.OSTreeDeployment = OSTreeDeployment{
+				OSName:                  deployment.OSName,
+				Checksum:                deployment.Checksum,
+				Version:                 deployment.Version,
+				ContainerImageReference: deployment.ContainerImageReference,
+				RequestedPackages:       deployment.RequestedPackages,
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to find booted ostree deployment")
+}
+
+// ParseRPMList parses the output of rpm -qa --queryformat RPMQueryFormat into a sorted list of packages
+func ParseRPMList(output string) ([]Package, error) {
+	var packages []Package
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected rpm query output line: %q", line)
+		}
+		epoch := fields[1]
+		if epoch == "(none)" {
+			epoch = ""
+		}
+		packages = append(packages, Package{
+			Name:    fields[0],
+			Epoch:   epoch,
+			Version: fields[2],
+			Release: fields[3],
+			Arch:    fields[4],
+		})
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Name != packages[j].Name {
+			return packages[i].Name < packages[j].Name
+		}
+		return packages[i].Arch < packages[j].Arch
+	})
+	return packages, nil
+}
+
+// OCILabels returns the labels exposing the provenance of the seed image
+func (s *SeedSBOM) OCILabels() map[string]string {
+	creationTime := s.CreationTime.UTC().Format(time.RFC3339)
+	return map[string]string{
+		SeedClusterNameOCILabel:       s.SourceCluster.ClusterName,
+		SeedClusterIDOCILabel:         s.SourceCluster.ClusterID,
+		SeedClusterOCPVersionOCILabel: s.SourceCluster.OCPVersion,
+		SeedCreationTimeOCILabel:      creationTime,
+		SeedSBOMOCILabel:              common.SeedSBOMFileName,
+		OCIImageCreatedLabel:          creationTime,
+	}
+}
+
+func ReadSeedSBOMFromFile(path string) (*SeedSBOM, error) {
+	data := &SeedSBOM{}
+	err := utils.ReadYamlOrJSONFile(path, data)
+	return data, err
+}
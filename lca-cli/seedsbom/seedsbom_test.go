@@ -0,0 +1,121 @@
+package seedsbom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/api/pkg/lib/version"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
+)
+
+func TestParseRPMList(t *testing.T) {
+	testcases := []struct {
+		name          string
+		output        string
+		expected      []Package
+		expectedError bool
+	}{
+		{
+			name:   "packages are sorted and epoch is cleared when unset",
+			output: "kernel\t(none)\t5.14.0\t284.el9\tx86_64\nbash\t(none)\t5.1.8\t6.el9\tx86_64\n\nopenssl\t1\t3.0.7\t16.el9\tx86_64\n",
+			expected: []Package{
+				{Name: "bash", Version: "5.1.8", Release: "6.el9", Arch: "x86_64"},
+				{Name: "kernel", Version: "5.14.0", Release: "284.el9", Arch: "x86_64"},
+				{Name: "openssl", Epoch: "1", Version: "3.0.7", Release: "16.el9", Arch: "x86_64"},
+			},
+		},
+		{
+			name:     "empty output",
+			output:   "",
+			expected: nil,
+		},
+		{
+			name:          "malformed line",
+			output:        "bash 5.1.8",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			packages, err := ParseRPMList(tc.output)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, packages)
+		})
+	}
+}
+
+func TestSetOperators(t *testing.T) {
+	csv := func(name, namespace, ver string, copied bool) operatorsv1alpha1.ClusterServiceVersion {
+		c := operatorsv1alpha1.ClusterServiceVersion{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: operatorsv1alpha1.ClusterServiceVersionSpec{
+				Version:  version.OperatorVersion{Version: semver.MustParse(ver)},
+				Provider: operatorsv1alpha1.AppLink{Name: "Red Hat"},
+			},
+		}
+		if copied {
+			c.Status.Reason = operatorsv1alpha1.CSVReasonCopied
+		}
+		return c
+	}
+
+	sbom := &SeedSBOM{}
+	sbom.SetOperators([]operatorsv1alpha1.ClusterServiceVersion{
+		csv("lvms-operator.v4.15.0", "openshift-storage", "4.15.0", false),
+		csv("lifecycle-agent.v4.15.0", "openshift-lifecycle-agent", "4.15.0", false),
+		csv("lifecycle-agent.v4.15.0", "default", "4.15.0", true),
+	})
+
+	assert.Equal(t, []Operator{
+		{Name: "lifecycle-agent.v4.15.0", Namespace: "openshift-lifecycle-agent", Version: "4.15.0", Provider: "Red Hat"},
+		{Name: "lvms-operator.v4.15.0", Namespace: "openshift-storage", Version: "4.15.0", Provider: "Red Hat"},
+	}, sbom.Operators)
+}
+
+func TestSetOSTreeDeployment(t *testing.T) {
+	sbom := &SeedSBOM{}
+	err := sbom.SetOSTreeDeployment(&rpmostreeclient.Status{
+		Deployments: []rpmostreeclient.Deployment{
+			{OSName: "rhcos", Checksum: "abc", Version: "415.92", Booted: false},
+			{OSName: "rhcos", Checksum: "def", Version: "416.94", Booted: true, RequestedPackages: []string{"strace"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, OSTreeDeployment{OSName: "rhcos", Checksum: "def", Version: "416.94", RequestedPackages: []string{"strace"}},
+		sbom.OSTreeDeployment)
+
+	err = sbom.SetOSTreeDeployment(&rpmostreeclient.Status{})
+	assert.Error(t, err)
+}
+
+func TestSetContainerImages(t *testing.T) {
+	sbom := &SeedSBOM{}
+	sbom.SetContainerImages("quay.io/a:1\n\nquay.io/b@sha256:123\n")
+	assert.Equal(t, []string{"quay.io/a:1", "quay.io/b@sha256:123"}, sbom.ContainerImages)
+}
+
+func TestOCILabels(t *testing.T) {
+	sbom := &SeedSBOM{
+		CreationTime:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		SourceCluster: SourceCluster{ClusterName: "seed", ClusterID: "1234", OCPVersion: "4.15.0"},
+	}
+	assert.Equal(t, map[string]string{
+		SeedClusterNameOCILabel:       "seed",
+		SeedClusterIDOCILabel:         "1234",
+		SeedClusterOCPVersionOCILabel: "4.15.0",
+		SeedCreationTimeOCILabel:      "2024-01-02T03:04:05Z",
+		SeedSBOMOCILabel:              common.SeedSBOMFileName,
+		OCIImageCreatedLabel:          "2024-01-02T03:04:05Z",
+	}, sbom.OCILabels())
+}
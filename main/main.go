@@ -27,7 +27,6 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/internal/extramanifest"
 
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -47,21 +46,17 @@ import (
 	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
-	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
-	"github.com/go-logr/logr"
 	"github.com/openshift/library-go/pkg/config/leaderelection"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
-	"k8s.io/client-go/util/retry"
 
 	"github.com/openshift-kni/lifecycle-agent/controllers"
-	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
 	"github.com/openshift-kni/lifecycle-agent/internal/backuprestore"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
@@ -82,10 +77,6 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
-const (
-	bakExt = ".bak"
-)
-
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(lcav1alpha1.AddToScheme(scheme))
@@ -171,7 +162,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := initSeedGen(context.TODO(), mgr.GetClient(), &setupLog); err != nil {
+	if err := lcautils.InitSeedGen(context.TODO(), mgr.GetClient(), &setupLog); err != nil {
 		setupLog.Error(err, "unable to initialize SeedGenerator CR")
 		os.Exit(1)
 	}
@@ -214,7 +205,7 @@ func main() {
 			Log:             log.WithName("UpgradeHandler"),
 			BackupRestore:   backupRestore,
 			ExtraManifest:   &extramanifest.EMHandler{Client: mgr.GetClient(), Log: log.WithName("ExtraManifest")},
-			ClusterConfig:   &clusterconfig.UpgradeClusterConfigGather{Client: mgr.GetClient(), Scheme: mgr.GetScheme(), Log: log},
+			ClusterConfig:   &clusterconfig.UpgradeClusterConfigGather{Client: mgr.GetClient(), Scheme: mgr.GetScheme(), Log: log, Ops: op},
 			Executor:        executor,
 			Ops:             op,
 			Recorder:        mgr.GetEventRecorderFor("ImageBasedUpgrade"),
@@ -257,136 +248,3 @@ func main() {
 		os.Exit(1)
 	}
 }
-
-// Seed generator orchestration is done in two stages.
-// In the first stage, the SeedGen CR is saved to filesystem and deleted from etcd,
-// so that it isn't included in the seed image. When the lca-cli is launched in a
-// separate container to generate the image, it shuts down the pods. Once finished,
-// it restarts kubelet, which restarts the pods.
-// When LCA recovers, it is able to run the second stage by restoring the SeedGen CR,
-// then running the reconciler to check and report the status.
-//
-// The initSeedGen function runs before the SeedGen controller is launched to restore
-// the CR, if it exists on the filesystem, in order to complete the orchestration with
-// the second stage.
-//
-// TODO: Determine if errors from this function can be handled better. If the saved files
-// are incomplete or corrupted, for example, maybe we should create a generic SeedGen CR
-// with a Failed state.
-func initSeedGen(ctx context.Context, c client.Client, log *logr.Logger) error {
-	storedCRFound := false
-	storedSecretCRFound := false
-
-	seedgenFilePath := common.PathOutsideChroot(utils.SeedGenStoredCR)
-	secretFilePath := common.PathOutsideChroot(utils.SeedGenStoredSecretCR)
-
-	if _, err := os.Stat(seedgenFilePath); err == nil {
-		storedCRFound = true
-	}
-
-	if _, err := os.Stat(secretFilePath); err == nil {
-		storedSecretCRFound = true
-	}
-
-	if !storedCRFound && !storedSecretCRFound {
-		// Nothing to do
-		return nil
-	} else if storedCRFound != storedSecretCRFound {
-		missing := utils.SeedGenStoredCR
-		if storedCRFound {
-			missing = utils.SeedGenStoredSecretCR
-		}
-		return fmt.Errorf("unable to recover SeedGenerator CR: Missing stored file %s", missing)
-	}
-
-	// Read CRs from file
-	secret := &corev1.Secret{}
-	if err := lcautils.ReadYamlOrJSONFile(secretFilePath, secret); err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return err
-	}
-
-	// Strip the ResourceVersion, otherwise the restore fails
-	secret.SetResourceVersion("")
-
-	seedgen := &seedgenv1alpha1.SeedGenerator{}
-	if err := lcautils.ReadYamlOrJSONFile(seedgenFilePath, seedgen); err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return err
-	}
-
-	// Strip the ResourceVersion, otherwise the restore fails
-	seedgen.SetResourceVersion("")
-
-	// Restore Secret CR
-	log.Info("Saved SeedGenerator Secret CR found, restoring ...")
-	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
-		return client.IgnoreNotFound(c.Delete(ctx, secret))
-	}); err != nil {
-		return err
-	}
-
-	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
-		return c.Create(ctx, secret)
-	}); err != nil {
-		return err
-	}
-
-	// Restore SeedGenerator CR
-
-	log.Info("Saved SeedGenerator CR found, restoring ...")
-	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
-		return client.IgnoreNotFound(c.Delete(ctx, seedgen))
-	}); err != nil {
-		return err
-	}
-
-	// Save status as the seedgen structure gets over-written by the create call
-	// with the result which has no status
-	status := seedgen.Status
-	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
-		return c.Create(ctx, seedgen)
-	}); err != nil {
-		return err
-	}
-
-	// Put the saved status into the newly create seedgen with the right resource
-	// version which is required for the update call to work
-	seedgen.Status = status
-	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
-		return c.Status().Update(ctx, seedgen)
-	}); err != nil {
-		return err
-	}
-
-	// Rename files for debugging in case of error
-	os.Remove(seedgenFilePath + bakExt)
-	if err := os.Rename(seedgenFilePath, seedgenFilePath+bakExt); err != nil {
-		return err
-	}
-
-	os.Remove(secretFilePath + bakExt)
-	if err := os.Rename(secretFilePath, secretFilePath+bakExt); err != nil {
-		return err
-	}
-
-	// Restore original pull-secret after seed creation.
-	// During seed creation, the pull-secret was removed; it needs to be restored back
-	// to allow the cluster operators to fully recover in the seed cluster.
-	log.Info("Restore original pull-secret after seed creation")
-	dockerConfigJSON, err := os.ReadFile(common.PathOutsideChroot(utils.StoredPullSecret))
-	if err != nil {
-		return fmt.Errorf("failed to read original pull-secret from %s file: %w", utils.StoredPullSecret, err)
-	}
-
-	if _, err := lcautils.UpdatePullSecretFromDockerConfig(ctx, c, dockerConfigJSON); err != nil {
-		return fmt.Errorf("failed to restore original pull-secret in seed cluster: %w", err)
-	}
-
-	log.Info("Restore successful and saved SeedGenerator CR removed")
-	return nil
-}
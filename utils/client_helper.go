@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
@@ -57,6 +58,44 @@ func GetClusterName(ctx context.Context, client runtimeclient.Client) (string, e
 	return installConfig.Metadata.Name, nil
 }
 
+// GetMachineNetworks returns the machine network CIDRs of the cluster, one per IP family on dual-stack clusters
+func GetMachineNetworks(ctx context.Context, client runtimeclient.Client) ([]string, error) {
+	installConfig, err := getInstallConfig(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	var machineNetworks []string
+	for _, machineNetwork := range installConfig.Networking.MachineNetwork {
+		machineNetworks = append(machineNetworks, machineNetwork.CIDR)
+	}
+	return machineNetworks, nil
+}
+
+// GetClusterNetworks returns the cluster network of the cluster, the IP blocks pod IPs are allocated from
+func GetClusterNetworks(ctx context.Context, client runtimeclient.Client) ([]seedreconfig.ClusterNetworkEntry, error) {
+	installConfig, err := getInstallConfig(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	var clusterNetworks []seedreconfig.ClusterNetworkEntry
+	for _, clusterNetwork := range installConfig.Networking.ClusterNetwork {
+		clusterNetworks = append(clusterNetworks, seedreconfig.ClusterNetworkEntry{
+			CIDR:       clusterNetwork.CIDR,
+			HostPrefix: clusterNetwork.HostPrefix,
+		})
+	}
+	return clusterNetworks, nil
+}
+
+// GetServiceNetworks returns the service network CIDRs of the cluster, one per IP family on dual-stack clusters
+func GetServiceNetworks(ctx context.Context, client runtimeclient.Client) ([]string, error) {
+	installConfig, err := getInstallConfig(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return installConfig.Networking.ServiceNetwork, nil
+}
+
 func GetClusterBaseDomain(ctx context.Context, client runtimeclient.Client) (string, error) {
 	installConfig, err := getInstallConfig(ctx, client)
 	if err != nil {
@@ -71,6 +110,10 @@ type ClusterInfo struct {
 	ClusterName              string
 	ClusterID                string
 	NodeIP                   string
+	NodeIPs                  []string
+	MachineNetworks          []string
+	ClusterNetworks          []seedreconfig.ClusterNetworkEntry
+	ServiceNetworks          []string
 	ReleaseRegistry          string
 	Hostname                 string
 	MirrorRegistryConfigured bool
@@ -96,7 +139,7 @@ func GetClusterInfo(ctx context.Context, client runtimeclient.Client) (*ClusterI
 	if err != nil {
 		return nil, err
 	}
-	ip, err := getNodeInternalIP(*node)
+	ips, err := getNodeInternalIPs(*node)
 	if err != nil {
 		return nil, err
 	}
@@ -115,26 +158,49 @@ func GetClusterInfo(ctx context.Context, client runtimeclient.Client) (*ClusterI
 		return nil, err
 	}
 
+	machineNetworks, err := GetMachineNetworks(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterNetworks, err := GetClusterNetworks(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceNetworks, err := GetServiceNetworks(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ClusterInfo{
 		ClusterName:              clusterName,
 		BaseDomain:               clusterBaseDomain,
 		OCPVersion:               clusterVersion.Status.Desired.Version,
 		ClusterID:                string(clusterVersion.Spec.ClusterID),
-		NodeIP:                   ip,
+		NodeIP:                   ips[0],
+		NodeIPs:                  ips,
+		MachineNetworks:          machineNetworks,
+		ClusterNetworks:          clusterNetworks,
+		ServiceNetworks:          serviceNetworks,
 		ReleaseRegistry:          releaseRegistry,
 		Hostname:                 hostname,
 		MirrorRegistryConfigured: len(mirrorRegistrySources) > 0,
 	}, nil
 }
 
-// TODO: add dual stuck support
-func getNodeInternalIP(node corev1.Node) (string, error) {
+// getNodeInternalIPs returns the node internal IPs, one per IP family on dual-stack clusters, the primary one first
+func getNodeInternalIPs(node corev1.Node) ([]string, error) {
+	var ips []string
 	for _, addr := range node.Status.Addresses {
 		if addr.Type == corev1.NodeInternalIP {
-			return addr.Address, nil
+			ips = append(ips, addr.Address)
 		}
 	}
-	return "", fmt.Errorf("failed to find node internal ip address")
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("failed to find node internal ip address")
+	}
+	return ips, nil
 }
 
 func getNodeHostname(node corev1.Node) (string, error) {
@@ -150,9 +216,25 @@ type installConfigMetadata struct {
 	Name string `json:"name"`
 }
 
+type installConfigMachineNetwork struct {
+	CIDR string `json:"cidr"`
+}
+
+type installConfigClusterNetwork struct {
+	CIDR       string `json:"cidr"`
+	HostPrefix int    `json:"hostPrefix,omitempty"`
+}
+
+type installConfigNetworking struct {
+	MachineNetwork []installConfigMachineNetwork `json:"machineNetwork,omitempty"`
+	ClusterNetwork []installConfigClusterNetwork `json:"clusterNetwork,omitempty"`
+	ServiceNetwork []string                      `json:"serviceNetwork,omitempty"`
+}
+
 type basicInstallConfig struct {
-	BaseDomain string                `json:"baseDomain"`
-	Metadata   installConfigMetadata `json:"metadata"`
+	BaseDomain string                  `json:"baseDomain"`
+	Metadata   installConfigMetadata   `json:"metadata"`
+	Networking installConfigNetworking `json:"networking,omitempty"`
 }
 
 func getInstallConfig(ctx context.Context, client runtimeclient.Client) (*basicInstallConfig, error) {
@@ -208,10 +290,22 @@ func GetReleaseRegistry(ctx context.Context, client runtimeclient.Client) (strin
 
 	return strings.Split(deployment.Spec.Template.Spec.Containers[0].Image, "/")[0], nil
 }
+
+// ReadSeedReconfigurationFromFile reads a seed reconfiguration of any supported version, converts it to the current
+// version and validates it
 func ReadSeedReconfigurationFromFile(path string) (*seedreconfig.SeedReconfiguration, error) {
-	data := &seedreconfig.SeedReconfiguration{}
-	err := ReadYamlOrJSONFile(path, data)
-	return data, err
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seedReconfiguration, err := seedreconfig.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := seedReconfiguration.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid seed reconfiguration %s: %w", path, err)
+	}
+	return seedReconfiguration, nil
 }
 
 func ExtractRegistryFromImage(image string) string {
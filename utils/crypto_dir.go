@@ -2,6 +2,8 @@ package utils
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"os"
 	"path"
@@ -48,6 +50,77 @@ func SeedReconfigurationKubeconfigRetentionToCryptoDir(cryptoDir string, kubecon
 	return nil
 }
 
+// ServingCertificateFiles is a user provided serving certificate written to the crypto dir
+type ServingCertificateFiles struct {
+	// CommonName is the subject common name of the certificate
+	CommonName      string
+	CertificateFile string
+	KeyFile         string
+}
+
+// SeedReconfigurationServingCertificatesToCryptoDir validates the user provided API named certificates and ingress
+// certificate and writes them to the crypto dir, the API ones first
+func SeedReconfigurationServingCertificatesToCryptoDir(cryptoDir string, seedReconfig *seedreconfig.SeedReconfiguration) ([]ServingCertificateFiles, error) {
+	if len(seedReconfig.APINamedCertificates) == 0 && seedReconfig.IngressCertificate == nil {
+		return nil, nil
+	}
+	if err := os.MkdirAll(cryptoDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating %s: %w", cryptoDir, err)
+	}
+
+	var files []ServingCertificateFiles
+	for i, namedCertificate := range seedReconfig.APINamedCertificates {
+		certificateFiles, err := servingCertificateToCryptoDir(cryptoDir, fmt.Sprintf("api-named-certificate-%d", i),
+			&namedCertificate.ServingCertificate)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, *certificateFiles)
+	}
+	if seedReconfig.IngressCertificate != nil {
+		certificateFiles, err := servingCertificateToCryptoDir(cryptoDir, "ingress-default-certificate", seedReconfig.IngressCertificate)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, *certificateFiles)
+	}
+	return files, nil
+}
+
+func servingCertificateToCryptoDir(cryptoDir, name string, servingCertificate *seedreconfig.ServingCertificate) (*ServingCertificateFiles, error) {
+	commonName, err := ServingCertificateCommonName(servingCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	files := &ServingCertificateFiles{
+		CommonName:      commonName,
+		CertificateFile: path.Join(cryptoDir, name+".crt"),
+		KeyFile:         path.Join(cryptoDir, name+".key"),
+	}
+	if err := os.WriteFile(files.CertificateFile, []byte(servingCertificate.Certificate), cryptoDirMode); err != nil {
+		return nil, fmt.Errorf("error writing %s.crt: %w", name, err)
+	}
+	if err := os.WriteFile(files.KeyFile, []byte(servingCertificate.Key), cryptoDirMode); err != nil {
+		return nil, fmt.Errorf("error writing %s.key: %w", name, err)
+	}
+	return files, nil
+}
+
+// ServingCertificateCommonName checks that the serving certificate matches its private key and returns its subject
+// common name
+func ServingCertificateCommonName(servingCertificate *seedreconfig.ServingCertificate) (string, error) {
+	keyPair, err := tls.X509KeyPair([]byte(servingCertificate.Certificate), []byte(servingCertificate.Key))
+	if err != nil {
+		return "", fmt.Errorf("failed to load certificate and key: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return leaf.Subject.CommonName, nil
+}
+
 func SeedReconfigurationKubeconfigRetentionFromCluster(ctx context.Context, client runtimeclient.Client) (*seedreconfig.KubeConfigCryptoRetention, error) {
 	var kubeconfigCryptoRetention seedreconfig.KubeConfigCryptoRetention
 
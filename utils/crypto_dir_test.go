@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+)
+
+func testServingCertificate(t *testing.T, commonName string) *seedreconfig.ServingCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return &seedreconfig.ServingCertificate{
+		Certificate: seedreconfig.PEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		Key:         seedreconfig.PEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+func TestSeedReconfigurationServingCertificatesToCryptoDir(t *testing.T) {
+	apiCertificate := testServingCertificate(t, "api.test.example.com")
+	ingressCertificate := testServingCertificate(t, "*.apps.test.example.com")
+	otherCertificate := testServingCertificate(t, "other.example.com")
+
+	testcases := []struct {
+		name          string
+		seedReconfig  *seedreconfig.SeedReconfiguration
+		expectedFiles []ServingCertificateFiles
+		expectedError bool
+	}{
+		{
+			name:         "No certificates",
+			seedReconfig: &seedreconfig.SeedReconfiguration{},
+		},
+		{
+			name: "API and ingress certificates",
+			seedReconfig: &seedreconfig.SeedReconfiguration{
+				APINamedCertificates: []seedreconfig.NamedCertificate{
+					{Names: []string{"api.test.example.com"}, ServingCertificate: *apiCertificate},
+					{ServingCertificate: *otherCertificate},
+				},
+				IngressCertificate: ingressCertificate,
+			},
+			expectedFiles: []ServingCertificateFiles{
+				{CommonName: "api.test.example.com", CertificateFile: "api-named-certificate-0.crt", KeyFile: "api-named-certificate-0.key"},
+				{CommonName: "other.example.com", CertificateFile: "api-named-certificate-1.crt", KeyFile: "api-named-certificate-1.key"},
+				{CommonName: "*.apps.test.example.com", CertificateFile: "ingress-default-certificate.crt", KeyFile: "ingress-default-certificate.key"},
+			},
+		},
+		{
+			name: "Key not matching the certificate",
+			seedReconfig: &seedreconfig.SeedReconfiguration{
+				IngressCertificate: &seedreconfig.ServingCertificate{Certificate: ingressCertificate.Certificate, Key: apiCertificate.Key},
+			},
+			expectedError: true,
+		},
+		{
+			name: "Invalid certificate",
+			seedReconfig: &seedreconfig.SeedReconfiguration{
+				APINamedCertificates: []seedreconfig.NamedCertificate{{ServingCertificate: seedreconfig.ServingCertificate{Certificate: "bad", Key: "bad"}}},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cryptoDir := t.TempDir()
+			files, err := SeedReconfigurationServingCertificatesToCryptoDir(cryptoDir, tc.seedReconfig)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			if tc.expectedError {
+				return
+			}
+			assert.Len(t, files, len(tc.expectedFiles))
+			for i, expected := range tc.expectedFiles {
+				assert.Equal(t, expected.CommonName, files[i].CommonName)
+				assert.Equal(t, filepath.Join(cryptoDir, expected.CertificateFile), files[i].CertificateFile)
+				assert.Equal(t, filepath.Join(cryptoDir, expected.KeyFile), files[i].KeyFile)
+				_, err := os.Stat(files[i].CertificateFile)
+				assert.NoError(t, err)
+				_, err = os.Stat(files[i].KeyFile)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
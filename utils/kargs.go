@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const machineConfigRoleLabel = "machineconfiguration.openshift.io/role"
+
+// KernelArguments returns the kernel arguments to add to the seed ones: the given ones followed by the ones of the
+// master MachineConfigs among the manifests, as they apply to the SNO node. Each manifest may hold several YAML
+// documents.
+func KernelArguments(kernelArguments, manifests []string) ([]string, error) {
+	kargs := make([]string, 0, len(kernelArguments))
+	seen := map[string]bool{}
+	add := func(karg string) {
+		if !seen[karg] {
+			seen[karg] = true
+			kargs = append(kargs, karg)
+		}
+	}
+
+	for _, karg := range kernelArguments {
+		add(karg)
+	}
+
+	for _, manifest := range manifests {
+		decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(manifest), 4096)
+		for {
+			object := unstructured.Unstructured{}
+			if err := decoder.Decode(&object); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("failed to decode manifest: %w", err)
+			}
+			if object.GetKind() != "MachineConfig" || object.GetLabels()[machineConfigRoleLabel] != "master" {
+				continue
+			}
+			mc := &mcfgv1.MachineConfig{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, mc); err != nil {
+				return nil, fmt.Errorf("failed to decode machine config %s: %w", object.GetName(), err)
+			}
+			for _, karg := range mc.Spec.KernelArguments {
+				add(karg)
+			}
+		}
+	}
+
+	return kargs, nil
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	masterMachineConfig = `apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfig
+metadata:
+  name: 99-master-kargs
+  labels:
+    machineconfiguration.openshift.io/role: master
+spec:
+  kernelArguments:
+  - hugepagesz=1G
+  - hugepages=16
+`
+	workerMachineConfig = `apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfig
+metadata:
+  name: 99-worker-kargs
+  labels:
+    machineconfiguration.openshift.io/role: worker
+spec:
+  kernelArguments:
+  - nosmt
+`
+	configMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  namespace: default
+data:
+  kernelArguments: nosmt
+`
+)
+
+func TestKernelArguments(t *testing.T) {
+	testcases := []struct {
+		name            string
+		kernelArguments []string
+		manifests       []string
+		expected        []string
+		expectedError   bool
+	}{
+		{
+			name:     "Nothing provided",
+			expected: []string{},
+		},
+		{
+			name:            "Kernel arguments only",
+			kernelArguments: []string{"isolcpus=2-3", "nohz_full=2-3"},
+			expected:        []string{"isolcpus=2-3", "nohz_full=2-3"},
+		},
+		{
+			name:            "Kernel arguments of master MachineConfigs, deduplicated",
+			kernelArguments: []string{"isolcpus=2-3", "hugepages=16"},
+			manifests:       []string{configMap, workerMachineConfig + "---\n" + masterMachineConfig},
+			expected:        []string{"isolcpus=2-3", "hugepages=16", "hugepagesz=1G"},
+		},
+		{
+			name:          "Invalid manifest",
+			manifests:     []string{"kind: [MachineConfig"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			kargs, err := KernelArguments(tc.kernelArguments, tc.manifests)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			if !tc.expectedError {
+				assert.Equal(t, tc.expected, kargs)
+			}
+		})
+	}
+}
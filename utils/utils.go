@@ -5,12 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"net"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"strings"
 	"text/template"
 
 	corev1 "k8s.io/api/core/v1"
@@ -25,13 +27,19 @@ import (
 	k8syaml "sigs.k8s.io/yaml"
 
 	"github.com/go-logr/logr"
+	seedgenv1alpha1 "github.com/openshift-kni/lifecycle-agent/api/seedgenerator/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
 	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
 	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	cp "github.com/otiai10/copy"
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 )
 
+// seedGenBakExt is appended to the stored SeedGenerator CR files once they're restored, to keep them for debugging
+const seedGenBakExt = ".bak"
+
 // MarshalToFile marshals anything and writes it to the given file path. file only readable by root
 func MarshalToFile(data any, filePath string) error {
 	marshaled, err := json.Marshal(data)
@@ -122,6 +130,156 @@ func IsIpv6(provideIp string) bool {
 	return ip.To4() == nil
 }
 
+// NodeIPsOrPrimary returns the node IPs, falling back to the primary node IP alone for configurations that only
+// carry a single node IP
+func NodeIPsOrPrimary(primary string, ips []string) []string {
+	if len(ips) > 0 {
+		return ips
+	}
+	if primary == "" {
+		return nil
+	}
+	return []string{primary}
+}
+
+// IPReplacement is a seed IP and the IP replacing it
+type IPReplacement struct {
+	From string
+	To   string
+}
+
+// IPReplacementsByFamily pairs each of the from IPs, or CIDRs, with the to one of the same family, in the order of the
+// from ones. It fails if there is no to one for the family of a from one, as the IP families of a cluster can't be
+// changed.
+func IPReplacementsByFamily(from, to []string) ([]IPReplacement, error) {
+	var replacements []IPReplacement
+	for _, fromIP := range from {
+		var toIP string
+		for _, ip := range to {
+			if isIPv6Family(ip) == isIPv6Family(fromIP) {
+				toIP = ip
+				break
+			}
+		}
+		if toIP == "" {
+			return nil, fmt.Errorf("no ip of the same family as %s in %v", fromIP, to)
+		}
+		replacements = append(replacements, IPReplacement{From: fromIP, To: toIP})
+	}
+	return replacements, nil
+}
+
+// isIPv6Family returns whether an IP or a CIDR is of the IPv6 family
+func isIPv6Family(ipOrCIDR string) bool {
+	return strings.Contains(ipOrCIDR, ":")
+}
+
+// NetworkReplacements returns the CIDRs of the seed networks replaced by the desired ones, paired by IP family.
+// Nothing is replaced when either of them is unknown, e.g. seed images created before network reconfiguration support.
+func NetworkReplacements(seed, desired []string) ([]IPReplacement, error) {
+	if len(seed) == 0 || len(desired) == 0 {
+		return nil, nil
+	}
+	replacements, err := IPReplacementsByFamily(seed, desired)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Filter(replacements, func(r IPReplacement, _ int) bool { return r.From != r.To }), nil
+}
+
+// ClusterNetworkReplacements returns the CIDRs of the seed cluster network replaced by the desired ones, as
+// NetworkReplacements does. When the host prefix changes, the "<cidr>/<host prefix>" form used by OVN-Kubernetes is
+// replaced as well.
+func ClusterNetworkReplacements(seed, desired []seedreconfig.ClusterNetworkEntry) ([]IPReplacement, error) {
+	withHostPrefix := func(entries []seedreconfig.ClusterNetworkEntry) []string {
+		return lo.Map(entries, func(e seedreconfig.ClusterNetworkEntry, _ int) string {
+			return fmt.Sprintf("%s/%d", e.CIDR, e.HostPrefix)
+		})
+	}
+	replacements, err := NetworkReplacements(withHostPrefix(seed), withHostPrefix(desired))
+	if err != nil {
+		return nil, err
+	}
+
+	var cidrReplacements []IPReplacement
+	for _, r := range replacements {
+		from, fromHostPrefix := splitHostPrefix(r.From)
+		to, toHostPrefix := splitHostPrefix(r.To)
+		if fromHostPrefix != "0" && toHostPrefix != "0" && fromHostPrefix != toHostPrefix {
+			cidrReplacements = append(cidrReplacements, r)
+		}
+		if from != to {
+			cidrReplacements = append(cidrReplacements, IPReplacement{From: from, To: to})
+		}
+	}
+	return cidrReplacements, nil
+}
+
+func splitHostPrefix(cidrWithHostPrefix string) (string, string) {
+	i := strings.LastIndex(cidrWithHostPrefix, "/")
+	return cidrWithHostPrefix[:i], cidrWithHostPrefix[i+1:]
+}
+
+// ServiceNetworkIPReplacements returns the well known IPs of the replaced service networks, the kubernetes service IP
+// and the cluster DNS IP, replaced by the ones of the same offset in the desired service networks
+func ServiceNetworkIPReplacements(serviceNetworkReplacements []IPReplacement) ([]IPReplacement, error) {
+	var replacements []IPReplacement
+	for _, r := range serviceNetworkReplacements {
+		for _, offset := range []int64{1, 10} {
+			from, err := IPInCIDR(r.From, offset)
+			if err != nil {
+				return nil, err
+			}
+			to, err := IPInCIDR(r.To, offset)
+			if err != nil {
+				return nil, err
+			}
+			replacements = append(replacements, IPReplacement{From: from, To: to})
+		}
+	}
+	return replacements, nil
+}
+
+// IPInCIDR returns the IP at the given offset from the network address of the CIDR
+func IPInCIDR(cidr string, offset int64) (string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse cidr %s: %w", cidr, err)
+	}
+	return TranslateIP(ipNet.IP.String(), ipNet, ipNet, big.NewInt(offset))
+}
+
+// TranslateIP returns the IP at the same offset in the to network as the given IP in the from network, plus the extra
+// offset, if any. It fails if the IP isn't in the from network or the result isn't in the to network.
+func TranslateIP(ip string, from, to *net.IPNet, extraOffset *big.Int) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || !from.Contains(parsed) {
+		return "", fmt.Errorf("ip %s is not in %s", ip, from)
+	}
+
+	ipBytes := func(ip net.IP) []byte {
+		if v4 := ip.To4(); v4 != nil {
+			return v4
+		}
+		return ip.To16()
+	}
+	offset := new(big.Int).Sub(new(big.Int).SetBytes(ipBytes(parsed)), new(big.Int).SetBytes(ipBytes(from.IP)))
+	if extraOffset != nil {
+		offset.Add(offset, extraOffset)
+	}
+
+	toBase := ipBytes(to.IP)
+	translated := new(big.Int).Add(new(big.Int).SetBytes(toBase), offset)
+	if translated.BitLen() > len(toBase)*8 {
+		return "", fmt.Errorf("ip %s has no equivalent in %s", ip, to)
+	}
+	translatedIP := net.IP(translated.FillBytes(make([]byte, len(toBase))))
+	if !to.Contains(translatedIP) {
+		return "", fmt.Errorf("ip %s has no equivalent in %s", ip, to)
+	}
+	return translatedIP.String(), nil
+}
+
 func CreateKubeClient(scheme *runtime.Scheme, kubeconfig string) (runtimeclient.Client, error) {
 	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
@@ -251,6 +409,10 @@ func InitIBU(ctx context.Context, c client.Client, log *logr.Logger) error {
 	// Strip the ResourceVersion, otherwise the restore fails
 	ibu.SetResourceVersion("")
 
+	if err := MergePostPivotProgress(ibu, common.PathOutsideChroot(common.PostPivotProgressFile)); err != nil {
+		log.Error(err, "Failed to merge post pivot progress into the IBU status")
+	}
+
 	log.Info("Saved IBU CR found, restoring ...")
 	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
 		return client.IgnoreNotFound(c.Delete(ctx, ibu))
@@ -283,6 +445,195 @@ func InitIBU(ctx context.Context, c client.Client, log *logr.Logger) error {
 	return nil
 }
 
+// MergePostPivotProgress sets the post-pivot steps recorded by lca-cli post-pivot in the given progress file, if any,
+// into the IBU status
+func MergePostPivotProgress(ibu *lcav1alpha1.ImageBasedUpgrade, progressFile string) error {
+	var steps []lcav1alpha1.PostPivotStep
+	if err := ReadYamlOrJSONFile(progressFile, &steps); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read post pivot progress from %s: %w", progressFile, err)
+	}
+	ibu.Status.PostPivotSteps = steps
+	return nil
+}
+
+// MergeRecertSummary sets the digest of the recert summary written by lca-cli post-pivot in the given file, if any,
+// into the IBU status
+func MergeRecertSummary(ibu *lcav1alpha1.ImageBasedUpgrade, digestFile string) error {
+	summary := &lcav1alpha1.RecertSummary{}
+	if err := ReadYamlOrJSONFile(digestFile, summary); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read recert summary digest from %s: %w", digestFile, err)
+	}
+	ibu.Status.RecertSummary = summary
+	return nil
+}
+
+// RecertSummaryMessage describes the recert summary digest in a single line, e.g. for events
+func RecertSummaryMessage(summary *lcav1alpha1.RecertSummary) string {
+	return recertSummaryMessage("Recert regenerated", summary)
+}
+
+// RecertDryRunSummaryMessage describes the digest of the recert dry-run summary in a single line, e.g. for the Prep
+// condition
+func RecertDryRunSummaryMessage(summary *lcav1alpha1.RecertSummary) string {
+	return recertSummaryMessage("Recert dry-run would regenerate", summary)
+}
+
+func recertSummaryMessage(prefix string, summary *lcav1alpha1.RecertSummary) string {
+	msg := fmt.Sprintf("%s %d certificates and %d keys", prefix, summary.Certificates, summary.Keys)
+	if len(summary.Renames) > 0 {
+		msg = fmt.Sprintf("%s, renames: %s", msg, strings.Join(summary.Renames, ", "))
+	}
+	return msg
+}
+
+// Seed generator orchestration is done in two stages.
+// In the first stage, the SeedGen CR is saved to filesystem and deleted from etcd,
+// so that it isn't included in the seed image. When the lca-cli is launched in a
+// separate container to generate the image, it shuts down the pods. Once finished,
+// it restarts kubelet, which restarts the pods.
+// When LCA recovers, it is able to run the second stage by restoring the SeedGen CR,
+// then running the reconciler to check and report the status.
+//
+// The InitSeedGen function runs before the SeedGen controller is launched to restore
+// the CR, if it exists on the filesystem, in order to complete the orchestration with
+// the second stage.
+//
+// TODO: Determine if errors from this function can be handled better. If the saved files
+// are incomplete or corrupted, for example, maybe we should create a generic SeedGen CR
+// with a Failed state.
+func InitSeedGen(ctx context.Context, c client.Client, log *logr.Logger) error {
+	return RestoreSeedGen(ctx, c, log, utils.SeedgenWorkspacePath)
+}
+
+// RestoreSeedGen restores the SeedGen CR, its Secret and the original pull-secret stored in the given seedgen
+// workspace, as described for InitSeedGen
+func RestoreSeedGen(ctx context.Context, c client.Client, log *logr.Logger, workspace string) error {
+	storedCRFound := false
+	storedSecretCRFound := false
+
+	storedCR := filepath.Join(workspace, utils.SeedGenStoredCRFileName)
+	storedSecretCR := filepath.Join(workspace, utils.SeedGenStoredSecretCRFileName)
+	storedPullSecret := filepath.Join(workspace, utils.StoredPullSecretFileName)
+	seedgenFilePath := common.PathOutsideChroot(storedCR)
+	secretFilePath := common.PathOutsideChroot(storedSecretCR)
+
+	if _, err := os.Stat(seedgenFilePath); err == nil {
+		storedCRFound = true
+	}
+
+	if _, err := os.Stat(secretFilePath); err == nil {
+		storedSecretCRFound = true
+	}
+
+	if !storedCRFound && !storedSecretCRFound {
+		// Nothing to do
+		return nil
+	} else if storedCRFound != storedSecretCRFound {
+		missing := storedCR
+		if storedCRFound {
+			missing = storedSecretCR
+		}
+		return fmt.Errorf("unable to recover SeedGenerator CR: Missing stored file %s", missing)
+	}
+
+	// Read CRs from file
+	secret := &corev1.Secret{}
+	if err := ReadYamlOrJSONFile(secretFilePath, secret); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	// Strip the ResourceVersion, otherwise the restore fails
+	secret.SetResourceVersion("")
+
+	seedgen := &seedgenv1alpha1.SeedGenerator{}
+	if err := ReadYamlOrJSONFile(seedgenFilePath, seedgen); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	// Strip the ResourceVersion, otherwise the restore fails
+	seedgen.SetResourceVersion("")
+
+	// Restore Secret CR
+	log.Info("Saved SeedGenerator Secret CR found, restoring ...")
+	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
+		return client.IgnoreNotFound(c.Delete(ctx, secret))
+	}); err != nil {
+		return err
+	}
+
+	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
+		return c.Create(ctx, secret)
+	}); err != nil {
+		return err
+	}
+
+	// Restore SeedGenerator CR
+
+	log.Info("Saved SeedGenerator CR found, restoring ...")
+	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
+		return client.IgnoreNotFound(c.Delete(ctx, seedgen))
+	}); err != nil {
+		return err
+	}
+
+	// Save status as the seedgen structure gets over-written by the create call
+	// with the result which has no status
+	status := seedgen.Status
+	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
+		return c.Create(ctx, seedgen)
+	}); err != nil {
+		return err
+	}
+
+	// Put the saved status into the newly create seedgen with the right resource
+	// version which is required for the update call to work
+	seedgen.Status = status
+	if err := common.RetryOnConflictOrRetriable(retry.DefaultBackoff, func() error {
+		return c.Status().Update(ctx, seedgen)
+	}); err != nil {
+		return err
+	}
+
+	// Rename files for debugging in case of error
+	os.Remove(seedgenFilePath + seedGenBakExt)
+	if err := os.Rename(seedgenFilePath, seedgenFilePath+seedGenBakExt); err != nil {
+		return err
+	}
+
+	os.Remove(secretFilePath + seedGenBakExt)
+	if err := os.Rename(secretFilePath, secretFilePath+seedGenBakExt); err != nil {
+		return err
+	}
+
+	// Restore original pull-secret after seed creation.
+	// During seed creation, the pull-secret was removed; it needs to be restored back
+	// to allow the cluster operators to fully recover in the seed cluster.
+	log.Info("Restore original pull-secret after seed creation")
+	dockerConfigJSON, err := os.ReadFile(common.PathOutsideChroot(storedPullSecret))
+	if err != nil {
+		return fmt.Errorf("failed to read original pull-secret from %s file: %w", storedPullSecret, err)
+	}
+
+	if _, err := UpdatePullSecretFromDockerConfig(ctx, c, dockerConfigJSON); err != nil {
+		return fmt.Errorf("failed to restore original pull-secret in seed cluster: %w", err)
+	}
+
+	log.Info("Restore successful and saved SeedGenerator CR removed")
+	return nil
+}
+
 func ConvertToRawExtension(config any) (runtime.RawExtension, error) {
 	rawIgnConfig, err := json.Marshal(config)
 	if err != nil {
@@ -2,10 +2,14 @@ package utils
 
 import (
 	"github.com/stretchr/testify/assert"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
 )
 
 func TestIsIpv6(t *testing.T) {
@@ -33,6 +37,182 @@ func TestIsIpv6(t *testing.T) {
 	}
 }
 
+func TestIPReplacementsByFamily(t *testing.T) {
+	testcases := []struct {
+		name          string
+		from          []string
+		to            []string
+		expected      []IPReplacement
+		expectedError bool
+	}{
+		{
+			name:     "single stack",
+			from:     []string{"192.168.127.10"},
+			to:       []string{"192.168.121.10"},
+			expected: []IPReplacement{{From: "192.168.127.10", To: "192.168.121.10"}},
+		},
+		{
+			name: "dual stack, paired by family",
+			from: []string{"192.168.127.10", "2620:52:0:198::10"},
+			to:   []string{"2620:52:0:199::10", "192.168.121.10"},
+			expected: []IPReplacement{
+				{From: "192.168.127.10", To: "192.168.121.10"},
+				{From: "2620:52:0:198::10", To: "2620:52:0:199::10"},
+			},
+		},
+		{
+			name:     "single stack seed, dual stack target",
+			from:     []string{"192.168.127.10"},
+			to:       []string{"192.168.121.10", "2620:52:0:199::10"},
+			expected: []IPReplacement{{From: "192.168.127.10", To: "192.168.121.10"}},
+		},
+		{
+			name:          "ip families differ",
+			from:          []string{"192.168.127.10"},
+			to:            []string{"2620:52:0:199::10"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			replacements, err := IPReplacementsByFamily(tc.from, tc.to)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			assert.Equal(t, tc.expected, replacements)
+		})
+	}
+}
+
+func TestNodeIPsOrPrimary(t *testing.T) {
+	assert.Equal(t, []string{"192.168.127.10", "2620:52:0:198::10"},
+		NodeIPsOrPrimary("192.168.127.10", []string{"192.168.127.10", "2620:52:0:198::10"}))
+	assert.Equal(t, []string{"192.168.127.10"}, NodeIPsOrPrimary("192.168.127.10", nil))
+	assert.Nil(t, NodeIPsOrPrimary("", nil))
+}
+
+func TestClusterNetworkReplacements(t *testing.T) {
+	seed := []seedreconfig.ClusterNetworkEntry{{CIDR: "10.128.0.0/14", HostPrefix: 23}, {CIDR: "fd01::/48", HostPrefix: 64}}
+
+	testcases := []struct {
+		name     string
+		desired  []seedreconfig.ClusterNetworkEntry
+		expected []IPReplacement
+	}{
+		{
+			name:     "Unchanged",
+			desired:  seed,
+			expected: nil,
+		},
+		{
+			name:     "Not provided",
+			desired:  nil,
+			expected: nil,
+		},
+		{
+			name:    "CIDR changed",
+			desired: []seedreconfig.ClusterNetworkEntry{{CIDR: "10.132.0.0/14", HostPrefix: 23}, {CIDR: "fd01::/48", HostPrefix: 64}},
+			expected: []IPReplacement{
+				{From: "10.128.0.0/14", To: "10.132.0.0/14"},
+			},
+		},
+		{
+			name:    "CIDR and host prefix changed",
+			desired: []seedreconfig.ClusterNetworkEntry{{CIDR: "fd02::/48", HostPrefix: 64}, {CIDR: "10.132.0.0/14", HostPrefix: 24}},
+			expected: []IPReplacement{
+				{From: "10.128.0.0/14/23", To: "10.132.0.0/14/24"},
+				{From: "10.128.0.0/14", To: "10.132.0.0/14"},
+				{From: "fd01::/48", To: "fd02::/48"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			replacements, err := ClusterNetworkReplacements(seed, tc.desired)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, replacements)
+		})
+	}
+}
+
+func TestServiceNetworkIPReplacements(t *testing.T) {
+	replacements, err := ServiceNetworkIPReplacements([]IPReplacement{
+		{From: "172.30.0.0/16", To: "172.31.0.0/16"},
+		{From: "fd02::/112", To: "fd03::/112"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []IPReplacement{
+		{From: "172.30.0.1", To: "172.31.0.1"},
+		{From: "172.30.0.10", To: "172.31.0.10"},
+		{From: "fd02::1", To: "fd03::1"},
+		{From: "fd02::a", To: "fd03::a"},
+	}, replacements)
+
+	_, err = ServiceNetworkIPReplacements([]IPReplacement{{From: "bad", To: "172.31.0.0/16"}})
+	assert.Error(t, err)
+}
+
+func TestTranslateIP(t *testing.T) {
+	cidr := func(s string) *net.IPNet {
+		_, ipNet, err := net.ParseCIDR(s)
+		assert.NoError(t, err)
+		return ipNet
+	}
+
+	testcases := []struct {
+		name          string
+		ip            string
+		from          string
+		to            string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "ipv4",
+			ip:       "172.30.12.34",
+			from:     "172.30.0.0/16",
+			to:       "10.96.0.0/12",
+			expected: "10.96.12.34",
+		},
+		{
+			name:     "ipv6",
+			ip:       "fd02::1:2",
+			from:     "fd02::/96",
+			to:       "fd03::/64",
+			expected: "fd03::1:2",
+		},
+		{
+			name:          "ip not in the from network",
+			ip:            "172.31.0.1",
+			from:          "172.30.0.0/16",
+			to:            "10.96.0.0/12",
+			expectedError: true,
+		},
+		{
+			name:          "to network too small",
+			ip:            "172.30.12.34",
+			from:          "172.30.0.0/16",
+			to:            "10.96.0.0/24",
+			expectedError: true,
+		},
+		{
+			name:          "overflow",
+			ip:            "172.30.12.34",
+			from:          "172.30.0.0/16",
+			to:            "255.255.255.0/24",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ip, err := TranslateIP(tc.ip, cidr(tc.from), cidr(tc.to), nil)
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			assert.Equal(t, tc.expected, ip)
+		})
+	}
+}
+
 func TestCopyFileIfExists(t *testing.T) {
 	testcases := []struct {
 		name          string
@@ -110,3 +290,49 @@ func TestCopyReplaceMirrorRegistry(t *testing.T) {
 		})
 	}
 }
+
+func TestMergePostPivotProgress(t *testing.T) {
+	ibu := &lcav1alpha1.ImageBasedUpgrade{}
+	progressFile := filepath.Join(t.TempDir(), "postpivot_progress.json")
+
+	// No progress file, nothing to merge
+	assert.NoError(t, MergePostPivotProgress(ibu, progressFile))
+	assert.Nil(t, ibu.Status.PostPivotSteps)
+
+	steps := []lcav1alpha1.PostPivotStep{
+		{Name: "recert"},
+		{Name: "set_cluster_id", Error: "cluster id is empty"},
+	}
+	assert.NoError(t, MarshalToFile(steps, progressFile))
+	assert.NoError(t, MergePostPivotProgress(ibu, progressFile))
+	assert.Equal(t, steps, ibu.Status.PostPivotSteps)
+
+	assert.NoError(t, os.WriteFile(progressFile, []byte("not json"), 0o600))
+	assert.Error(t, MergePostPivotProgress(ibu, progressFile))
+}
+
+func TestMergeRecertSummary(t *testing.T) {
+	ibu := &lcav1alpha1.ImageBasedUpgrade{}
+	digestFile := filepath.Join(t.TempDir(), "recert_summary_digest.json")
+
+	// No digest file, nothing to merge
+	assert.NoError(t, MergeRecertSummary(ibu, digestFile))
+	assert.Nil(t, ibu.Status.RecertSummary)
+
+	summary := &lcav1alpha1.RecertSummary{
+		Certificates: 120,
+		Keys:         40,
+		Renames:      []string{"hostname: seed -> sno"},
+		Timings:      map[string]string{"scan": "2.5s"},
+	}
+	assert.NoError(t, MarshalToFile(summary, digestFile))
+	assert.NoError(t, MergeRecertSummary(ibu, digestFile))
+	assert.Equal(t, summary, ibu.Status.RecertSummary)
+	assert.Equal(t, "Recert regenerated 120 certificates and 40 keys, renames: hostname: seed -> sno",
+		RecertSummaryMessage(ibu.Status.RecertSummary))
+	assert.Equal(t, "Recert dry-run would regenerate 120 certificates and 40 keys, renames: hostname: seed -> sno",
+		RecertDryRunSummaryMessage(ibu.Status.RecertSummary))
+
+	assert.NoError(t, os.WriteFile(digestFile, []byte("not json"), 0o600))
+	assert.Error(t, MergeRecertSummary(ibu, digestFile))
+}